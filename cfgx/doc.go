@@ -14,6 +14,8 @@
 //   - Diagnostics: WithOptionError lets wrappers surface invalid option state.
 //
 // Hook helpers:
+//   - RegisteredTypesHook decodes types added via RegisterTypeHook or RegisterType, e.g. every
+//     config.Optional[T] instantiation.
 //   - OptionalBoolHook requires registration via RegisterOptionalBoolType so cfgx can manipulate
 //     custom optional-bool implementations (config.OptionalBool registers itself during init).
 //   - DurationHook mirrors mapstructure's string-to-duration helper.
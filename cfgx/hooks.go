@@ -14,6 +14,7 @@ import (
 // OptionalBool exposes the behavior required by cfgx to manipulate optional boolean values.
 // Types that satisfy this interface (e.g., config.OptionalBool) can be registered via
 // RegisterOptionalBoolType so OptionalBoolHook can operate without importing the concrete type.
+// Other types, generic ones included, register through RegisterTypeHook.
 type OptionalBool interface {
 	Set(bool)
 	Unset()
@@ -74,9 +75,87 @@ func (r *optionalBoolRegistration) newPointer() OptionalBool {
 	return ob
 }
 
-// DefaultDecodeHooks returns the standard hook set (optional bool, duration, text unmarshaler).
+// TypeHook converts data into a value of type to, a decode target accepted by
+// the matcher the hook was registered with.
+type TypeHook func(data any, to reflect.Type) (any, error)
+
+type typeHookRegistration struct {
+	name  string
+	match func(reflect.Type) bool
+	hook  TypeHook
+}
+
+type typeHookRegistry struct {
+	mu      sync.RWMutex
+	entries []typeHookRegistration
+}
+
+var typeHooks typeHookRegistry
+
+// RegisterTypeHook registers hook for every decode target accepted by match, so
+// a single registration can cover all instantiations of a generic type (e.g.
+// config.Optional[T]). Registering a name again replaces the previous entry.
+func RegisterTypeHook(name string, match func(reflect.Type) bool, hook TypeHook) {
+	if name == "" || match == nil || hook == nil {
+		panic("cfgx: RegisterTypeHook requires a name, matcher and hook")
+	}
+	typeHooks.mu.Lock()
+	defer typeHooks.mu.Unlock()
+	entry := typeHookRegistration{name: name, match: match, hook: hook}
+	for i, existing := range typeHooks.entries {
+		if existing.name == name {
+			typeHooks.entries[i] = entry
+			return
+		}
+	}
+	typeHooks.entries = append(typeHooks.entries, entry)
+}
+
+// RegisterType registers hook for the type of sample and pointers to it.
+func RegisterType(sample any, hook TypeHook) {
+	if sample == nil {
+		panic("cfgx: nil sample provided to RegisterType")
+	}
+	valueType := reflect.TypeOf(sample)
+	if valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	RegisterTypeHook(valueType.String(), func(t reflect.Type) bool {
+		return t == valueType || t == reflect.PointerTo(valueType)
+	}, hook)
+}
+
+func (r *typeHookRegistry) lookup(to reflect.Type) TypeHook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, entry := range r.entries {
+		if entry.match(to) {
+			return entry.hook
+		}
+	}
+	return nil
+}
+
+// RegisteredTypesHook dispatches to the hooks added via RegisterTypeHook and
+// RegisterType.
+func RegisteredTypesHook() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if to == nil {
+			return data, nil
+		}
+		hook := typeHooks.lookup(to)
+		if hook == nil {
+			return data, nil
+		}
+		return hook(data, to)
+	}
+}
+
+// DefaultDecodeHooks returns the standard hook set (registered types, optional bool, duration,
+// text unmarshaler).
 func DefaultDecodeHooks() []mapstructure.DecodeHookFunc {
 	return []mapstructure.DecodeHookFunc{
+		RegisteredTypesHook(),
 		OptionalBoolHook(),
 		DurationHook(),
 		TextUnmarshalerHook(),
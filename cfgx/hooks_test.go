@@ -2,6 +2,7 @@ package cfgx_test
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -85,6 +86,64 @@ func TestTextUnmarshalerHook(t *testing.T) {
 	}
 }
 
+type hostPort struct {
+	Host string
+	Port string
+}
+
+func TestRegisterTypeHook(t *testing.T) {
+	cfgx.RegisterType(hostPort{}, func(data any, to reflect.Type) (any, error) {
+		raw, ok := data.(string)
+		if !ok {
+			return data, nil
+		}
+		host, port, _ := strings.Cut(raw, ":")
+		value := hostPort{Host: host, Port: port}
+		if to.Kind() == reflect.Ptr {
+			return &value, nil
+		}
+		return value, nil
+	})
+
+	type Config struct {
+		Primary hostPort  `mapstructure:"primary"`
+		Replica *hostPort `mapstructure:"replica"`
+	}
+
+	cfg, err := cfgx.Build[Config](map[string]any{
+		"primary": "db:5432",
+		"replica": "replica:5433",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Primary != (hostPort{Host: "db", Port: "5432"}) {
+		t.Fatalf("unexpected primary %+v", cfg.Primary)
+	}
+	if cfg.Replica == nil || cfg.Replica.Port != "5433" {
+		t.Fatalf("unexpected replica %+v", cfg.Replica)
+	}
+
+	_, err = cfgx.Build[Config](map[string]any{"primary": "db:5432"}, cfgx.WithoutDefaultHooks[Config]())
+	if err == nil {
+		t.Fatal("expected decode error without registered type hooks")
+	}
+}
+
+func TestOptionalGenericHook(t *testing.T) {
+	type Config struct {
+		Timeout config.Optional[time.Duration] `mapstructure:"timeout"`
+	}
+
+	cfg, err := cfgx.Build[Config](map[string]any{"timeout": "0s"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Timeout.IsSet() || cfg.Timeout.Value() != 0 {
+		t.Fatalf("expected explicit zero timeout, got %s", cfg.Timeout.String())
+	}
+}
+
 func ExampleRegisterOptionalBoolType() {
 	cfgx.RegisterOptionalBoolType(config.NewOptionalBoolUnset())
 	type Config struct {
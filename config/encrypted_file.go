@@ -0,0 +1,428 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+	"github.com/goliatone/go-errors"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+)
+
+// EncryptedFileMetadataKey is the top level key holding the algorithm, key ID
+// and MAC of an encrypted config file.
+const EncryptedFileMetadataKey = "_encryption"
+
+const (
+	encryptedFileVersion = 1
+	encryptedLeafPrefix  = "ENC["
+	encryptedLeafSuffix  = "]"
+	encryptedFileMACInfo = "go-config encrypted file mac"
+)
+
+// ErrEncryptedFileTampered is returned when the MAC of an encrypted config
+// file does not match its content.
+var ErrEncryptedFileTampered = stderrors.New("encrypted config file MAC mismatch")
+
+// EncryptFile encrypts every leaf value of a JSON, YAML or TOML document with
+// the key keyID. Keys and structure stay readable:
+//
+//	{"database": {"password": "ENC[AES256GCM:prod:...]"}, "_encryption": {...}}
+//
+// Each leaf is bound to its key path, and a MAC over all leaves and empty maps
+// or lists detects values that were changed, moved, added or removed.
+func EncryptFile(data []byte, fileType ConfigFileType, keyID string, keys KeyProvider) ([]byte, error) {
+	return encryptFile(data, fileType, solvers.EncryptionAlgorithmAES256GCM, keyID, keys, nil)
+}
+
+// encryptFile encrypts the leaves of data with keys and records algorithm,
+// keyID and extra in the encryption metadata.
+func encryptFile(data []byte, fileType ConfigFileType, algorithm, keyID string, keys KeyProvider, extra map[string]any) ([]byte, error) {
+	doc, err := parseConfigDocument(data, fileType)
+	if err != nil {
+		return nil, err
+	}
+	if _, encrypted := doc[EncryptedFileMetadataKey]; encrypted {
+		return nil, errors.New("config file is already encrypted", errors.CategoryBadInput).
+			WithTextCode("CONFIG_FILE_ALREADY_ENCRYPTED")
+	}
+
+	out, err := mapLeaves(doc, "", func(path string, leaf any) (any, error) {
+		if leaf == nil {
+			return nil, nil
+		}
+		plaintext, err := json.Marshal(leaf)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := solvers.SealValueWithAAD(plaintext, keyID, keys, []byte(path))
+		if err != nil {
+			return nil, err
+		}
+		return encryptedLeafPrefix + payload + encryptedLeafSuffix, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.CategoryBadInput, "failed to encrypt config file").
+			WithTextCode("CONFIG_ENCRYPT_FAILED").
+			WithMetadata(map[string]any{
+				"file_type": string(fileType),
+				"key_id":    keyID,
+			})
+	}
+
+	mac, err := encryptedFileMAC(out, algorithm, keyID, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.CategoryBadInput, "failed to encrypt config file").
+			WithTextCode("CONFIG_ENCRYPT_FAILED").
+			WithMetadata(map[string]any{
+				"file_type": string(fileType),
+				"key_id":    keyID,
+			})
+	}
+	meta := map[string]any{
+		"version":   encryptedFileVersion,
+		"algorithm": algorithm,
+		"key_id":    keyID,
+		"mac":       mac,
+	}
+	for key, value := range extra {
+		meta[key] = value
+	}
+	out[EncryptedFileMetadataKey] = meta
+
+	if fileType == FileTypeJSON {
+		encoded, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(encoded, '\n'), nil
+	}
+	return fileType.Parser().Marshal(out)
+}
+
+// DecryptFile verifies the MAC of a document produced by EncryptFile or
+// EncryptFileForAge and returns its decrypted values, without the encryption
+// metadata. Files encrypted for age need the keys from AgeIdentities.
+func DecryptFile(data []byte, fileType ConfigFileType, keys KeyProvider) (map[string]any, error) {
+	values, _, err := decryptFile(data, fileType, keys)
+	return values, err
+}
+
+// decryptFile is DecryptFile that also returns the key paths holding
+// encrypted values. A list with an encrypted item counts as one key path.
+func decryptFile(data []byte, fileType ConfigFileType, keys KeyProvider) (map[string]any, []string, error) {
+	doc, err := parseConfigDocument(data, fileType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta, ok := doc[EncryptedFileMetadataKey].(map[string]any)
+	if !ok {
+		return nil, nil, errors.New("config file is not encrypted", errors.CategoryBadInput).
+			WithTextCode("CONFIG_FILE_NOT_ENCRYPTED").
+			WithMetadata(map[string]any{
+				"metadata_key": EncryptedFileMetadataKey,
+			})
+	}
+	algorithm, _ := meta["algorithm"].(string)
+	version := fmt.Sprint(meta["version"])
+	supported := algorithm == solvers.EncryptionAlgorithmAES256GCM || algorithm == EncryptionAlgorithmAge
+	if !supported || version != strconv.Itoa(encryptedFileVersion) {
+		return nil, nil, errors.New("unsupported config file encryption", errors.CategoryBadInput).
+			WithTextCode("CONFIG_FILE_ENCRYPTION_UNSUPPORTED").
+			WithMetadata(map[string]any{
+				"algorithm": algorithm,
+				"version":   version,
+			})
+	}
+	keyID, _ := meta["key_id"].(string)
+	storedMAC, _ := meta["mac"].(string)
+	delete(doc, EncryptedFileMetadataKey)
+
+	if algorithm == EncryptionAlgorithmAge {
+		if keys, err = unwrapAgeDataKey(meta, keys); err != nil {
+			return nil, nil, errors.Wrap(err, errors.CategoryOperation, "failed to decrypt config file data key").
+				WithTextCode("CONFIG_DECRYPT_FAILED").
+				WithMetadata(map[string]any{
+					"algorithm": algorithm,
+				})
+		}
+	}
+
+	mac, err := encryptedFileMAC(doc, algorithm, keyID, keys)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.CategoryOperation, "failed to verify config file").
+			WithTextCode("CONFIG_DECRYPT_FAILED").
+			WithMetadata(map[string]any{
+				"key_id": keyID,
+			})
+	}
+	if !hmac.Equal([]byte(mac), []byte(storedMAC)) {
+		return nil, nil, errors.Wrap(ErrEncryptedFileTampered, errors.CategoryValidation, "config file failed integrity check").
+			WithTextCode("CONFIG_FILE_TAMPERED").
+			WithMetadata(map[string]any{
+				"key_id": keyID,
+			})
+	}
+
+	out, err := mapLeaves(doc, "", func(path string, leaf any) (any, error) {
+		text, ok := leaf.(string)
+		if !ok || !isEncryptedLeaf(text) {
+			return leaf, nil
+		}
+		payload := strings.TrimSuffix(strings.TrimPrefix(text, encryptedLeafPrefix), encryptedLeafSuffix)
+		plaintext, err := solvers.OpenValueWithAAD(payload, keys, []byte(path))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		value, err := decodeEncryptedLeaf(plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.CategoryOperation, "failed to decrypt config file").
+			WithTextCode("CONFIG_DECRYPT_FAILED").
+			WithMetadata(map[string]any{
+				"key_id": keyID,
+			})
+	}
+	return out, encryptedKeyPaths(doc, ""), nil
+}
+
+// decodeEncryptedLeaf decodes a decrypted leaf, keeping integers as int64
+// instead of the float64 encoding/json uses for every number.
+func decodeEncryptedLeaf(plaintext []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(plaintext))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return value, nil
+	}
+	if i, err := number.Int64(); err == nil {
+		return i, nil
+	}
+	return number.Float64()
+}
+
+// encryptedKeyPaths lists the key paths of doc whose value is an encrypted
+// leaf or a list holding one.
+func encryptedKeyPaths(doc map[string]any, path string) []string {
+	var paths []string
+	for key, value := range doc {
+		keyPath := joinKeyPath(path, key)
+		if child, ok := value.(map[string]any); ok {
+			paths = append(paths, encryptedKeyPaths(child, keyPath)...)
+			continue
+		}
+		if hasEncryptedLeaf(value) {
+			paths = append(paths, keyPath)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func hasEncryptedLeaf(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return isEncryptedLeaf(v)
+	case []any:
+		for _, item := range v {
+			if hasEncryptedLeaf(item) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, item := range v {
+			if hasEncryptedLeaf(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isEncryptedLeaf(text string) bool {
+	return strings.HasPrefix(text, encryptedLeafPrefix) && strings.HasSuffix(text, encryptedLeafSuffix)
+}
+
+// EncryptedFileProvider loads a file written by EncryptFile or
+// EncryptFileForAge, see DecryptFile for keys. The file type is
+// inferred from the extension, ignoring a trailing ".enc", e.g.
+// "config.yaml.enc". Keys holding encrypted values are added to
+// SensitiveKeys for the rest of the Load.
+func EncryptedFileProvider[C Validable](filepath string, keys KeyProvider, orders ...int) ProviderBuilder[C] {
+	filetype := inferConfigFiletype(filepath)
+
+	return func(c *Container[C]) (Provider, error) {
+		p := &Loader{
+			providerType: ProviderTypeLocalFile,
+			name:         string(ProviderTypeLocalFile) + ":" + filepath,
+			order:        getOrder(PriorityConfig, orders...),
+			load: func(ctx context.Context, k *koanf.Koanf) error {
+				c.logger.Debug("encrypted file provider", "filepath", filepath)
+				data, err := os.ReadFile(filepath)
+				if err == nil {
+					var (
+						values    map[string]any
+						encrypted []string
+					)
+					if values, encrypted, err = decryptFile(data, filetype, keys); err == nil {
+						err = k.Load(confmap.Provider(values, ""), nil, c.mergeOptions(ProviderTypeLocalFile)...)
+					}
+					for _, key := range encrypted {
+						c.markSensitiveResolvedKey(key)
+					}
+				}
+				if err != nil {
+					return errors.Wrap(err, errors.CategoryOperation, "failed to load configuration from encrypted file").
+						WithTextCode("FILE_LOAD_FAILED").
+						WithMetadata(map[string]any{
+							"filepath":  filepath,
+							"file_type": string(filetype),
+							"encrypted": true,
+						})
+				}
+				return nil
+			},
+		}
+		return p, nil
+	}
+}
+
+func parseConfigDocument(data []byte, fileType ConfigFileType) (map[string]any, error) {
+	if err := fileType.Valid(); err != nil {
+		return nil, err
+	}
+	doc, err := fileType.Parser().Unmarshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.CategoryBadInput, "failed to parse config file").
+			WithTextCode("CONFIG_PARSE_FAILED").
+			WithMetadata(map[string]any{
+				"file_type": string(fileType),
+			})
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	return doc, nil
+}
+
+// mapLeaves returns a copy of doc with fn applied to every value that is not
+// a map or a list. Leaf paths quote map keys, ["db"]["hosts"][0], so a list
+// item, a map key "0" and a key holding a dot never share a path.
+func mapLeaves(doc map[string]any, path string, fn func(path string, leaf any) (any, error)) (map[string]any, error) {
+	out := make(map[string]any, len(doc))
+	for key, value := range doc {
+		mapped, err := mapLeafValue(value, leafKeyPath(path, key), fn)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = mapped
+	}
+	return out, nil
+}
+
+func mapLeafValue(value any, path string, fn func(path string, leaf any) (any, error)) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		return mapLeaves(v, path, fn)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			mapped, err := mapLeafValue(item, leafIndexPath(path, i), fn)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = mapped
+		}
+		return out, nil
+	default:
+		return fn(path, value)
+	}
+}
+
+func leafKeyPath(path, key string) string {
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+func leafIndexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// encryptedFileMAC authenticates every leaf path and stored value of doc, and
+// every empty map or list, so values cannot be swapped, dropped or added, and
+// nodes cannot change kind, without detection.
+func encryptedFileMAC(doc map[string]any, algorithm, keyID string, keys KeyProvider) (string, error) {
+	if keys == nil {
+		return "", fmt.Errorf("no encryption key provider")
+	}
+	key, err := keys.Key(keyID)
+	if err != nil {
+		return "", err
+	}
+	derive := hmac.New(sha256.New, key)
+	derive.Write([]byte(encryptedFileMACInfo))
+	mac := hmac.New(sha256.New, derive.Sum(nil))
+
+	var lines []string
+	if err := encryptedFileMACLines(doc, "", &lines); err != nil {
+		return "", err
+	}
+	sort.Strings(lines)
+
+	fmt.Fprintf(mac, "%s\x00%s\x00%d\n", algorithm, keyID, encryptedFileVersion)
+	for _, line := range lines {
+		mac.Write([]byte(line + "\n"))
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// encryptedFileMACLines appends a path and value line for every leaf below
+// value, and a {} or [] line for every empty map or list.
+func encryptedFileMACLines(value any, path string, lines *[]string) error {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			*lines = append(*lines, path+"\x00{}")
+		}
+		for key, item := range v {
+			if err := encryptedFileMACLines(item, leafKeyPath(path, key), lines); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if len(v) == 0 {
+			*lines = append(*lines, path+"\x00[]")
+		}
+		for i, item := range v {
+			if err := encryptedFileMACLines(item, leafIndexPath(path, i), lines); err != nil {
+				return err
+			}
+		}
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		*lines = append(*lines, path+"\x00"+string(encoded))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+// EncryptionAlgorithmAge marks files written by EncryptFileForAge.
+const EncryptionAlgorithmAge = "age"
+
+// EncryptFileForAge encrypts every leaf value of a JSON, YAML or TOML
+// document for age recipients, like SOPS does: a random data key encrypts the
+// leaves with AES-256-GCM and signs the MAC, and age encrypts the data key
+// for every recipient into the encryption metadata. Any of the matching
+// identities, passed through AgeIdentities, decrypts the file.
+func EncryptFileForAge(data []byte, fileType ConfigFileType, recipients ...age.Recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("encrypt config file for age: no recipients")
+	}
+	dataKey, err := solvers.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapped bytes.Buffer
+	writer, err := age.Encrypt(&wrapped, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(dataKey); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return encryptFile(data, fileType, EncryptionAlgorithmAge, "", solvers.StaticKey(dataKey), map[string]any{
+		"data_key": base64.StdEncoding.EncodeToString(wrapped.Bytes()),
+	})
+}
+
+// AgeIdentities returns the keys that decrypt files written by
+// EncryptFileForAge, for DecryptFile and EncryptedFileProvider. Parse
+// identities with age.ParseIdentities or age.ParseX25519Identity.
+func AgeIdentities(identities ...age.Identity) KeyProvider {
+	return ageKeys{identities: identities}
+}
+
+type ageKeys struct {
+	identities []age.Identity
+}
+
+// Key fails: age identities only unwrap the data key of a file.
+func (k ageKeys) Key(string) ([]byte, error) {
+	return nil, stderrors.New("age identities only decrypt files written by EncryptFileForAge")
+}
+
+// unwrapAgeDataKey decrypts the data key stored in meta with the identities
+// in keys.
+func unwrapAgeDataKey(meta map[string]any, keys KeyProvider) (KeyProvider, error) {
+	identities, ok := keys.(ageKeys)
+	if !ok || len(identities.identities) == 0 {
+		return nil, fmt.Errorf("%w: age encrypted file needs config.AgeIdentities", solvers.ErrDecryptionFailed)
+	}
+	encoded, _ := meta["data_key"].(string)
+	wrapped, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(wrapped) == 0 {
+		return nil, fmt.Errorf("%w: invalid age data key", solvers.ErrDecryptionFailed)
+	}
+	reader, err := age.Decrypt(bytes.NewReader(wrapped), identities.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", solvers.ErrDecryptionFailed, err)
+	}
+	dataKey, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", solvers.ErrDecryptionFailed, err)
+	}
+	return solvers.StaticKey(dataKey), nil
+}
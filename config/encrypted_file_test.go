@@ -0,0 +1,221 @@
+package config
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+type encryptedFileConfig struct {
+	Name     string `koanf:"name"`
+	Database struct {
+		Host     string `koanf:"host"`
+		Port     int    `koanf:"port"`
+		Password string `koanf:"password"`
+	} `koanf:"database"`
+	Tags []string `koanf:"tags"`
+}
+
+func (encryptedFileConfig) Validate() error { return nil }
+
+var encryptedFileFixtures = map[ConfigFileType]string{
+	FileTypeJSON: `{"name": "api", "database": {"host": "db.local", "port": 5432, "password": "hunter2"}, "tags": ["a", "b"]}`,
+	FileTypeYAML: "name: api\ndatabase:\n  host: db.local\n  port: 5432\n  password: hunter2\ntags:\n  - a\n  - b\n",
+	FileTypeTOML: "name = \"api\"\ntags = [\"a\", \"b\"]\n\n[database]\nhost = \"db.local\"\nport = 5432\npassword = \"hunter2\"\n",
+}
+
+func testKeyring(t *testing.T) Keyring {
+	t.Helper()
+	key, err := solvers.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return Keyring{"prod": solvers.StaticKey(key)}
+}
+
+func TestEncryptedFileProvider_RoundTrip(t *testing.T) {
+	keys := testKeyring(t)
+
+	for fileType, plain := range encryptedFileFixtures {
+		t.Run(string(fileType), func(t *testing.T) {
+			encrypted, err := EncryptFile([]byte(plain), fileType, "prod", keys)
+			if err != nil {
+				t.Fatalf("encrypt failed: %v", err)
+			}
+			for _, leaked := range []string{"hunter2", "db.local", "5432"} {
+				if strings.Contains(string(encrypted), leaked) {
+					t.Fatalf("encrypted file leaked %q:\n%s", leaked, encrypted)
+				}
+			}
+			for _, visible := range []string{"database", "password", "tags", EncryptedFileMetadataKey} {
+				if !strings.Contains(string(encrypted), visible) {
+					t.Fatalf("expected key %q to stay readable:\n%s", visible, encrypted)
+				}
+			}
+
+			path := filepath.Join(t.TempDir(), "config."+string(fileType)+".enc")
+			if err := os.WriteFile(path, encrypted, 0o600); err != nil {
+				t.Fatalf("write file: %v", err)
+			}
+
+			cfg := New(encryptedFileConfig{}).
+				WithProvider(EncryptedFileProvider[encryptedFileConfig](path, keys))
+			if err := cfg.Load(context.Background()); err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			got := cfg.Raw()
+			if got.Name != "api" || got.Database.Host != "db.local" || got.Database.Port != 5432 ||
+				got.Database.Password != "hunter2" || strings.Join(got.Tags, ",") != "a,b" {
+				t.Fatalf("unexpected config: %+v", got)
+			}
+			if port, ok := cfg.K.Get("database.port").(int64); !ok || port != 5432 {
+				t.Fatalf("expected port to decrypt as int64, got %T %v", cfg.K.Get("database.port"), cfg.K.Get("database.port"))
+			}
+			for _, key := range []string{"name", "database.host", "database.port", "database.password", "tags"} {
+				if !cfg.isSensitiveKey(key) {
+					t.Fatalf("expected decrypted key %q to be sensitive, got %v", key, cfg.SensitiveKeys())
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptFile_DetectsTampering(t *testing.T) {
+	keys := testKeyring(t)
+	encrypted, err := EncryptFile([]byte(encryptedFileFixtures[FileTypeJSON]), FileTypeJSON, "prod", keys)
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	doc, err := parseConfigDocument(encrypted, FileTypeJSON)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	database := doc["database"].(map[string]any)
+
+	tamper := func(mutate func(doc map[string]any)) []byte {
+		copied, err := parseConfigDocument(encrypted, FileTypeJSON)
+		if err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+		mutate(copied)
+		out, err := FileTypeJSON.Parser().Marshal(copied)
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+		return out
+	}
+
+	cases := map[string][]byte{
+		"swapped values": tamper(func(d map[string]any) {
+			db := d["database"].(map[string]any)
+			db["host"], db["password"] = database["password"], database["host"]
+		}),
+		"removed value": tamper(func(d map[string]any) {
+			delete(d["database"].(map[string]any), "password")
+		}),
+		"added value": tamper(func(d map[string]any) {
+			d["debug"] = true
+		}),
+		"list turned into map": tamper(func(d map[string]any) {
+			tags := d["tags"].([]any)
+			d["tags"] = map[string]any{"0": tags[0], "1": tags[1]}
+		}),
+		"nested keys flattened": tamper(func(d map[string]any) {
+			for key, value := range d["database"].(map[string]any) {
+				d["database."+key] = value
+			}
+			delete(d, "database")
+		}),
+		"added empty map": tamper(func(d map[string]any) {
+			d["extra"] = map[string]any{}
+		}),
+		"added empty list": tamper(func(d map[string]any) {
+			d["extra"] = []any{}
+		}),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecryptFile(data, FileTypeJSON, keys)
+			if !stderrors.Is(err, ErrEncryptedFileTampered) {
+				t.Fatalf("expected tamper error, got %v", err)
+			}
+		})
+	}
+
+	if _, err := DecryptFile(encrypted, FileTypeJSON, testKeyring(t)); err == nil {
+		t.Fatalf("expected decrypt with another key to fail")
+	}
+	if _, err := DecryptFile([]byte(encryptedFileFixtures[FileTypeJSON]), FileTypeJSON, keys); err == nil {
+		t.Fatalf("expected plain file to be rejected")
+	}
+	if _, err := EncryptFile(encrypted, FileTypeJSON, "prod", keys); err == nil {
+		t.Fatalf("expected encrypted file to be rejected")
+	}
+}
+
+func TestEncryptedFileProvider_Age(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+
+	encrypted, err := EncryptFileForAge([]byte(encryptedFileFixtures[FileTypeYAML]), FileTypeYAML, identity.Recipient(), other.Recipient())
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if strings.Contains(string(encrypted), "hunter2") || !strings.Contains(string(encrypted), "password") {
+		t.Fatalf("unexpected encrypted file:\n%s", encrypted)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml.enc")
+	if err := os.WriteFile(path, encrypted, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	for _, id := range []*age.X25519Identity{identity, other} {
+		cfg := New(encryptedFileConfig{}).
+			WithProvider(EncryptedFileProvider[encryptedFileConfig](path, AgeIdentities(id)))
+		if err := cfg.Load(context.Background()); err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		if got := cfg.Raw(); got.Database.Password != "hunter2" || got.Database.Port != 5432 {
+			t.Fatalf("unexpected config: %+v", got)
+		}
+		if !cfg.isSensitiveKey("database.password") {
+			t.Fatalf("expected decrypted key to be sensitive")
+		}
+	}
+
+	stranger, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+	if _, err := DecryptFile(encrypted, FileTypeYAML, AgeIdentities(stranger)); !stderrors.Is(err, solvers.ErrDecryptionFailed) {
+		t.Fatalf("expected decrypt with another identity to fail, got %v", err)
+	}
+	if _, err := DecryptFile(encrypted, FileTypeYAML, testKeyring(t)); !stderrors.Is(err, solvers.ErrDecryptionFailed) {
+		t.Fatalf("expected decrypt with AES keys to fail, got %v", err)
+	}
+
+	doc, err := parseConfigDocument(encrypted, FileTypeYAML)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	doc["debug"] = true
+	tampered, err := FileTypeYAML.Parser().Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if _, err := DecryptFile(tampered, FileTypeYAML, AgeIdentities(identity)); !stderrors.Is(err, ErrEncryptedFileTampered) {
+		t.Fatalf("expected tamper error, got %v", err)
+	}
+}
@@ -0,0 +1,453 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+// ExpressionFunctionGroup selects a set of built-in expression functions.
+type ExpressionFunctionGroup string
+
+const (
+	// ExpressionFunctionsEnv provides env(name, default), hostname() and
+	// cpu_count().
+	ExpressionFunctionsEnv ExpressionFunctionGroup = "env"
+	// ExpressionFunctionsFile provides file(path), reading a file relative to
+	// the working directory, or in a container from the file system of the
+	// URI solver.
+	ExpressionFunctionsFile ExpressionFunctionGroup = "file"
+	// ExpressionFunctionsEncoding provides base64encode(s), base64decode(s)
+	// and sha256(s).
+	ExpressionFunctionsEncoding ExpressionFunctionGroup = "encoding"
+	// ExpressionFunctionsUnits provides parse_duration("5m") and
+	// bytes("10MB").
+	ExpressionFunctionsUnits ExpressionFunctionGroup = "units"
+	// ExpressionFunctionsStrings provides str_join(list, sep), str_split(s,
+	// sep) and coalesce(values...).
+	ExpressionFunctionsStrings ExpressionFunctionGroup = "strings"
+	// ExpressionFunctionsTime provides time_now() and format_time(t, layout).
+	ExpressionFunctionsTime ExpressionFunctionGroup = "time"
+	// ExpressionFunctionsID provides uuid().
+	ExpressionFunctionsID ExpressionFunctionGroup = "id"
+	// ExpressionFunctionsURL provides url_join(base, elems...) and
+	// url_query(base, params).
+	ExpressionFunctionsURL ExpressionFunctionGroup = "url"
+)
+
+// ExpressionFunctionGroups lists every built-in expression function group.
+func ExpressionFunctionGroups() []ExpressionFunctionGroup {
+	return []ExpressionFunctionGroup{
+		ExpressionFunctionsEnv,
+		ExpressionFunctionsFile,
+		ExpressionFunctionsEncoding,
+		ExpressionFunctionsUnits,
+		ExpressionFunctionsStrings,
+		ExpressionFunctionsTime,
+		ExpressionFunctionsID,
+		ExpressionFunctionsURL,
+	}
+}
+
+var expressionLibrary = map[ExpressionFunctionGroup]map[string]ExpressionFunction{
+	ExpressionFunctionsEnv: {
+		"env":       exprEnv,
+		"hostname":  exprHostname,
+		"cpu_count": exprCPUCount,
+	},
+	ExpressionFunctionsFile: {
+		"file": exprFile,
+	},
+	ExpressionFunctionsEncoding: {
+		"base64encode": exprBase64Encode,
+		"base64decode": exprBase64Decode,
+		"sha256":       exprSHA256,
+	},
+	ExpressionFunctionsUnits: {
+		"parse_duration": exprDuration,
+		"bytes":          exprBytes,
+	},
+	ExpressionFunctionsStrings: {
+		"str_join":  exprJoin,
+		"str_split": exprSplit,
+		"coalesce":  exprCoalesce,
+	},
+	ExpressionFunctionsTime: {
+		"time_now":    exprNow,
+		"format_time": exprFormatTime,
+	},
+	ExpressionFunctionsID: {
+		"uuid": exprUUID,
+	},
+	ExpressionFunctionsURL: {
+		"url_join":  exprURLJoin,
+		"url_query": exprURLQuery,
+	},
+}
+
+// ExpressionFunctions returns the built-in expression functions of the given
+// groups keyed by name, or of every group when none is given. Unknown groups
+// are ignored.
+func ExpressionFunctions(groups ...ExpressionFunctionGroup) map[string]ExpressionFunction {
+	if len(groups) == 0 {
+		groups = ExpressionFunctionGroups()
+	}
+
+	out := map[string]ExpressionFunction{}
+	for _, group := range groups {
+		for name, fn := range expressionLibrary[group] {
+			out[name] = fn
+		}
+	}
+	return out
+}
+
+// WithExpressionLibrary registers the built-in expression functions of the
+// given groups, or of every group when none is given. Functions already
+// registered with WithExpressionFunction keep precedence. Names avoid the
+// expression engine builtins, such as join or now, which would shadow them.
+func (c *Container[C]) WithExpressionLibrary(groups ...ExpressionFunctionGroup) *Container[C] {
+	names := make([]string, 0)
+	functions := ExpressionFunctions(groups...)
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := c.expressionFunctions[name]; ok {
+			continue
+		}
+		fn := functions[name]
+		if name == "file" {
+			fn = c.exprURIFile
+		}
+		c.WithExpressionFunction(name, fn)
+	}
+	return c
+}
+
+func exprEnv(args ...any) (any, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("env expects a name and an optional default")
+	}
+	name, err := stringArg("env", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	if len(args) == 2 {
+		return args[1], nil
+	}
+	return nil, fmt.Errorf("env: %q is not set", name)
+}
+
+func exprHostname(args ...any) (any, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("hostname expects no arguments")
+	}
+	return os.Hostname()
+}
+
+func exprCPUCount(args ...any) (any, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("cpu_count expects no arguments")
+	}
+	return runtime.NumCPU(), nil
+}
+
+func exprFile(args ...any) (any, error) {
+	return readExprFile(os.DirFS("."), args...)
+}
+
+// exprURIFile is file(path) reading from the file system of the first URI
+// solver, so file(x), ${file:x} and @file://x see the same files.
+func (c *Container[C]) exprURIFile(args ...any) (any, error) {
+	fsys := firstURISolverFS(c.solvers)
+	if fsys == nil {
+		fsys = os.DirFS(".")
+	}
+	return readExprFile(fsys, args...)
+}
+
+func readExprFile(fsys fs.FS, args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("file expects a path")
+	}
+	path, err := stringArg("file", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return solvers.SolveFileProtocol(fsys, path)
+}
+
+func exprBase64Encode(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("base64encode expects one argument")
+	}
+	value, err := stringArg("base64encode", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(value)), nil
+}
+
+func exprBase64Decode(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("base64decode expects one argument")
+	}
+	value, err := stringArg("base64decode", args[0])
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("base64decode: %w", err)
+	}
+	return string(decoded), nil
+}
+
+func exprSHA256(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("sha256 expects one argument")
+	}
+	value, err := stringArg("sha256", args[0])
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func exprDuration(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("parse_duration expects one argument")
+	}
+	value, err := stringArg("parse_duration", args[0])
+	if err != nil {
+		return nil, err
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("parse_duration: %w", err)
+	}
+	return d, nil
+}
+
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// exprBytes parses a size such as "10MB" or "512KiB" into a byte count. KB,
+// MB, GB and TB are decimal; KiB, MiB, GiB and TiB are binary.
+func exprBytes(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("bytes expects one argument")
+	}
+	value, err := stringArg("bytes", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	value = strings.TrimSpace(value)
+	split := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number, unit := value, ""
+	if split != -1 {
+		number, unit = value[:split], strings.TrimSpace(value[split:])
+	}
+
+	multiplier, ok := byteUnits[strings.ToLower(unit)]
+	if !ok {
+		return nil, fmt.Errorf("bytes: unknown unit %q in %q", unit, value)
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("bytes: invalid size %q", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+func exprJoin(args ...any) (any, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("str_join expects a list and an optional separator")
+	}
+	sep := ""
+	if len(args) == 2 {
+		var err error
+		if sep, err = stringArg("str_join", args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	list := reflect.ValueOf(args[0])
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nil, fmt.Errorf("str_join: expected a list, got %T", args[0])
+	}
+	parts := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		parts = append(parts, fmt.Sprint(list.Index(i).Interface()))
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func exprSplit(args ...any) (any, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("str_split expects a string and a separator")
+	}
+	value, err := stringArg("str_split", args[0])
+	if err != nil {
+		return nil, err
+	}
+	sep, err := stringArg("str_split", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(value, sep), nil
+}
+
+// exprCoalesce returns the first argument that is neither nil nor an empty
+// string.
+func exprCoalesce(args ...any) (any, error) {
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		if str, ok := arg.(string); ok && str == "" {
+			continue
+		}
+		return arg, nil
+	}
+	return nil, nil
+}
+
+func exprNow(args ...any) (any, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("time_now expects no arguments")
+	}
+	return time.Now(), nil
+}
+
+// exprFormatTime formats a time.Time or RFC 3339 string using a Go layout,
+// RFC 3339 by default.
+func exprFormatTime(args ...any) (any, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("format_time expects a time and an optional layout")
+	}
+
+	var t time.Time
+	switch v := args[0].(type) {
+	case time.Time:
+		t = v
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("format_time: %w", err)
+		}
+		t = parsed
+	default:
+		return nil, fmt.Errorf("format_time: expected a time, got %T", args[0])
+	}
+
+	layout := time.RFC3339
+	if len(args) == 2 {
+		var err error
+		if layout, err = stringArg("format_time", args[1]); err != nil {
+			return nil, err
+		}
+	}
+	return t.Format(layout), nil
+}
+
+// exprUUID returns a random (version 4) UUID.
+func exprUUID(args ...any) (any, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("uuid expects no arguments")
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, fmt.Errorf("uuid: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+func exprURLJoin(args ...any) (any, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("url_join expects a base url")
+	}
+	base, err := stringArg("url_join", args[0])
+	if err != nil {
+		return nil, err
+	}
+	elems := make([]string, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		elems = append(elems, fmt.Sprint(arg))
+	}
+	joined, err := url.JoinPath(base, elems...)
+	if err != nil {
+		return nil, fmt.Errorf("url_join: %w", err)
+	}
+	return joined, nil
+}
+
+// exprURLQuery adds params to the query string of base, keeping existing
+// parameters.
+func exprURLQuery(args ...any) (any, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("url_query expects a base url and a map of params")
+	}
+	base, err := stringArg("url_query", args[0])
+	if err != nil {
+		return nil, err
+	}
+	params, ok := args[1].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("url_query: expected a map of params, got %T", args[1])
+	}
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("url_query: %w", err)
+	}
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, fmt.Sprint(value))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func stringArg(fn string, arg any) (string, error) {
+	str, ok := arg.(string)
+	if !ok {
+		return "", fmt.Errorf("%s: expected a string argument, got %T", fn, arg)
+	}
+	return str, nil
+}
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+type expressionLibraryConfig struct {
+	App struct {
+		Name    string        `koanf:"name"`
+		Host    string        `koanf:"host"`
+		Workers int           `koanf:"workers"`
+		Timeout time.Duration `koanf:"timeout"`
+		MaxBody int64         `koanf:"max_body"`
+		Token   string        `koanf:"token"`
+		Digest  string        `koanf:"digest"`
+		APIURL  string        `koanf:"api_url"`
+	} `koanf:"app"`
+}
+
+func (c *expressionLibraryConfig) Validate() error { return nil }
+
+func TestContainerWithExpressionLibrary_ResolvesTypedValues(t *testing.T) {
+	t.Setenv("EXPR_LIB_NAME", "billing")
+
+	cfg := &expressionLibraryConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*expressionLibraryConfig](map[string]any{
+			"app": map[string]any{
+				"name":     `{{ env("EXPR_LIB_NAME") }}`,
+				"host":     `{{ coalesce(env("EXPR_LIB_HOST", ""), "localhost") }}`,
+				"workers":  "{{ cpu_count() }}",
+				"timeout":  `{{ parse_duration("1m30s") }}`,
+				"max_body": `{{ bytes("10MB") }}`,
+				"token":    `{{ base64decode("c2VjcmV0") }}`,
+				"digest":   `{{ sha256("abc") }}`,
+				"api_url":  `{{ url_join("https://api.example.com", "v1", "users") }}`,
+			},
+		})).
+		WithExpressionLibrary().
+		WithStrictExpressions()
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte("abc"))
+	if cfg.App.Name != "billing" {
+		t.Fatalf("expected env lookup, got %q", cfg.App.Name)
+	}
+	if cfg.App.Host != "localhost" {
+		t.Fatalf("expected coalesce fallback, got %q", cfg.App.Host)
+	}
+	if cfg.App.Workers != runtime.NumCPU() {
+		t.Fatalf("expected %d workers, got %d", runtime.NumCPU(), cfg.App.Workers)
+	}
+	if cfg.App.Timeout != 90*time.Second {
+		t.Fatalf("expected 90s timeout, got %s", cfg.App.Timeout)
+	}
+	if cfg.App.MaxBody != 10_000_000 {
+		t.Fatalf("expected 10MB in bytes, got %d", cfg.App.MaxBody)
+	}
+	if cfg.App.Token != "secret" {
+		t.Fatalf("expected decoded token, got %q", cfg.App.Token)
+	}
+	if cfg.App.Digest != hex.EncodeToString(sum[:]) {
+		t.Fatalf("expected sha256 digest, got %q", cfg.App.Digest)
+	}
+	if cfg.App.APIURL != "https://api.example.com/v1/users" {
+		t.Fatalf("expected joined url, got %q", cfg.App.APIURL)
+	}
+}
+
+func TestContainerWithExpressionLibrary_NotShadowedByBuiltins(t *testing.T) {
+	cfg := &expressionLibraryConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*expressionLibraryConfig](map[string]any{
+			"app": map[string]any{
+				"name": `{{ str_join(str_split("a-b-c", "-"), "+") }}`,
+				"host": `{{ format_time(time_now(), "2006") }}`,
+			},
+		})).
+		WithExpressionLibrary().
+		WithStrictExpressions()
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.App.Name != "a+b+c" {
+		t.Fatalf("expected library str_join and str_split, got %q", cfg.App.Name)
+	}
+	if cfg.App.Host != time.Now().Format("2006") {
+		t.Fatalf("expected library time_now, got %q", cfg.App.Host)
+	}
+}
+
+func TestContainerWithExpressionLibrary_SelectsGroups(t *testing.T) {
+	cfg := &expressionLibraryConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*expressionLibraryConfig](map[string]any{
+			"app": map[string]any{
+				"token":  `{{ base64decode("c2VjcmV0") }}`,
+				"digest": "{{ uuid() }}",
+			},
+		})).
+		WithExpressionLibrary(ExpressionFunctionsEncoding)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if cfg.App.Token != "secret" {
+		t.Fatalf("expected encoding group to resolve, got %q", cfg.App.Token)
+	}
+	if cfg.App.Digest != "{{ uuid() }}" {
+		t.Fatalf("expected id group to stay unregistered, got %q", cfg.App.Digest)
+	}
+}
+
+func TestContainerWithExpressionLibrary_FileReadsURISolverFS(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token.txt"), []byte("t0ken\n"), 0o600); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+
+	cfg := &expressionLibraryConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithExpressionLibrary(ExpressionFunctionsFile).
+		WithSolvers(
+			solvers.NewVariablesSolver("${", "}"),
+			solvers.NewURISolverWithFS("@", "://", os.DirFS(dir)),
+			solvers.NewExpressionSolver("{{", "}}"),
+		).
+		WithProvider(DefaultValuesProvider[*expressionLibraryConfig](map[string]any{
+			"app": map[string]any{
+				"name":  "${file:token.txt}",
+				"host":  "@file://token.txt",
+				"token": `{{ file("token.txt") }}`,
+			},
+		}))
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.App.Name != "t0ken" || cfg.App.Host != "t0ken" || cfg.App.Token != "t0ken" {
+		t.Fatalf("expected every file reference to read the URI solver FS, got %+v", cfg.App)
+	}
+}
+
+func TestContainerWithExpressionLibrary_KeepsRegisteredFunctions(t *testing.T) {
+	cfg := &expressionLibraryConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*expressionLibraryConfig](map[string]any{
+			"app": map[string]any{
+				"host": "{{ hostname() }}",
+			},
+		})).
+		WithExpressionFunction("hostname", func(args ...any) (any, error) {
+			return "custom-host", nil
+		}).
+		WithExpressionLibrary(ExpressionFunctionsEnv)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if cfg.App.Host != "custom-host" {
+		t.Fatalf("expected registered function to win, got %q", cfg.App.Host)
+	}
+}
+
+func TestExpressionFunctions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	host, _ := os.Hostname()
+	fixed := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	functions := ExpressionFunctions()
+
+	tests := []struct {
+		name string
+		fn   string
+		args []any
+		want any
+	}{
+		{name: "env default", fn: "env", args: []any{"EXPR_LIB_MISSING", "fallback"}, want: "fallback"},
+		{name: "hostname", fn: "hostname", want: host},
+		{name: "cpu count", fn: "cpu_count", want: runtime.NumCPU()},
+		{name: "file", fn: "file", args: []any{"secret.txt"}, want: "s3cret"},
+		{name: "base64 encode", fn: "base64encode", args: []any{"secret"}, want: "c2VjcmV0"},
+		{name: "base64 decode", fn: "base64decode", args: []any{"c2VjcmV0"}, want: "secret"},
+		{name: "parse duration", fn: "parse_duration", args: []any{"5m"}, want: 5 * time.Minute},
+		{name: "bytes decimal", fn: "bytes", args: []any{"10MB"}, want: int64(10_000_000)},
+		{name: "bytes binary", fn: "bytes", args: []any{"1.5 KiB"}, want: int64(1536)},
+		{name: "bytes plain", fn: "bytes", args: []any{"512"}, want: int64(512)},
+		{name: "str join", fn: "str_join", args: []any{[]any{"a", 1, "c"}, ","}, want: "a,1,c"},
+		{name: "coalesce", fn: "coalesce", args: []any{nil, "", "first", "second"}, want: "first"},
+		{name: "coalesce empty", fn: "coalesce", args: []any{nil, ""}, want: nil},
+		{name: "format time", fn: "format_time", args: []any{fixed, "2006-01-02"}, want: "2024-03-01"},
+		{name: "format time string", fn: "format_time", args: []any{"2024-03-01T12:30:00Z", "15:04"}, want: "12:30"},
+		{name: "url join", fn: "url_join", args: []any{"https://api.example.com/", "v1", "users/"}, want: "https://api.example.com/v1/users/"},
+		{name: "url query", fn: "url_query", args: []any{"https://api.example.com?a=1", map[string]any{"b": 2}}, want: "https://api.example.com?a=1&b=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, ok := functions[tt.fn]
+			if !ok {
+				t.Fatalf("function %q not registered", tt.fn)
+			}
+			got, err := fn(tt.args...)
+			if err != nil {
+				t.Fatalf("%s failed: %v", tt.fn, err)
+			}
+			if got != tt.want {
+				t.Fatalf("%s: expected %#v, got %#v", tt.fn, tt.want, got)
+			}
+		})
+	}
+
+	split, err := functions["str_split"]("a,b", ",")
+	if err != nil {
+		t.Fatalf("str_split failed: %v", err)
+	}
+	if parts, ok := split.([]string); !ok || len(parts) != 2 || parts[0] != "a" || parts[1] != "b" {
+		t.Fatalf("unexpected str_split result %#v", split)
+	}
+
+	id, err := functions["uuid"]()
+	if err != nil {
+		t.Fatalf("uuid failed: %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id.(string)) {
+		t.Fatalf("expected v4 uuid, got %q", id)
+	}
+
+	if _, err := functions["time_now"](); err != nil {
+		t.Fatalf("time_now failed: %v", err)
+	}
+}
+
+func TestExpressionFunctions_Errors(t *testing.T) {
+	functions := ExpressionFunctions()
+
+	tests := []struct {
+		name string
+		fn   string
+		args []any
+	}{
+		{name: "env unset", fn: "env", args: []any{"EXPR_LIB_MISSING"}},
+		{name: "file escape", fn: "file", args: []any{"../secret.txt"}},
+		{name: "base64 invalid", fn: "base64decode", args: []any{"!!"}},
+		{name: "parse duration invalid", fn: "parse_duration", args: []any{"soon"}},
+		{name: "bytes unit", fn: "bytes", args: []any{"10XB"}},
+		{name: "bytes number", fn: "bytes", args: []any{"MB"}},
+		{name: "str join not list", fn: "str_join", args: []any{"a", ","}},
+		{name: "format time type", fn: "format_time", args: []any{42}},
+		{name: "non string arg", fn: "sha256", args: []any{42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := functions[tt.fn](tt.args...); err == nil {
+				t.Fatalf("expected %s to fail", tt.fn)
+			}
+		})
+	}
+}
+
+func TestExpressionFunctions_Groups(t *testing.T) {
+	functions := ExpressionFunctions(ExpressionFunctionsUnits, ExpressionFunctionsURL)
+	if len(functions) != 4 {
+		t.Fatalf("expected 4 functions, got %d", len(functions))
+	}
+	for _, name := range []string{"parse_duration", "bytes", "url_join", "url_query"} {
+		if _, ok := functions[name]; !ok {
+			t.Fatalf("expected %q in selected groups", name)
+		}
+	}
+}
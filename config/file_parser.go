@@ -55,7 +55,12 @@ const (
 	FileTypeJSON ConfigFileType = "json"
 )
 
+// inferConfigFiletype uses the file extension, ignoring a trailing ".enc" as
+// used by encrypted files (config.yaml.enc).
 func inferConfigFiletype(path string, defaultFileType ...ConfigFileType) ConfigFileType {
+	if strings.EqualFold(filepath.Ext(path), ".enc") {
+		path = path[:len(path)-len(".enc")]
+	}
 	ext := filepath.Ext(path)
 	switch strings.ToLower(ext) {
 	case ".toml":
@@ -19,6 +19,8 @@ func TestInferConfigFiletype(t *testing.T) {
 		{"testdata/fileparser/config.yaml", FileTypeYAML},
 		{"testdata/fileparser/config.yml", FileTypeYAML},
 		{"testdata/fileparser/config.toml", FileTypeTOML},
+		{"testdata/fileparser/config.yaml.enc", FileTypeYAML},
+		{"testdata/fileparser/config.toml.ENC", FileTypeTOML},
 		// unknown extension should default to JSON
 		// unless a default is provided
 		{"testdata/fileparser/config.unknown", FileTypeJSON},
@@ -92,13 +92,34 @@ type Container[C Validable] struct {
 	keyedStringTransformers  map[string][]StringTransformer
 	normalizers              []Normalizer[C]
 	validators               []Validator[C]
+	tagValidation            bool
+	validationRules          map[string]ValidationRule
 	strictMerge              bool
+	deletePolicy             DeletePolicy
+	mergeStrategies          mergeStrategies
+	mergeConflictMode        MergeConflictMode
+	mergeConflictState       *mergeConflictState
+	keyOrigins               *keyOrigins
+	mergeKeepState           *mergeKeepState
+	providerTrust            map[ProviderType]int
+	sensitiveKeys            []string
+	encryptionKeys           KeyProvider
+	execOptions              *ExecOptions
+	httpOptions              *HTTPOptions
+	uriCache                 *solvers.URICache
+	resolvedSensitiveKeys    map[string]struct{}
 	loadTimeout              time.Duration
 	delimiter                string
 	configPath               string
 	solvers                  []solvers.ConfigSolver
 	solverPasses             int
+	solverDependencyGraph    bool
+	refDirectives            bool
+	solverTrace              func(TraceEvent)
+	unresolvedReferenceCheck bool
 	expressionFunctions      map[string]ExpressionFunction
+	strictExpressions        bool
+	assertions               bool
 	logger                   logger.Logger
 
 	loaders []ProviderBuilder[C]
@@ -221,6 +242,24 @@ func (c *Container[C]) WithSolvers(slvrs ...solvers.ConfigSolver) *Container[C]
 	return c
 }
 
+// WithRefDirectives resolves $ref directives, e.g.
+// {"cache": {"$ref": "#/shared/redis", "db": 1}}, by running a ref solver
+// before the other solvers. It is off by default so embedded JSON Schema or
+// OpenAPI fragments load unchanged. A ref solver added with WithSolvers is
+// used as is.
+func (c *Container[C]) WithRefDirectives() *Container[C] {
+	c.refDirectives = true
+	return c
+}
+
+// WithSolverDependencyGraph resolves keys in dependency order in a single
+// pass instead of repeating every solver. WithSolverPasses is ignored and
+// reference cycles fail Load with CONFIG_REFERENCE_CYCLE.
+func (c *Container[C]) WithSolverDependencyGraph() *Container[C] {
+	c.solverDependencyGraph = true
+	return c
+}
+
 // WithSolverPasses sets the maximum number of solver passes (minimum 1).
 func (c *Container[C]) WithSolverPasses(passes int) *Container[C] {
 	if passes < 1 {
@@ -260,7 +299,9 @@ func New[C Validable](c C) *Container[C] {
 		configPath:          DefaultConfigFilepath,
 		logger:              logger.NewDefaultLogger("config"),
 		solverPasses:        1,
+		assertions:          true,
 		solvers: []solvers.ConfigSolver{
+			solvers.NewExtendsSolver("$extends", MergeWithBooleanPrecedence),
 			solvers.NewVariablesSolver("${", "}"),
 			solvers.NewURISolver("@", "://"),
 			solvers.NewExpressionSolver("{{", "}}"),
@@ -311,12 +352,23 @@ func (c *Container[C]) MustLoad(ctx context.Context) {
 	}
 }
 
+// Load reads every provider, runs the solvers, decodes and validates the
+// configuration. Secrets and sensitive keys are redacted from the metadata of
+// the returned error.
 func (c *Container[C]) Load(ctx context.Context) error {
+	return redactErrorMetadata(c.load(ctx), c.isSensitiveKey)
+}
+
+func (c *Container[C]) load(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, c.loadTimeout)
 	defer cancel()
 
 	// reset config state i.e. so if we remove keys the are gone
 	c.newConfig()
+	c.keyOrigins = newKeyOrigins(c.mergeConflictMode != MergeConflictModeOff || c.solverTrace != nil)
+	c.mergeConflictState = newMergeConflictState(c.mergeConflictMode, c.logger, c.keyOrigins)
+	c.mergeKeepState = newMergeKeepState(c.mergeStrategies)
+	c.resolvedSensitiveKeys = nil
 
 	if len(c.loaders) > 0 {
 		c.providers = nil
@@ -368,57 +420,31 @@ func (c *Container[C]) Load(ctx context.Context) error {
 	// load providers
 	for i, source := range c.providers {
 		c.logger.Debug("= loading source", "source_type", source.Type())
+		c.keyOrigins.setProvider(providerName(source))
 		if err := source.Load(ctx, c.K); err != nil {
+			metadata := map[string]any{
+				"source_type":   string(source.Type()),
+				"source_index":  i,
+				"total_sources": len(c.providers),
+			}
+			var conflictErr *MergeConflictError
+			if stderrors.As(err, &conflictErr) {
+				metadata["merge_conflicts"] = conflictErr.Conflicts
+			}
 			return errors.Wrap(err, errors.CategoryOperation, "failed to load configuration from source").
 				WithTextCode("CONFIG_LOAD_FAILED").
-				WithMetadata(map[string]any{
-					"source_type":   string(source.Type()),
-					"source_index":  i,
-					"total_sources": len(c.providers),
-				})
+				WithMetadata(metadata)
 		}
 	}
 
 	// run all solvers
-	effectiveSolvers := c.effectiveSolvers()
-	if len(effectiveSolvers) > 0 {
-		maxPasses := c.solverPasses
-		if maxPasses < 1 {
-			maxPasses = 1
-		}
-		for pass := 0; pass < maxPasses; pass++ {
-			before, ok := snapshotConfig(c.K)
-			for _, solver := range effectiveSolvers {
-				solver.Solve(c.K)
-				if reporter, ok := solver.(solvers.ErrorReporter); ok {
-					if solverErr := reporter.Err(); solverErr != nil {
-						metadata := map[string]any{
-							"solver": fmt.Sprintf("%T", solver),
-						}
-
-						var selectErr *solvers.SelectResolutionError
-						if stderrors.As(solverErr, &selectErr) {
-							metadata["solver"] = "select"
-							metadata["select_path"] = selectErr.SelectPath
-							metadata["select_value"] = selectErr.SelectValue
-							metadata["default_key"] = selectErr.DefaultKey
-							metadata["failing_node_path"] = selectErr.NodePath
-						}
-
-						return errors.Wrap(solverErr, errors.CategoryValidation, "failed to resolve select configuration").
-							WithTextCode("CONFIG_SELECT_RESOLUTION_FAILED").
-							WithMetadata(metadata)
-					}
-				}
-			}
-			if !ok {
-				continue
-			}
-			after := c.K.Raw()
-			if reflect.DeepEqual(before, after) {
-				break
-			}
-		}
+	if err := c.runSolvers(ctx); err != nil {
+		return err
+	}
+
+	// evaluate $assert directives against the resolved values
+	if err := c.runAssertions(); err != nil {
+		return err
 	}
 
 	// unmarshal configuration into our base struct via cfgx
@@ -450,6 +476,168 @@ func (c *Container[C]) Load(ctx context.Context) error {
 	return nil
 }
 
+func (c *Container[C]) runSolvers(ctx context.Context) error {
+	effectiveSolvers := c.effectiveSolvers(ctx)
+	if len(effectiveSolvers) == 0 {
+		return nil
+	}
+
+	ordered := effectiveSolvers
+	maxPasses := c.solverPasses
+	if maxPasses < 1 {
+		maxPasses = 1
+	}
+	if c.solverDependencyGraph {
+		ordered = []solvers.ConfigSolver{solvers.NewGraphSolver(effectiveSolvers...)}
+		maxPasses = 1
+	}
+
+	// expression and template failures can be fixed by a later pass, so only
+	// the errors from the last pass are reported.
+	var expressionErr error
+	trace := c.solverTraceFunc()
+	for pass := 0; pass < maxPasses; pass++ {
+		expressionErr = nil
+		before, ok := snapshotConfig(c.K)
+		for _, solver := range ordered {
+			if solverErr := solvers.TraceSolve(ctx, solver, c.K, pass+1, trace); solverErr != nil {
+				var exprErr *solvers.ExpressionResolutionError
+				var tplErr *solvers.TemplateResolutionError
+				if (stderrors.As(solverErr, &exprErr) || stderrors.As(solverErr, &tplErr)) && pass < maxPasses-1 {
+					expressionErr = wrapSolverError(solver, solverErr)
+					continue
+				}
+				return wrapSolverError(solver, solverErr)
+			}
+		}
+		if !ok {
+			continue
+		}
+		after := c.K.Raw()
+		if reflect.DeepEqual(before, after) {
+			break
+		}
+	}
+	if expressionErr != nil {
+		return expressionErr
+	}
+
+	if err := c.checkUnresolvedReferences(effectiveSolvers); err != nil {
+		return err
+	}
+	c.unescapeReferences(effectiveSolvers)
+
+	return nil
+}
+
+func wrapSolverError(solver solvers.ConfigSolver, solverErr error) error {
+	metadata := map[string]any{
+		"solver": fmt.Sprintf("%T", solver),
+	}
+
+	if stderrors.Is(solverErr, context.DeadlineExceeded) || stderrors.Is(solverErr, context.Canceled) {
+		return errors.Wrap(solverErr, errors.CategoryOperation, "configuration solving stopped before completion").
+			WithTextCode("CONFIG_SOLVER_CANCELED").
+			WithMetadata(metadata)
+	}
+
+	var cycleErr *solvers.ReferenceCycleError
+	if stderrors.As(solverErr, &cycleErr) {
+		metadata["solver"] = "graph"
+		metadata["cycle"] = cycleErr.Cycle
+		return errors.Wrap(solverErr, errors.CategoryValidation, "configuration references form a cycle").
+			WithTextCode("CONFIG_REFERENCE_CYCLE").
+			WithMetadata(metadata)
+	}
+
+	var decryptErr *solvers.DecryptionError
+	if stderrors.As(solverErr, &decryptErr) {
+		metadata["solver"] = "uri"
+		metadata["failed_keys"] = decryptErr.Keys
+		return errors.Wrap(solverErr, errors.CategoryValidation, "failed to decrypt configuration values").
+			WithTextCode("CONFIG_DECRYPT_FAILED").
+			WithMetadata(metadata)
+	}
+
+	var exprErr *solvers.ExpressionResolutionError
+	if stderrors.As(solverErr, &exprErr) {
+		failedKeys := make([]string, 0, len(exprErr.Failures))
+		failures := make([]map[string]any, 0, len(exprErr.Failures))
+		for _, failure := range exprErr.Failures {
+			failedKeys = append(failedKeys, failure.Key)
+			failures = append(failures, map[string]any{
+				"key":        failure.Key,
+				"expression": failure.Expression,
+				"error":      fmt.Sprint(failure.Err),
+			})
+		}
+		metadata["solver"] = "expression"
+		metadata["failed_keys"] = failedKeys
+		metadata["failures"] = failures
+		return errors.Wrap(solverErr, errors.CategoryValidation, "failed to evaluate configuration expressions").
+			WithTextCode("CONFIG_EXPRESSION_FAILED").
+			WithMetadata(metadata)
+	}
+
+	var tplErr *solvers.TemplateResolutionError
+	if stderrors.As(solverErr, &tplErr) {
+		failedKeys := make([]string, 0, len(tplErr.Failures))
+		failures := make([]map[string]any, 0, len(tplErr.Failures))
+		for _, failure := range tplErr.Failures {
+			failedKeys = append(failedKeys, failure.Key)
+			failures = append(failures, map[string]any{
+				"key":   failure.Key,
+				"error": fmt.Sprint(failure.Err),
+			})
+		}
+		metadata["solver"] = "template"
+		metadata["failed_keys"] = failedKeys
+		metadata["failures"] = failures
+		return errors.Wrap(solverErr, errors.CategoryValidation, "failed to render configuration templates").
+			WithTextCode("CONFIG_TEMPLATE_FAILED").
+			WithMetadata(metadata)
+	}
+
+	var extendsErr *solvers.ExtendsResolutionError
+	if stderrors.As(solverErr, &extendsErr) {
+		metadata["solver"] = "extends"
+		metadata["extends_target"] = extendsErr.Target
+		metadata["failing_node_path"] = extendsErr.NodePath
+		if len(extendsErr.Chain) > 0 {
+			metadata["cycle"] = extendsErr.Chain
+		}
+		return errors.Wrap(solverErr, errors.CategoryValidation, "failed to resolve $extends configuration").
+			WithTextCode("CONFIG_EXTENDS_RESOLUTION_FAILED").
+			WithMetadata(metadata)
+	}
+
+	var refErr *solvers.RefResolutionError
+	if stderrors.As(solverErr, &refErr) {
+		metadata["solver"] = "ref"
+		metadata["ref"] = refErr.Ref
+		metadata["failing_node_path"] = refErr.NodePath
+		if len(refErr.Chain) > 0 {
+			metadata["cycle"] = refErr.Chain
+		}
+		return errors.Wrap(solverErr, errors.CategoryValidation, "failed to resolve $ref configuration").
+			WithTextCode("CONFIG_REF_RESOLUTION_FAILED").
+			WithMetadata(metadata)
+	}
+
+	var selectErr *solvers.SelectResolutionError
+	if stderrors.As(solverErr, &selectErr) {
+		metadata["solver"] = "select"
+		metadata["select_path"] = selectErr.SelectPath
+		metadata["select_value"] = selectErr.SelectValue
+		metadata["default_key"] = selectErr.DefaultKey
+		metadata["failing_node_path"] = selectErr.NodePath
+	}
+
+	return errors.Wrap(solverErr, errors.CategoryValidation, "failed to resolve select configuration").
+		WithTextCode("CONFIG_SELECT_RESOLUTION_FAILED").
+		WithMetadata(metadata)
+}
+
 func (c *Container[C]) Raw() C {
 	return c.base
 }
@@ -549,6 +737,12 @@ func (c *Container[C]) runSemanticValidation() error {
 		}
 	}
 
+	if c.tagValidation {
+		if err := c.runTagValidation(record); err != nil {
+			return err
+		}
+	}
+
 	for _, validator := range c.validators {
 		if validator == nil {
 			continue
@@ -0,0 +1,50 @@
+package config
+
+import (
+	stderrors "errors"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+const assertionIssueCode = "CONFIG_ASSERTION_FAILED"
+
+// WithAssertions enables or disables $assert directives (enabled by default).
+// Once all solvers ran, every "$assert" entry is evaluated with the container
+// expression functions and the directives are removed before decoding. Each
+// failure is reported as a ValidationIssue with the path of the node holding
+// the directive. With ValidationNone failures are not reported.
+func (c *Container[C]) WithAssertions(enabled bool) *Container[C] {
+	c.assertions = enabled
+	return c
+}
+
+func (c *Container[C]) runAssertions() error {
+	if !c.assertions {
+		return nil
+	}
+
+	solver := solvers.NewAssertSolver(solvers.WithAssertEvaluator(c.expressionEvaluator()))
+	solver.Solve(c.K)
+
+	if c.validationMode == ValidationNone {
+		return nil
+	}
+
+	err := solver.(solvers.ErrorReporter).Err()
+	var failed *solvers.AssertionFailedError
+	if !stderrors.As(err, &failed) {
+		return nil
+	}
+
+	report := &ValidationReport{}
+	for _, failure := range failed.Failures {
+		report.Issues = append(report.Issues, ValidationIssue{
+			Stage:   "assert",
+			Path:    failure.NodePath,
+			Code:    assertionIssueCode,
+			Message: failure.Error(),
+			Cause:   failure,
+		})
+	}
+	return c.wrapValidationReport(report)
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+type assertionConfig struct {
+	Env  string `koanf:"env"`
+	Pool struct {
+		Min int `koanf:"min"`
+		Max int `koanf:"max"`
+	} `koanf:"pool"`
+	Brokers []string `koanf:"brokers"`
+}
+
+func (c *assertionConfig) Validate() error { return nil }
+
+func TestAssertionsReportFailuresAsValidationIssues(t *testing.T) {
+	cfg := &assertionConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*assertionConfig](map[string]any{
+			"pool": map[string]any{
+				"min":     "${limits.min}",
+				"max":     10,
+				"$assert": []any{"{{ max >= min }}"},
+			},
+			"limits": map[string]any{
+				"min": 20,
+			},
+			"$assert": []any{"{{ len(brokers) > 0 }}"},
+		}))
+
+	err := container.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected assertion failure")
+	}
+
+	var report *ValidationReport
+	if !errors.As(err, &report) {
+		t.Fatalf("expected ValidationReport, got %T", err)
+	}
+	if len(report.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", report.Issues)
+	}
+
+	paths := []string{report.Issues[0].Path, report.Issues[1].Path}
+	if paths[0] != "<root>" || paths[1] != "pool" {
+		t.Fatalf("unexpected issue paths %v", paths)
+	}
+	for _, issue := range report.Issues {
+		if issue.Stage != "assert" || issue.Code != assertionIssueCode {
+			t.Fatalf("unexpected issue %+v", issue)
+		}
+		var failure *solvers.AssertionError
+		if !errors.As(issue.Cause, &failure) {
+			t.Fatalf("expected AssertionError cause, got %T", issue.Cause)
+		}
+	}
+}
+
+func TestAssertionsRemoveDirectiveBeforeDecode(t *testing.T) {
+	cfg := &assertionConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithStrictDecode(true).
+		WithProvider(DefaultValuesProvider[*assertionConfig](map[string]any{
+			"env":     "production",
+			"brokers": []any{"kafka-1:9092"},
+			"pool": map[string]any{
+				"min":     2,
+				"max":     10,
+				"$assert": []any{"{{ max >= min }}", "{{ allowed_pool(max) }}"},
+			},
+			"$assert": `{{ env == "production" }}`,
+		})).
+		WithExpressionFunction("allowed_pool", func(args ...any) (any, error) {
+			size, _ := args[0].(int)
+			return size <= 50, nil
+		})
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.Pool.Max != 10 {
+		t.Fatalf("expected pool max 10, got %d", cfg.Pool.Max)
+	}
+	if container.K.Exists("pool.$assert") || container.K.Exists("$assert") {
+		t.Fatal("expected $assert directives to be removed")
+	}
+}
+
+func TestAssertionsSkippedWithValidationNone(t *testing.T) {
+	cfg := &assertionConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithValidationMode(ValidationNone).
+		WithProvider(DefaultValuesProvider[*assertionConfig](map[string]any{
+			"brokers": []any{},
+			"$assert": []any{"{{ len(brokers) > 0 }}"},
+		}))
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if container.K.Exists("$assert") {
+		t.Fatal("expected $assert directive to be removed")
+	}
+}
+
+func TestAssertionsDisabled(t *testing.T) {
+	cfg := &assertionConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithAssertions(false).
+		WithProvider(DefaultValuesProvider[*assertionConfig](map[string]any{
+			"brokers": []any{},
+			"$assert": []any{"{{ len(brokers) > 0 }}"},
+		}))
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !container.K.Exists("$assert") {
+		t.Fatal("expected $assert directive to be kept when disabled")
+	}
+}
+
+func TestAssertionsStringLeafSkippedByExpressionSolver(t *testing.T) {
+	load := func(graph bool, max int) error {
+		container := New(&assertionConfig{}).
+			WithConfigPath("").
+			WithProvider(DefaultValuesProvider[*assertionConfig](map[string]any{
+				"pool": map[string]any{
+					"min":     2,
+					"max":     max,
+					"$assert": "{{ max >= min }}",
+				},
+			})).
+			WithStrictExpressions().
+			WithUnresolvedReferenceCheck()
+		if graph {
+			container.WithSolverDependencyGraph()
+		}
+		return container.Load(context.Background())
+	}
+
+	for _, graph := range []bool{false, true} {
+		if err := load(graph, 10); err != nil {
+			t.Fatalf("graph=%v: expected passing assertion, got %v", graph, err)
+		}
+
+		err := load(graph, 1)
+		var report *ValidationReport
+		if !errors.As(err, &report) {
+			t.Fatalf("graph=%v: expected ValidationReport, got %v", graph, err)
+		}
+		if len(report.Issues) != 1 || report.Issues[0].Code != assertionIssueCode || report.Issues[0].Path != "pool" {
+			t.Fatalf("graph=%v: expected one assertion issue at pool, got %+v", graph, report.Issues)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+	"github.com/goliatone/go-errors"
+)
+
+type contextConfig struct {
+	Password string `koanf:"password"`
+}
+
+func (contextConfig) Validate() error { return nil }
+
+func TestLoad_SolverHonoursTimeout(t *testing.T) {
+	hung := solvers.NewURISolverWithOptions("@", "://",
+		solvers.WithURIContextProtocolResolver("vault", func(ctx context.Context, _ string) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+	)
+
+	cfg := New(contextConfig{}).
+		WithTimeout(50 * time.Millisecond).
+		WithSolvers(hung).
+		WithProvider(DefaultValuesProvider[contextConfig](map[string]any{
+			"password": "@vault://db/prod",
+		}))
+
+	start := time.Now()
+	err := cfg.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected load to fail on timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected load to stop at the timeout, took %s", elapsed)
+	}
+
+	var cfgErr *errors.Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("expected go-errors wrapper, got %v", err)
+	}
+	if cfgErr.TextCode != "CONFIG_SOLVER_CANCELED" {
+		t.Fatalf("expected CONFIG_SOLVER_CANCELED, got %q", cfgErr.TextCode)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"github.com/goliatone/go-config/koanf/solvers"
+	"github.com/goliatone/go-errors"
+)
+
+// KeyProvider returns the AES-256 key for a key ID, see solvers.KeyProvider.
+type KeyProvider = solvers.KeyProvider
+
+// Keyring maps key IDs to key providers, see solvers.Keyring.
+type Keyring = solvers.Keyring
+
+// WithEncryptionKeys enables the enc protocol on the URI solver, e.g.
+// "@enc://AES256GCM:prod:<base64>". Decrypted keys are marked sensitive for
+// the rest of the Load.
+func (c *Container[C]) WithEncryptionKeys(keys KeyProvider) *Container[C] {
+	c.encryptionKeys = keys
+	return c
+}
+
+// EncryptValue encrypts plaintext with the key keyID and returns the value to
+// store in a config source, including the "@enc://" prefix. Use an empty
+// keyID for the default key.
+func EncryptValue(plaintext, keyID string, keys KeyProvider) (string, error) {
+	payload, err := solvers.SealValue([]byte(plaintext), keyID, keys)
+	if err != nil {
+		return "", errors.Wrap(err, errors.CategoryBadInput, "failed to encrypt config value").
+			WithTextCode("CONFIG_ENCRYPT_FAILED").
+			WithMetadata(map[string]any{
+				"key_id": keyID,
+			})
+	}
+	return "@enc://" + payload, nil
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+	"github.com/goliatone/go-errors"
+)
+
+type encryptedConfig struct {
+	Database struct {
+		Host     string `koanf:"host"`
+		Password string `koanf:"password"`
+	} `koanf:"database"`
+}
+
+func (encryptedConfig) Validate() error { return nil }
+
+func TestEncryptValue_LoadDecrypts(t *testing.T) {
+	key, err := solvers.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	keys := Keyring{"prod": solvers.StaticKey(key)}
+
+	value, err := EncryptValue("hunter2", "prod", keys)
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if !strings.HasPrefix(value, "@enc://AES256GCM:prod:") {
+		t.Fatalf("unexpected encrypted value %q", value)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"database": {"host": "db.local", "password": "` + value + `"}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg := New(encryptedConfig{}).
+		WithEncryptionKeys(keys).
+		WithProvider(FileProvider[encryptedConfig](path))
+	if err := cfg.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if got := cfg.Raw().Database.Password; got != "hunter2" {
+		t.Fatalf("expected decrypted password, got %q", got)
+	}
+	if !cfg.isSensitiveKey("database.password") {
+		t.Fatalf("expected decrypted key to be sensitive, got %v", cfg.SensitiveKeys())
+	}
+	if cfg.isSensitiveKey("database.host") {
+		t.Fatalf("expected plain key to stay non sensitive")
+	}
+}
+
+func TestEncryptValue_WithoutKeysLeavesValue(t *testing.T) {
+	key, err := solvers.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	value, err := EncryptValue("hunter2", "", solvers.StaticKey(key))
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+
+	cfg := New(encryptedConfig{}).
+		WithProvider(DefaultValuesProvider[encryptedConfig](map[string]any{
+			"database": map[string]any{"password": value},
+		}))
+	if err := cfg.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := cfg.Raw().Database.Password; got != value {
+		t.Fatalf("expected ciphertext to be left unchanged, got %q", got)
+	}
+	if len(cfg.SensitiveKeys()) != 0 {
+		t.Fatalf("expected no sensitive keys, got %v", cfg.SensitiveKeys())
+	}
+}
+
+func TestEncryptValue_DecryptionFailureFailsLoad(t *testing.T) {
+	key, err := solvers.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	other, err := solvers.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	value, err := EncryptValue("hunter2", "", solvers.StaticKey(other))
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+
+	for _, graph := range []bool{false, true} {
+		cfg := New(encryptedConfig{}).
+			WithEncryptionKeys(solvers.StaticKey(key)).
+			WithProvider(DefaultValuesProvider[encryptedConfig](map[string]any{
+				"database": map[string]any{"password": value},
+			}))
+		if graph {
+			cfg.WithSolverDependencyGraph()
+		}
+
+		err := cfg.Load(context.Background())
+		if !errors.Is(err, solvers.ErrDecryptionFailed) {
+			t.Fatalf("graph=%v: expected decryption failure, got %v", graph, err)
+		}
+		var richErr *errors.Error
+		if !errors.As(err, &richErr) || richErr.TextCode != "CONFIG_DECRYPT_FAILED" {
+			t.Fatalf("graph=%v: unexpected error %#v", graph, err)
+		}
+		keys, _ := richErr.Metadata["failed_keys"].([]string)
+		if len(keys) != 1 || keys[0] != "database.password" {
+			t.Fatalf("graph=%v: unexpected metadata %#v", graph, richErr.Metadata)
+		}
+	}
+}
+
+func TestEncryptValue_RejectsBadKeys(t *testing.T) {
+	if _, err := EncryptValue("hunter2", "", solvers.StaticKey([]byte("short"))); err == nil {
+		t.Fatalf("expected short key to fail")
+	}
+	if _, err := EncryptValue("hunter2", "a:b", solvers.StaticKey(make([]byte, 32))); err == nil {
+		t.Fatalf("expected key id with ':' to fail")
+	}
+}
+
+func TestWithExecProtocol_MarksKeysSensitive(t *testing.T) {
+	defaults := map[string]any{
+		"database": map[string]any{
+			"host":     "db.local",
+			"password": `@exec://sh -c 'echo hunter2'`,
+		},
+	}
+
+	cfg := New(encryptedConfig{}).
+		WithProvider(DefaultValuesProvider[encryptedConfig](defaults))
+	if err := cfg.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := cfg.Raw().Database.Password; got != defaults["database"].(map[string]any)["password"] {
+		t.Fatalf("expected exec to be off by default, got %q", got)
+	}
+
+	cfg = New(encryptedConfig{}).
+		WithExecProtocol(ExecOptions{Allow: []string{"sh"}}).
+		WithProvider(DefaultValuesProvider[encryptedConfig](defaults))
+	if err := cfg.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := cfg.Raw().Database.Password; got != "hunter2" {
+		t.Fatalf("expected exec output, got %q", got)
+	}
+	if !cfg.isSensitiveKey("database.password") {
+		t.Fatalf("expected exec key to be sensitive, got %v", cfg.SensitiveKeys())
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"context"
+	"io/fs"
 	"strings"
 
 	"github.com/goliatone/go-config/koanf/solvers"
@@ -12,6 +14,8 @@ import (
 type ExpressionFunction func(args ...any) (any, error)
 
 // WithExpressionFunction registers or replaces an expression function by name.
+// Template solvers added with WithSolvers can call it too, e.g.
+// {% githash 7 %}.
 func (c *Container[C]) WithExpressionFunction(name string, fn ExpressionFunction) *Container[C] {
 	name = strings.ToLower(strings.TrimSpace(name))
 	if name == "" || fn == nil {
@@ -24,18 +28,100 @@ func (c *Container[C]) WithExpressionFunction(name string, fn ExpressionFunction
 	return c
 }
 
-func (c *Container[C]) effectiveSolvers() []solvers.ConfigSolver {
+// WithStrictExpressions makes expression evaluation errors fail Load with
+// CONFIG_EXPRESSION_FAILED instead of leaving the value unchanged. Every
+// failing key is listed in the error metadata.
+func (c *Container[C]) WithStrictExpressions() *Container[C] {
+	c.strictExpressions = true
+	return c
+}
+
+// effectiveSolvers returns the configured solvers with the container ref,
+// expression, template, variables and URI settings applied.
+func (c *Container[C]) effectiveSolvers(ctx context.Context) []solvers.ConfigSolver {
+	return c.uriSolvers(ctx, variablesSolvers(c.templateSolvers(c.refSolvers(c.expressionSolvers()))))
+}
+
+// refSolvers puts a ref solver first in in when WithRefDirectives is set and
+// in has none.
+func (c *Container[C]) refSolvers(in []solvers.ConfigSolver) []solvers.ConfigSolver {
+	if !c.refDirectives {
+		return in
+	}
+	for _, solver := range in {
+		if solvers.SolverName(solver) == "ref" {
+			return in
+		}
+	}
+	return append([]solvers.ConfigSolver{solvers.NewRefSolver()}, in...)
+}
+
+// variablesSolvers makes the file namespace of the variables solvers in in
+// read from the file system of the first URI solver, so ${file:x} and
+// @file://x see the same files.
+func variablesSolvers(in []solvers.ConfigSolver) []solvers.ConfigSolver {
+	fsys := firstURISolverFS(in)
+	if fsys == nil {
+		return in
+	}
+
+	out := make([]solvers.ConfigSolver, 0, len(in))
+	for _, solver := range in {
+		updated, _ := solvers.ReplaceVariablesSolverOptions(solver, solvers.WithVariablesFS(fsys))
+		out = append(out, updated)
+	}
+	return out
+}
+
+// firstURISolverFS returns the file system of the first URI solver in in, or
+// nil.
+func firstURISolverFS(in []solvers.ConfigSolver) fs.FS {
+	for _, solver := range in {
+		if fsys, ok := solvers.URISolverFS(solver); ok {
+			return fsys
+		}
+	}
+	return nil
+}
+
+// templateSolvers shares the expression function registry with the template
+// solvers in in.
+func (c *Container[C]) templateSolvers(in []solvers.ConfigSolver) []solvers.ConfigSolver {
+	if len(c.expressionFunctions) == 0 {
+		return in
+	}
+	funcs := make(map[string]solvers.TemplateFunction, len(c.expressionFunctions))
+	for name, fn := range c.expressionFunctions {
+		if fn != nil {
+			funcs[name] = solvers.TemplateFunction(fn)
+		}
+	}
+
+	out := make([]solvers.ConfigSolver, 0, len(in))
+	for _, solver := range in {
+		updated, _ := solvers.ReplaceTemplateSolverFunctions(solver, funcs)
+		out = append(out, updated)
+	}
+	return out
+}
+
+func (c *Container[C]) expressionSolvers() []solvers.ConfigSolver {
+	var onErr solvers.EvalErrorHandler
+	if c.strictExpressions {
+		onErr = solvers.OnEvalFail()
+	}
+
 	if len(c.solvers) == 0 {
 		if len(c.expressionFunctions) == 0 {
 			return nil
 		}
 		return []solvers.ConfigSolver{
-			solvers.NewExpressionSolverWithEvaluator("{{", "}}", c.expressionEvaluator(), nil),
+			solvers.NewExpressionSolverWithEvaluator("{{", "}}", c.expressionEvaluator(), onErr),
 		}
 	}
 
 	eval := c.expressionEvaluator()
-	if eval == nil {
+	if eval == nil && onErr == nil {
 		return c.solvers
 	}
 
@@ -46,14 +132,15 @@ func (c *Container[C]) effectiveSolvers() []solvers.ConfigSolver {
 		updated, ok := solvers.ReplaceExpressionSolverEvaluator(solver, eval)
 		if ok {
 			replaced = true
-			out = append(out, updated)
-			continue
 		}
-		out = append(out, solver)
+		if withHandler, ok := solvers.ReplaceExpressionSolverErrorHandler(updated, onErr); ok {
+			updated = withHandler
+		}
+		out = append(out, updated)
 	}
 
-	if !replaced {
-		out = append(out, solvers.NewExpressionSolverWithEvaluator("{{", "}}", eval, nil))
+	if !replaced && eval != nil {
+		out = append(out, solvers.NewExpressionSolverWithEvaluator("{{", "}}", eval, onErr))
 	}
 
 	return out
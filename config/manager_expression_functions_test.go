@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/goliatone/go-config/koanf/solvers"
+	"github.com/goliatone/go-errors"
 )
 
 type expressionFunctionsConfig struct {
@@ -88,3 +89,76 @@ func TestContainerWithExpressionFunction_SecondRegistrationReplacesFirst(t *test
 		t.Fatalf("expected latest function registration to win, got %q", cfg.App.Hash)
 	}
 }
+
+func TestContainerWithStrictExpressions_ReportsEveryFailingKey(t *testing.T) {
+	cfg := &expressionFunctionsConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*expressionFunctionsConfig](map[string]any{
+			"app": map[string]any{
+				"hash":  "{{ githash(7) }}",
+				"label": "{{ }}",
+			},
+		})).
+		WithStrictExpressions()
+
+	err := container.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected expression failure")
+	}
+
+	var cfgErr *errors.Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("expected go-errors wrapper, got %v", err)
+	}
+	if cfgErr.TextCode != "CONFIG_EXPRESSION_FAILED" {
+		t.Fatalf("expected CONFIG_EXPRESSION_FAILED, got %q", cfgErr.TextCode)
+	}
+	keys, ok := cfgErr.Metadata["failed_keys"].([]string)
+	if !ok || len(keys) != 2 || keys[0] != "app.hash" || keys[1] != "app.label" {
+		t.Fatalf("unexpected failed_keys metadata: %#v", cfgErr.Metadata["failed_keys"])
+	}
+	failures, ok := cfgErr.Metadata["failures"].([]map[string]any)
+	if !ok || failures[0]["expression"] != "githash(7)" {
+		t.Fatalf("unexpected failures metadata: %#v", cfgErr.Metadata["failures"])
+	}
+
+	var exprErr *solvers.ExpressionResolutionError
+	if !errors.As(err, &exprErr) {
+		t.Fatalf("expected ExpressionResolutionError in chain, got %v", err)
+	}
+}
+
+func TestContainerWithStrictExpressions_LaterPassCanResolve(t *testing.T) {
+	cfg := &expressionFunctionsConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*expressionFunctionsConfig](map[string]any{
+			"app": map[string]any{
+				"hash": "{{ owner.name }}",
+			},
+			"owner": "${team.lead}",
+			"team": map[string]any{
+				"lead": map[string]any{
+					"name": "ann",
+				},
+			},
+		})).
+		WithSolvers(
+			solvers.NewExpressionSolver("{{", "}}"),
+			solvers.NewVariablesSolver("${", "}"),
+		).
+		WithStrictExpressions()
+
+	if err := container.Load(context.Background()); err == nil {
+		t.Fatal("expected expression failure with a single pass")
+	}
+
+	container.WithSolverPasses(2)
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.App.Hash != "ann" {
+		t.Fatalf("expected app hash ann, got %q", cfg.App.Hash)
+	}
+}
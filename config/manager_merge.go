@@ -0,0 +1,115 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// WithDeletePolicy lets higher priority file, env and struct layers remove
+// keys set by lower layers. With DeleteOnUnset a "$unset" value deletes the
+// key, with DeleteOnNull an explicit null does too. Struct layers only honor
+// "$unset" since a nil field cannot be told apart from an unset one, while env
+// layers read the string "null" as an explicit null.
+func (c *Container[C]) WithDeletePolicy(policy DeletePolicy) *Container[C] {
+	c.deletePolicy = policy
+	return c
+}
+
+// WithMergeStrategy sets how providers merge into the keys matching pattern,
+// e.g. WithMergeStrategy("cors.origins", MergeAppend). Patterns are dot
+// separated paths where each segment may be a glob ("features.*") and "**"
+// matches any number of segments. An exact path wins over a glob, and among
+// globs the last registered match wins. Every built-in provider honors the
+// strategies.
+func (c *Container[C]) WithMergeStrategy(pattern string, strategy MergeStrategy) *Container[C] {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return c
+	}
+	c.mergeStrategies = append(c.mergeStrategies, pathMergeStrategy{
+		pattern:  pattern,
+		strategy: strategy,
+	})
+	return c
+}
+
+// WithProviderTrust sets how much MergeKeep paths trust providers of type pt.
+// A value is only replaced by a provider trusted at least as much as the one
+// that set it. By default defaults and struct layers have trust 0, env vars
+// and flags 10, and files 20.
+func (c *Container[C]) WithProviderTrust(pt ProviderType, trust int) *Container[C] {
+	if c.providerTrust == nil {
+		c.providerTrust = map[ProviderType]int{}
+	}
+	c.providerTrust[pt] = trust
+	return c
+}
+
+func (c *Container[C]) trustOf(pt ProviderType) int {
+	if trust, ok := c.providerTrust[pt]; ok {
+		return trust
+	}
+	return defaultProviderTrust[pt]
+}
+
+// WithMergeConflicts enables detection of keys whose shape (map, list or
+// scalar) differs between providers. MergeConflictModeLastWins and
+// MergeConflictModeWarn keep the later value, the latter logging a warning.
+// MergeConflictModeError fails Load with a *MergeConflictError listing every
+// conflict of the offending provider, also added to the error metadata under
+// "merge_conflicts". Detected conflicts are available from MergeConflicts.
+func (c *Container[C]) WithMergeConflicts(mode MergeConflictMode) *Container[C] {
+	c.mergeConflictMode = mode
+	return c
+}
+
+// MergeConflicts returns the conflicts detected by the last Load.
+func (c *Container[C]) MergeConflicts() []MergeConflict {
+	if c.mergeConflictState == nil {
+		return nil
+	}
+	return append([]MergeConflict(nil), c.mergeConflictState.conflicts...)
+}
+
+// mergeOptions returns the koanf merge option used by a provider type. The
+// defaults and flags providers keep koanf's own merge unless merge strategies,
+// conflict detection or key origins are enabled, and then still honor
+// WithStrictMerge on paths without a strategy.
+func (c *Container[C]) mergeOptions(pt ProviderType) []koanf.Option {
+	merger := booleanMerger{
+		deletePolicy: c.deletePolicy,
+		nullString:   pt == ProviderTypeEnv,
+		strategies:   c.mergeStrategies,
+		conflicts:    c.mergeConflictState,
+		keep:         c.mergeKeepState,
+		trust:        c.trustOf(pt),
+	}
+	origins := c.keyOrigins
+
+	switch pt {
+	case ProviderTypeDefault, ProviderTypeFlag:
+		if len(c.mergeStrategies) == 0 && merger.conflicts == nil && origins == nil {
+			return nil
+		}
+		merger.deletePolicy = DeleteNever
+		merger.plain = true
+		merger.strict = c.strictMerge
+	case ProviderTypeStruct:
+		if merger.deletePolicy == DeleteOnNull {
+			merger.deletePolicy = DeleteOnUnset
+		}
+	}
+
+	return []koanf.Option{koanf.WithMergeFunc(func(src, dst map[string]any) error {
+		if err := merger.merge("", src, dst); err != nil {
+			return err
+		}
+		merger.keep.commit(merger.strategies, src, merger.trust)
+		origins.record("", src)
+		if merger.conflicts != nil {
+			return merger.conflicts.commit()
+		}
+		return nil
+	})}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeWithDeletePolicy(t *testing.T) {
+	base := func() map[string]any {
+		return map[string]any{
+			"name":  "app",
+			"cache": map[string]any{"ttl": "5m", "size": 10},
+			"tags":  []any{"a"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		policy   DeletePolicy
+		src      map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "never keeps lower layer values",
+			policy:   DeleteNever,
+			src:      map[string]any{"name": nil, "cache": map[string]any{"ttl": nil}},
+			expected: base(),
+		},
+		{
+			name:   "never merges unset sentinel as a value",
+			policy: DeleteNever,
+			src:    map[string]any{"name": UnsetValue},
+			expected: map[string]any{
+				"name":  UnsetValue,
+				"cache": map[string]any{"ttl": "5m", "size": 10},
+				"tags":  []any{"a"},
+			},
+		},
+		{
+			name:   "unset sentinel deletes nested key",
+			policy: DeleteOnUnset,
+			src:    map[string]any{"cache": map[string]any{"ttl": UnsetValue}, "name": nil},
+			expected: map[string]any{
+				"name":  "app",
+				"cache": map[string]any{"size": 10},
+				"tags":  []any{"a"},
+			},
+		},
+		{
+			name:   "unset sentinel deletes subtree",
+			policy: DeleteOnUnset,
+			src:    map[string]any{"cache": UnsetValue},
+			expected: map[string]any{
+				"name": "app",
+				"tags": []any{"a"},
+			},
+		},
+		{
+			name:   "null deletes",
+			policy: DeleteOnNull,
+			src:    map[string]any{"name": nil, "tags": UnsetValue, "cache": map[string]any{"size": nil}},
+			expected: map[string]any{
+				"cache": map[string]any{"ttl": "5m"},
+			},
+		},
+		{
+			name:     "missing keys are ignored",
+			policy:   DeleteOnNull,
+			src:      map[string]any{"other": nil},
+			expected: base(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := base()
+			if err := MergeWithDeletePolicy(tt.policy)(tt.src, dst); err != nil {
+				t.Fatalf("merge failed: %v", err)
+			}
+			if !reflect.DeepEqual(dst, tt.expected) {
+				t.Fatalf("expected %#v, got %#v", tt.expected, dst)
+			}
+		})
+	}
+}
+
+type deletePolicyConfig struct {
+	Name  string `koanf:"name"`
+	Cache struct {
+		TTL  string `koanf:"ttl"`
+		Size int    `koanf:"size"`
+	} `koanf:"cache"`
+}
+
+func (c *deletePolicyConfig) Validate() error { return nil }
+
+func writeDeletePolicyBase(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "base.json")
+	if err := os.WriteFile(path, []byte(`{"name": "app", "cache": {"ttl": "5m", "size": 10}}`), 0o600); err != nil {
+		t.Fatalf("write base: %v", err)
+	}
+	return path
+}
+
+func TestContainerDeletePolicyFileLayer(t *testing.T) {
+	base := writeDeletePolicyBase(t)
+	overlay := filepath.Join(t.TempDir(), "overlay.json")
+	if err := os.WriteFile(overlay, []byte(`{"name": null, "cache": {"ttl": "$unset"}}`), 0o600); err != nil {
+		t.Fatalf("write overlay: %v", err)
+	}
+
+	container := New(&deletePolicyConfig{}).
+		WithConfigPath("").
+		WithDeletePolicy(DeleteOnNull).
+		WithProvider(
+			FileProvider[*deletePolicyConfig](overlay, int(PriorityConfig.WithOffset(1))),
+			FileProvider[*deletePolicyConfig](base),
+		)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if container.K.Exists("name") || container.K.Exists("cache.ttl") {
+		t.Fatalf("expected name and cache.ttl to be deleted, got %v", container.K.All())
+	}
+	cfg := container.Raw()
+	if cfg.Name != "" || cfg.Cache.TTL != "" || cfg.Cache.Size != 10 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
+
+func TestContainerDeletePolicyEnvLayer(t *testing.T) {
+	base := writeDeletePolicyBase(t)
+	t.Setenv("DELPOL_NAME", "null")
+	t.Setenv("DELPOL_CACHE__TTL", "$unset")
+
+	load := func(policy DeletePolicy) *Container[*deletePolicyConfig] {
+		container := New(&deletePolicyConfig{}).
+			WithConfigPath("").
+			WithDeletePolicy(policy).
+			WithProvider(
+				EnvProvider[*deletePolicyConfig]("DELPOL_", "__"),
+				FileProvider[*deletePolicyConfig](base),
+			)
+		if err := container.Load(context.Background()); err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		return container
+	}
+
+	container := load(DeleteOnNull)
+	if container.K.Exists("name") || container.K.Exists("cache.ttl") {
+		t.Fatalf("expected env layer to delete keys, got %v", container.K.All())
+	}
+
+	container = load(DeleteOnUnset)
+	if !container.K.Exists("name") {
+		t.Fatalf("expected null not to delete name under DeleteOnUnset")
+	}
+	if container.K.Exists("cache.ttl") {
+		t.Fatalf("expected $unset to delete cache.ttl, got %v", container.K.All())
+	}
+}
+
+func TestContainerDeletePolicyStructLayer(t *testing.T) {
+	base := writeDeletePolicyBase(t)
+
+	overlay := &deletePolicyConfig{Name: UnsetValue}
+	overlay.Cache.TTL = "1m"
+
+	container := New(&deletePolicyConfig{}).
+		WithConfigPath("").
+		WithDeletePolicy(DeleteOnNull).
+		WithProvider(
+			StructProvider[*deletePolicyConfig](overlay, int(PriorityConfig.WithOffset(1))),
+			FileProvider[*deletePolicyConfig](base),
+		)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if container.K.Exists("name") {
+		t.Fatalf("expected struct layer to delete name, got %v", container.K.All())
+	}
+	if got := container.K.String("cache.ttl"); got != "1m" {
+		t.Fatalf("expected cache.ttl 1m, got %q", got)
+	}
+}
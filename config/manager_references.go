@@ -0,0 +1,57 @@
+package config
+
+import (
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+const unresolvedReferenceIssueCode = "CONFIG_UNRESOLVED_REFERENCE"
+
+// WithUnresolvedReferenceCheck makes Load fail when reference tokens such as
+// ${path}, {{ expr }} or @proto://uri are still present after all solver
+// passes. Each leftover token is reported as a separate ValidationIssue.
+// Tokens escaped with a backslash, e.g. \${literal}, are not reported.
+func (c *Container[C]) WithUnresolvedReferenceCheck() *Container[C] {
+	c.unresolvedReferenceCheck = true
+	return c
+}
+
+func (c *Container[C]) checkUnresolvedReferences(slvrs []solvers.ConfigSolver) error {
+	if !c.unresolvedReferenceCheck {
+		return nil
+	}
+
+	report := &ValidationReport{}
+	for _, solver := range slvrs {
+		scanner, ok := solver.(solvers.ReferenceScanner)
+		if !ok {
+			continue
+		}
+		for _, ref := range scanner.UnresolvedReferences(c.K) {
+			if ref == nil {
+				continue
+			}
+			report.Issues = append(report.Issues, ValidationIssue{
+				Stage:   "resolve",
+				Path:    ref.Path,
+				Code:    unresolvedReferenceIssueCode,
+				Message: ref.Error(),
+				Cause:   ref,
+			})
+		}
+	}
+
+	if len(report.Issues) > 0 {
+		return c.wrapValidationReport(report)
+	}
+	return nil
+}
+
+// unescapeReferences turns escaped tokens into literals once solving is done.
+// Solvers always skip escaped tokens, so the backslash is always removed.
+func (c *Container[C]) unescapeReferences(slvrs []solvers.ConfigSolver) {
+	for _, solver := range slvrs {
+		if scanner, ok := solver.(solvers.ReferenceScanner); ok {
+			scanner.UnescapeReferences(c.K)
+		}
+	}
+}
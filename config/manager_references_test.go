@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+type referenceCheckConfig struct {
+	Host    string `koanf:"host"`
+	DSN     string `koanf:"dsn"`
+	Secret  string `koanf:"secret"`
+	Literal string `koanf:"literal"`
+}
+
+func (c *referenceCheckConfig) Validate() error { return nil }
+
+func TestUnresolvedReferenceCheckReportsEveryToken(t *testing.T) {
+	cfg := &referenceCheckConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*referenceCheckConfig](map[string]any{
+			"host":   "localhost",
+			"dsn":    "postgres://${db.user}@${host}",
+			"secret": "@vault://db/password",
+		})).
+		WithUnresolvedReferenceCheck()
+
+	err := container.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected unresolved reference error")
+	}
+
+	var report *ValidationReport
+	if !errors.As(err, &report) {
+		t.Fatalf("expected ValidationReport, got %T", err)
+	}
+	if len(report.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", report.Issues)
+	}
+
+	reasons := map[string]solvers.UnresolvedReason{}
+	for _, issue := range report.Issues {
+		if issue.Code != unresolvedReferenceIssueCode {
+			t.Fatalf("unexpected issue code %q", issue.Code)
+		}
+		var ref *solvers.UnresolvedReferenceError
+		if !errors.As(issue.Cause, &ref) {
+			t.Fatalf("expected UnresolvedReferenceError cause, got %T", issue.Cause)
+		}
+		reasons[issue.Path] = ref.Reason
+	}
+
+	if reasons["dsn"] != solvers.UnresolvedMissingPath {
+		t.Fatalf("expected dsn missing_path, got %q", reasons["dsn"])
+	}
+	if reasons["secret"] != solvers.UnresolvedUnknownProtocol {
+		t.Fatalf("expected secret unknown_protocol, got %q", reasons["secret"])
+	}
+}
+
+func TestUnresolvedReferenceCheckDisabledByDefault(t *testing.T) {
+	cfg := &referenceCheckConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*referenceCheckConfig](map[string]any{
+			"dsn": "postgres://${db.user}",
+		}))
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.DSN != "postgres://${db.user}" {
+		t.Fatalf("expected dsn to stay unresolved, got %q", cfg.DSN)
+	}
+}
+
+func TestUnresolvedReferenceCheckEscapes(t *testing.T) {
+	cfg := &referenceCheckConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*referenceCheckConfig](map[string]any{
+			"host":    "localhost",
+			"dsn":     `http://${host}/\${path}`,
+			"secret":  `\@file://secret.txt`,
+			"literal": `\{{ not an expression }}`,
+		})).
+		WithUnresolvedReferenceCheck()
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.DSN != "http://localhost/${path}" {
+		t.Fatalf("unexpected dsn %q", cfg.DSN)
+	}
+	if cfg.Secret != "@file://secret.txt" {
+		t.Fatalf("unexpected secret %q", cfg.Secret)
+	}
+	if cfg.Literal != "{{ not an expression }}" {
+		t.Fatalf("unexpected literal %q", cfg.Literal)
+	}
+}
+
+func TestUnresolvedReferenceCheckDisabledUnescapesLiterals(t *testing.T) {
+	cfg := &referenceCheckConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*referenceCheckConfig](map[string]any{
+			"dsn":     "echo $${HOME}",
+			"secret":  `\@file://secret.txt`,
+			"literal": "<p>{{{ body }}}</p>",
+		}))
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.DSN != "echo $${HOME}" {
+		t.Fatalf("unexpected dsn %q", cfg.DSN)
+	}
+	if cfg.Secret != "@file://secret.txt" {
+		t.Fatalf("unexpected secret %q", cfg.Secret)
+	}
+	if cfg.Literal != "<p>{{{ body }}}</p>" {
+		t.Fatalf("unexpected literal %q", cfg.Literal)
+	}
+}
+
+func TestEmbeddedExpressionEscapeWithoutReferenceCheck(t *testing.T) {
+	cfg := &referenceCheckConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithSolvers(solvers.NewExpressionSolverWithOptions("{{", "}}", solvers.WithEmbeddedExpressions())).
+		WithProvider(DefaultValuesProvider[*referenceCheckConfig](map[string]any{
+			"literal": `literal \{{ braces }} and {{ 1 + 1 }}`,
+		}))
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.Literal != "literal {{ braces }} and 2" {
+		t.Fatalf("unexpected literal %q", cfg.Literal)
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// WithSensitiveKeys marks key paths whose values are redacted in env provider
+// debug logs and Load error metadata. Patterns follow WithMergeStrategy, e.g.
+// "**.password". Fields of type Secret[T] are sensitive without registration.
+func (c *Container[C]) WithSensitiveKeys(patterns ...string) *Container[C] {
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			c.sensitiveKeys = append(c.sensitiveKeys, pattern)
+		}
+	}
+	return c
+}
+
+// SensitiveKeys returns the registered sensitive key patterns, the paths of
+// Secret fields in the config struct and the keys resolved through the enc or
+// exec protocols by the last Load, sorted.
+func (c *Container[C]) SensitiveKeys() []string {
+	keys := append(append([]string{}, c.sensitiveKeys...), c.secretKeys()...)
+	for key := range c.resolvedSensitiveKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *Container[C]) secretKeys() []string {
+	return secretKeyPaths(reflect.TypeOf(c.base), "koanf")
+}
+
+// isSensitiveKey reports whether the dot separated key path is sensitive.
+func (c *Container[C]) isSensitiveKey(key string) bool {
+	if _, ok := c.resolvedSensitiveKeys[key]; ok {
+		return true
+	}
+	for _, pattern := range c.sensitiveKeys {
+		if pattern == key || matchKeyPattern(pattern, key) {
+			return true
+		}
+	}
+	for _, path := range c.secretKeys() {
+		if path == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+	"github.com/goliatone/go-errors"
+)
+
+type templateConfig struct {
+	App struct {
+		Name    string `koanf:"name"`
+		Version string `koanf:"version"`
+		Banner  string `koanf:"banner"`
+	} `koanf:"app"`
+}
+
+func (c *templateConfig) Validate() error { return nil }
+
+func TestContainerTemplateSolver_SharesExpressionFunctions(t *testing.T) {
+	cfg := &templateConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*templateConfig](map[string]any{
+			"app": map[string]any{
+				"name":    "api",
+				"version": "{{ githash(7) }}",
+				"banner":  "{% .app.name %}@{% githash 7 %}",
+			},
+		})).
+		WithSolvers(
+			solvers.NewExpressionSolver("{{", "}}"),
+			solvers.NewTemplateSolver("{%", "%}"),
+		).
+		WithExpressionFunction("githash", func(args ...any) (any, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("githash expects one argument")
+			}
+			return "f9d293c", nil
+		})
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.App.Version != "f9d293c" {
+		t.Fatalf("expected version from expression, got %q", cfg.App.Version)
+	}
+	if cfg.App.Banner != "api@f9d293c" {
+		t.Fatalf("expected rendered banner, got %q", cfg.App.Banner)
+	}
+}
+
+func TestContainerTemplateSolver_FailsLoadWithKeyPath(t *testing.T) {
+	container := New(&templateConfig{}).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*templateConfig](map[string]any{
+			"app": map[string]any{
+				"banner": "{% .app.missing %}",
+			},
+		})).
+		WithSolvers(solvers.NewTemplateSolver("{%", "%}"))
+
+	err := container.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected template failure")
+	}
+
+	var cfgErr *errors.Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("expected go-errors wrapper, got %v", err)
+	}
+	if cfgErr.TextCode != "CONFIG_TEMPLATE_FAILED" {
+		t.Fatalf("expected CONFIG_TEMPLATE_FAILED, got %q", cfgErr.TextCode)
+	}
+	keys, _ := cfgErr.Metadata["failed_keys"].([]string)
+	if len(keys) != 1 || keys[0] != "app.banner" {
+		t.Fatalf("expected failed key app.banner, got %v", cfgErr.Metadata["failed_keys"])
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+// TraceEvent records one change a solver made to a key, see
+// solvers.TraceEvent.
+type TraceEvent = solvers.TraceEvent
+
+// TraceCollector keeps trace events and renders the resolution chain of each
+// key, see solvers.TraceCollector.
+type TraceCollector = solvers.TraceCollector
+
+// WithSolverTrace calls fn for every key a solver changes during Load, with
+// the pass, solver name, value before and after, duration, and the provider
+// that set the key:
+//
+//	collector := &config.TraceCollector{}
+//	container.WithSolverTrace(collector.Record)
+//	...
+//	fmt.Print(collector.Render("database.dsn"))
+//
+// Values of sensitive keys are redacted. fn runs synchronously, so keep it
+// cheap.
+func (c *Container[C]) WithSolverTrace(fn func(TraceEvent)) *Container[C] {
+	c.solverTrace = fn
+	return c
+}
+
+func (c *Container[C]) solverTraceFunc() solvers.TraceFunc {
+	if c.solverTrace == nil {
+		return nil
+	}
+	return func(event TraceEvent) {
+		event.Before = c.redactTraceValue(event.Key, event.Before)
+		event.After = c.redactTraceValue(event.Key, event.After)
+		event.Provider = c.keyOrigins.of(event.Key)
+		c.solverTrace(event)
+	}
+}
+
+// redactTraceValue redacts value when key, or a key below it for objects,
+// is sensitive.
+func (c *Container[C]) redactTraceValue(key string, value any) any {
+	if value == nil {
+		return nil
+	}
+	if c.isSensitiveKey(key) {
+		return RedactedValue
+	}
+	if _, ok := value.(secretValue); ok {
+		return RedactedValue
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for child, item := range v {
+			out[child] = c.redactTraceValue(joinKeyPath(key, child), item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = c.redactTraceValue(key, item)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type traceConfig struct {
+	App struct {
+		Name  string `koanf:"name"`
+		Label string `koanf:"label"`
+	} `koanf:"app"`
+	DB struct {
+		Password string `koanf:"password"`
+	} `koanf:"db"`
+}
+
+func (c *traceConfig) Validate() error { return nil }
+
+func TestContainerWithSolverTrace_RecordsChain(t *testing.T) {
+	collector := &TraceCollector{}
+	container := New(&traceConfig{}).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*traceConfig](map[string]any{
+			"app": map[string]any{
+				"name":  "api",
+				"label": "{{ upper(\"${app.name}\") }}",
+			},
+			"db": map[string]any{
+				"password": "${app.name}-secret",
+			},
+		})).
+		WithSensitiveKeys("db.password").
+		WithSolverTrace(collector.Record)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	chain := collector.Chain("app.label")
+	if len(chain) != 2 {
+		t.Fatalf("expected variables and expression steps, got %+v", chain)
+	}
+	if chain[0].Pass != 1 || chain[0].Solver != "variables" || chain[0].After != `{{ upper("api") }}` {
+		t.Fatalf("unexpected variables step: %+v", chain[0])
+	}
+	if chain[1].Solver != "expression" || chain[1].Before != `{{ upper("api") }}` || chain[1].After != "API" {
+		t.Fatalf("unexpected expression step: %+v", chain[1])
+	}
+
+	password := collector.Chain("db.password")
+	if len(password) != 1 || password[0].Before != RedactedValue || password[0].After != RedactedValue {
+		t.Fatalf("expected redacted password step, got %+v", password)
+	}
+	if out := collector.String(); strings.Contains(out, "api-secret") {
+		t.Fatalf("expected rendered trace to hide the password, got %q", out)
+	}
+}
+
+func TestContainerWithSolverTrace_ReportsProvider(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"app": {"label": "${app.name}-v2"}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	collector := &TraceCollector{}
+	container := New(&traceConfig{}).
+		WithConfigPath("").
+		WithProvider(
+			DefaultValuesProvider[*traceConfig](map[string]any{
+				"app": map[string]any{"name": "api", "label": "${app.name}"},
+				"db":  map[string]any{"password": "${app.name}-secret"},
+			}),
+			FileProvider[*traceConfig](file),
+		).
+		WithSolverTrace(collector.Record)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if chain := collector.Chain("app.label"); len(chain) != 1 || chain[0].Provider != "file:"+file {
+		t.Fatalf("expected label step from the file provider, got %+v", chain)
+	}
+	if chain := collector.Chain("db.password"); len(chain) != 1 || chain[0].Provider != "default" {
+		t.Fatalf("expected password step from the default provider, got %+v", chain)
+	}
+	if out := collector.Render("app.label"); !strings.HasPrefix(out, `app.label: "${app.name}-v2" from file:`+file+"\n") {
+		t.Fatalf("expected rendered chain to name the provider, got %q", out)
+	}
+}
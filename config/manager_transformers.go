@@ -83,23 +83,12 @@ func (c *Container[C]) transformStruct(
 ) error {
 	t := value.Type()
 	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if field.PkgPath != "" {
+		nextPath, ok := fieldKeyPath(t.Field(i), path)
+		if !ok {
 			continue
 		}
 
-		name, ignore, explicitName := parseKoanfFieldTag(field)
-		if ignore {
-			continue
-		}
-
-		fieldValue := value.Field(i)
-		nextPath := joinKeyPath(path, name)
-		if field.Anonymous && !explicitName && isStructLikeField(field.Type) {
-			nextPath = path
-		}
-
-		if err := c.transformValue(fieldValue, nextPath, global, record); err != nil {
+		if err := c.transformValue(value.Field(i), nextPath, global, record); err != nil {
 			return err
 		}
 	}
@@ -179,6 +168,25 @@ func invokeStringTransformer(transformer StringTransformer, value string) (out s
 	return out, "", nil
 }
 
+// fieldKeyPath returns the koanf key path of field below path. Embedded
+// structs without an explicit key share the path of their parent. Unexported
+// and ignored fields report ok=false.
+func fieldKeyPath(field reflect.StructField, path string) (nextPath string, ok bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+
+	name, ignore, explicitName := parseKoanfFieldTag(field)
+	if ignore {
+		return "", false
+	}
+
+	if field.Anonymous && !explicitName && isStructLikeField(field.Type) {
+		return path, true
+	}
+	return joinKeyPath(path, name), true
+}
+
 func parseKoanfFieldTag(field reflect.StructField) (name string, ignore bool, explicitName bool) {
 	name = field.Name
 
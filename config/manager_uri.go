@@ -0,0 +1,121 @@
+package config
+
+import (
+	"context"
+	"time"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+	"github.com/goliatone/go-errors"
+)
+
+// ExecOptions configures the exec protocol, see solvers.ExecOptions.
+type ExecOptions = solvers.ExecOptions
+
+// WithExecProtocol enables the exec protocol on the URI solver, e.g.
+// "@exec://pass show db/prod". Only executables in opts.Allow run, and each
+// command is bounded by opts.Timeout and the Load context. Keys resolved
+// through exec are marked sensitive for the rest of the Load.
+func (c *Container[C]) WithExecProtocol(opts ExecOptions) *Container[C] {
+	c.execOptions = &opts
+	return c
+}
+
+// HTTPOptions configures the http and https protocols, see
+// solvers.HTTPOptions.
+type HTTPOptions = solvers.HTTPOptions
+
+// WithHTTPProtocol enables the http and https protocols on the URI solver,
+// e.g. "@https://config.internal/release.txt". Only hosts in opts.Allow are
+// fetched, including redirect targets, and each request is bounded by
+// opts.Timeout and the Load context.
+func (c *Container[C]) WithHTTPProtocol(opts HTTPOptions) *Container[C] {
+	c.httpOptions = &opts
+	return c
+}
+
+// URICacheOptions configures the URI cache, see solvers.URICacheOptions.
+type URICacheOptions = solvers.URICacheOptions
+
+// WithURICache keeps values resolved by the URI solvers across loads, with a
+// time to live per protocol:
+//
+//	container.WithURICache(config.URICacheOptions{
+//		TTL: map[string]time.Duration{"storage": 5 * time.Minute, "include": time.Minute},
+//	})
+//
+// Protocols without a TTL are read on every Load.
+func (c *Container[C]) WithURICache(opts URICacheOptions) *Container[C] {
+	c.uriCache = solvers.NewURICache(opts)
+	return c
+}
+
+// URICache returns the cache set by WithURICache, or nil. Use it to
+// invalidate values, e.g. after rotating a secret.
+func (c *Container[C]) URICache() *solvers.URICache {
+	return c.uriCache
+}
+
+// WatchURIFiles checks the file:// targets read by the URI solvers every
+// interval until ctx is done. When one changes, the cached values that depend
+// on it are evicted and the container is loaded again. onReload, if set,
+// receives the Load result. Load runs on the watcher goroutine, so callers
+// reading the config concurrently must synchronize.
+func (c *Container[C]) WatchURIFiles(ctx context.Context, interval time.Duration, onReload func(error)) error {
+	if c.uriCache == nil {
+		return errors.New("uri file watching requires WithURICache", errors.CategoryBadInput).
+			WithTextCode("CONFIG_URI_CACHE_DISABLED")
+	}
+	go c.uriCache.Watch(ctx, interval, func(paths []string) {
+		c.logger.Debug("uri file targets changed, reloading", "paths", paths)
+		err := c.Load(ctx)
+		if onReload != nil {
+			onReload(err)
+		}
+	})
+	return nil
+}
+
+// uriSolvers applies the load context, the enc, exec and http protocols, the
+// URI cache and sensitive key tracking to the URI solvers in in, and to the
+// ref solvers with the file system of the first URI solver, so $ref includes
+// read the same files as @include://.
+func (c *Container[C]) uriSolvers(ctx context.Context, in []solvers.ConfigSolver) []solvers.ConfigSolver {
+	opts := []solvers.URISolverOption{
+		solvers.WithURIContext(ctx),
+		solvers.WithURISensitiveKeyHandler(c.markSensitiveResolvedKey),
+	}
+	if c.encryptionKeys != nil {
+		opts = append(opts, solvers.WithURIEncryptionKeys(c.encryptionKeys))
+	}
+	if c.execOptions != nil {
+		opts = append(opts, solvers.WithURIExecProtocol(*c.execOptions))
+	}
+	if c.httpOptions != nil {
+		opts = append(opts, solvers.WithURIHTTPProtocol(*c.httpOptions))
+	}
+	if c.uriCache != nil {
+		opts = append(opts, solvers.WithURICache(c.uriCache))
+	}
+
+	refOpts := opts
+	if fsys := firstURISolverFS(in); fsys != nil {
+		refOpts = append(append([]solvers.URISolverOption{}, opts...), solvers.WithURIFS(fsys))
+	}
+
+	out := make([]solvers.ConfigSolver, 0, len(in))
+	for _, solver := range in {
+		updated, ok := solvers.ReplaceURISolverOptions(solver, opts...)
+		if !ok {
+			updated, _ = solvers.ReplaceRefSolverOptions(solver, refOpts...)
+		}
+		out = append(out, updated)
+	}
+	return out
+}
+
+func (c *Container[C]) markSensitiveResolvedKey(key string) {
+	if c.resolvedSensitiveKeys == nil {
+		c.resolvedSensitiveKeys = map[string]struct{}{}
+	}
+	c.resolvedSensitiveKeys[key] = struct{}{}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+)
+
+type uriCacheConfig struct {
+	Shared struct {
+		Host string `koanf:"host"`
+	} `koanf:"shared"`
+}
+
+func (uriCacheConfig) Validate() error { return nil }
+
+func TestWatchURIFiles_ReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "common.json")
+	if err := os.WriteFile(target, []byte(`{"host": "a"}`), 0o600); err != nil {
+		t.Fatalf("write include: %v", err)
+	}
+
+	evicted := make(chan string, 4)
+	cfg := New(uriCacheConfig{}).
+		WithURICache(URICacheOptions{
+			TTL:     map[string]time.Duration{"include": time.Hour},
+			OnEvict: func(uri string, _ solvers.URICacheEvictReason) { evicted <- uri },
+		}).
+		WithSolvers(solvers.NewURISolverWithFS("@", "://", os.DirFS(dir))).
+		WithProvider(DefaultValuesProvider[uriCacheConfig](map[string]any{
+			"shared": "@include://file://common.json",
+		}))
+
+	if err := cfg.Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.Raw().Shared.Host; got != "a" {
+		t.Fatalf("expected host a, got %q", got)
+	}
+	if cfg.URICache().Len() != 1 {
+		t.Fatalf("expected one cached include, got %d", cfg.URICache().Len())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan error, 1)
+	if err := cfg.WatchURIFiles(ctx, 10*time.Millisecond, func(err error) { reloaded <- err }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+
+	if err := os.WriteFile(target, []byte(`{"host": "b"}`), 0o600); err != nil {
+		t.Fatalf("write include: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(target, later, later); err != nil {
+		t.Fatalf("touch include: %v", err)
+	}
+
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatalf("reload: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a reload after the include changed")
+	}
+	cancel()
+
+	if uri := <-evicted; uri != "include://file://common.json" {
+		t.Fatalf("expected include eviction, got %q", uri)
+	}
+	if got := cfg.Raw().Shared.Host; got != "b" {
+		t.Fatalf("expected host b after reload, got %q", got)
+	}
+}
+
+func TestWatchURIFiles_RequiresCache(t *testing.T) {
+	err := New(uriCacheConfig{}).WatchURIFiles(context.Background(), time.Second, nil)
+	if err == nil {
+		t.Fatal("expected an error without WithURICache")
+	}
+}
+
+func TestWithHTTPProtocol_AllowsListedHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"host": "remote"}`))
+	}))
+	defer server.Close()
+
+	defaults := map[string]any{"shared": "@include://" + server.URL + "/shared.json"}
+
+	cfg := New(uriCacheConfig{}).
+		WithProvider(DefaultValuesProvider[uriCacheConfig](defaults))
+	if err := cfg.Load(context.Background()); err == nil {
+		t.Fatalf("expected http to be off by default, got %+v", cfg.Raw())
+	}
+
+	cfg = New(uriCacheConfig{}).
+		WithHTTPProtocol(HTTPOptions{Allow: []string{strings.TrimPrefix(server.URL, "http://")}}).
+		WithProvider(DefaultValuesProvider[uriCacheConfig](defaults))
+	if err := cfg.Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.Raw().Shared.Host; got != "remote" {
+		t.Fatalf("expected host remote, got %q", got)
+	}
+}
+
+func TestRefDirectives_UseContainerURIOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"redis": {"host": "remote"}}`))
+	}))
+	defer server.Close()
+
+	cfg := New(uriCacheConfig{}).
+		WithRefDirectives().
+		WithHTTPProtocol(HTTPOptions{Allow: []string{strings.TrimPrefix(server.URL, "http://")}}).
+		WithProvider(DefaultValuesProvider[uriCacheConfig](map[string]any{
+			"shared": map[string]any{"$ref": "include://" + server.URL + "/common.json#/redis"},
+		}))
+	if err := cfg.Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.Raw().Shared.Host; got != "remote" {
+		t.Fatalf("expected host remote, got %q", got)
+	}
+}
+
+func TestRefDirectives_ReadURISolverFSAndCache(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "common.json"), []byte(`{"redis": {"host": "a"}}`), 0o600); err != nil {
+		t.Fatalf("write include: %v", err)
+	}
+
+	cfg := New(uriCacheConfig{}).
+		WithRefDirectives().
+		WithURICache(URICacheOptions{TTL: map[string]time.Duration{"include": time.Hour}}).
+		WithSolvers(solvers.NewURISolverWithFS("@", "://", os.DirFS(dir))).
+		WithProvider(DefaultValuesProvider[uriCacheConfig](map[string]any{
+			"shared": map[string]any{"$ref": "include://file://common.json#/redis"},
+		}))
+	if err := cfg.Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.Raw().Shared.Host; got != "a" {
+		t.Fatalf("expected host a, got %q", got)
+	}
+	if cfg.URICache().Len() != 1 {
+		t.Fatalf("expected the $ref include to be cached, got %d entries", cfg.URICache().Len())
+	}
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const validateTagName = "validate"
+
+// WithTagValidation checks `validate` struct tags on the decoded config, e.g.
+//
+//	Port     int           `koanf:"port" validate:"required,min=1,max=65535"`
+//	Level    string        `koanf:"level" validate:"oneof=debug info warn error"`
+//	Endpoint string        `koanf:"endpoint" validate:"url"`
+//	Listen   string        `koanf:"listen" validate:"hostport"`
+//	Timeout  time.Duration `koanf:"timeout" validate:"duration_min=1s"`
+//
+// Every failing rule becomes a ValidationIssue with the koanf key path of the
+// field. Tag validation runs after normalizers and before validators, in
+// semantic validation mode only.
+func (c *Container[C]) WithTagValidation() *Container[C] {
+	c.tagValidation = true
+	return c
+}
+
+// WithValidationRule registers or replaces a `validate` tag rule by name, so
+// `validate:"semver"` calls rule. Rules do not enable tag validation, see
+// WithTagValidation.
+func (c *Container[C]) WithValidationRule(name string, rule ValidationRule) *Container[C] {
+	name = strings.TrimSpace(name)
+	if name == "" || rule == nil {
+		return c
+	}
+	if c.validationRules == nil {
+		c.validationRules = map[string]ValidationRule{}
+	}
+	c.validationRules[name] = rule
+	return c
+}
+
+func (c *Container[C]) validationRule(name string) (ValidationRule, bool) {
+	if rule, ok := c.validationRules[name]; ok {
+		return rule, true
+	}
+	rule, ok := builtinValidationRules[name]
+	return rule, ok
+}
+
+func (c *Container[C]) runTagValidation(record func(stage, path, code string, err error) error) error {
+	root := reflect.ValueOf(&c.base).Elem()
+	return c.validateTaggedValue(root, "", record)
+}
+
+// validateTaggedValue walks value like transformValue, descending into
+// structs, pointers to structs, and slices and maps of structs.
+func (c *Container[C]) validateTaggedValue(
+	value reflect.Value,
+	path string,
+	record func(stage, path, code string, err error) error,
+) error {
+	if !value.IsValid() {
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return c.validateTaggedValue(value.Elem(), path, record)
+	case reflect.Struct:
+		return c.validateTaggedStruct(value, path, record)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := c.validateTaggedValue(value.Index(i), joinKeyPath(path, strconv.Itoa(i)), record); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			if err := c.validateTaggedValue(value.MapIndex(key), joinKeyPath(path, key.String()), record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Container[C]) validateTaggedStruct(
+	value reflect.Value,
+	path string,
+	record func(stage, path, code string, err error) error,
+) error {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		nextPath, ok := fieldKeyPath(field, path)
+		if !ok {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if tag := strings.TrimSpace(field.Tag.Get(validateTagName)); tag != "" && tag != "-" {
+			if err := c.validateField(fieldValue, nextPath, tag, record); err != nil {
+				return err
+			}
+		}
+
+		if err := c.validateTaggedValue(fieldValue, nextPath, record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateField applies each rule of tag to value. Unset optionals and nil
+// pointers only fail required. A set optional passes required even with the
+// zero value.
+func (c *Container[C]) validateField(
+	value reflect.Value,
+	path, tag string,
+	record func(stage, path, code string, err error) error,
+) error {
+	target, set, optional := validationTarget(value)
+
+	for _, entry := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		fieldErr := &FieldValidationError{Path: path, Rule: name, Param: param}
+		if name == "required" {
+			if set && (optional || !target.IsZero()) {
+				continue
+			}
+			fieldErr.Err = stderrors.New("is required")
+		} else {
+			rule, ok := c.validationRule(name)
+			if !ok {
+				fieldErr.Err = invalidRuleError{msg: fmt.Sprintf("unknown validation rule %q", name)}
+			} else if !set {
+				continue
+			} else {
+				fieldErr.Err = invokeValidationRule(rule, target, param)
+			}
+		}
+		if fieldErr.Err == nil {
+			continue
+		}
+
+		code := "CONFIG_FIELD_" + strings.ToUpper(name)
+		var invalid invalidRuleError
+		if stderrors.As(fieldErr.Err, &invalid) {
+			code = "CONFIG_FIELD_TAG_INVALID"
+		}
+		if err := record("validate", path, code, fieldErr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func invokeValidationRule(rule ValidationRule, value reflect.Value, param string) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("validation rule panic: %v", recovered)
+		}
+	}()
+	return rule(value, param)
+}
+
+// validationTarget unwraps pointers, Secret, Optional and OptionalBool. set is
+// false for nil pointers and unset optionals, and optional reports whether
+// presence came from an Optional or OptionalBool.
+func validationTarget(value reflect.Value) (target reflect.Value, set bool, optional bool) {
+	for {
+		if !value.IsValid() {
+			return value, false, optional
+		}
+		if value.CanInterface() {
+			if secret, ok := value.Interface().(secretValue); ok {
+				value = reflect.ValueOf(secret.revealAny())
+				continue
+			}
+		}
+		if accessor, ok := optionalAccessor(value); ok {
+			optional = true
+			if !accessor.IsSet() {
+				return value, false, optional
+			}
+			value = reflect.ValueOf(accessor).MethodByName("Value").Call(nil)[0]
+			continue
+		}
+		if value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return value, false, optional
+			}
+			value = value.Elem()
+			continue
+		}
+		return value, true, optional
+	}
+}
+
+func optionalAccessor(value reflect.Value) (interface{ IsSet() bool }, bool) {
+	if value.Kind() == reflect.Pointer && value.IsNil() {
+		return nil, false
+	}
+	if value.Kind() != reflect.Pointer && value.CanAddr() {
+		value = value.Addr()
+	}
+	if !value.CanInterface() || value.Kind() != reflect.Pointer {
+		return nil, false
+	}
+	switch optional := value.Interface().(type) {
+	case *OptionalBool:
+		return optional, true
+	case optionalValue:
+		return optional, true
+	}
+	return nil, false
+}
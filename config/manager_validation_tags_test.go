@@ -0,0 +1,171 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goliatone/go-errors"
+)
+
+type TagValidationServer struct {
+	Port   int    `koanf:"port" validate:"required,min=1,max=65535"`
+	Listen string `koanf:"listen" validate:"hostport"`
+}
+
+type tagValidationBackend struct {
+	URL string `koanf:"url" validate:"required,url"`
+}
+
+type tagValidationConfig struct {
+	TagValidationServer `koanf:"server"`
+	App                 struct {
+		Name     string         `koanf:"name" validate:"required,min=3"`
+		Level    string         `koanf:"level" validate:"oneof=debug info warn error"`
+		Timeout  time.Duration  `koanf:"timeout" validate:"duration_min=1s,duration_max=1m"`
+		Tags     []string       `koanf:"tags" validate:"max=2"`
+		Version  string         `koanf:"version" validate:"semver"`
+		Password Secret[string] `koanf:"password" validate:"required"`
+		Retries  *Optional[int] `koanf:"retries" validate:"min=1"`
+		Debug    *OptionalBool  `koanf:"debug" validate:"required"`
+	} `koanf:"app"`
+	Backends []tagValidationBackend `koanf:"backends"`
+}
+
+func (c *tagValidationConfig) Validate() error { return nil }
+
+func semverRule(value reflect.Value, _ string) error {
+	if value.Kind() != reflect.String || !strings.HasPrefix(value.String(), "v") {
+		return fmt.Errorf("must be a semantic version")
+	}
+	return nil
+}
+
+func loadTagValidationConfig(t *testing.T, values map[string]any) error {
+	t.Helper()
+	return New(&tagValidationConfig{}).
+		WithConfigPath("").
+		WithFailFast(false).
+		WithTagValidation().
+		WithValidationRule("semver", semverRule).
+		WithProvider(DefaultValuesProvider[*tagValidationConfig](values)).
+		Load(context.Background())
+}
+
+func TestTagValidation_ReportsIssuePerRule(t *testing.T) {
+	err := loadTagValidationConfig(t, map[string]any{
+		"server": map[string]any{"port": 70000, "listen": "localhost"},
+		"app": map[string]any{
+			"name":    "ab",
+			"level":   "trace",
+			"timeout": "500ms",
+			"tags":    []any{"a", "b", "c"},
+			"version": "1.0.0",
+			"retries": 0,
+		},
+		"backends": []any{
+			map[string]any{"url": "https://ok.example.com"},
+			map[string]any{"url": "not a url"},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+
+	var report *ValidationReport
+	if !errors.As(err, &report) {
+		t.Fatalf("expected validation report, got %v", err)
+	}
+
+	got := map[string]string{}
+	for _, issue := range report.Issues {
+		if issue.Stage != "validate" {
+			t.Fatalf("expected validate stage, got %+v", issue)
+		}
+		got[issue.Path] = issue.Code
+	}
+	want := map[string]string{
+		"server.port":    "CONFIG_FIELD_MAX",
+		"server.listen":  "CONFIG_FIELD_HOSTPORT",
+		"app.name":       "CONFIG_FIELD_MIN",
+		"app.level":      "CONFIG_FIELD_ONEOF",
+		"app.timeout":    "CONFIG_FIELD_DURATION_MIN",
+		"app.tags":       "CONFIG_FIELD_MAX",
+		"app.version":    "CONFIG_FIELD_SEMVER",
+		"app.password":   "CONFIG_FIELD_REQUIRED",
+		"app.retries":    "CONFIG_FIELD_MIN",
+		"app.debug":      "CONFIG_FIELD_REQUIRED",
+		"backends.1.url": "CONFIG_FIELD_URL",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected issues:\n got %v\nwant %v", got, want)
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Path != "app.level" {
+			continue
+		}
+		var fieldErr *FieldValidationError
+		if !errors.As(issue.Cause, &fieldErr) || fieldErr.Rule != "oneof" || fieldErr.Param != "debug info warn error" {
+			t.Fatalf("expected oneof field error, got %#v", issue.Cause)
+		}
+		if issue.Message != "app.level: must be one of [debug info warn error]" {
+			t.Fatalf("unexpected message %q", issue.Message)
+		}
+	}
+}
+
+func TestTagValidation_PassesValidConfig(t *testing.T) {
+	err := loadTagValidationConfig(t, map[string]any{
+		"server": map[string]any{"port": 8080, "listen": ":8080"},
+		"app": map[string]any{
+			"name":     "api",
+			"level":    "info",
+			"timeout":  "5s",
+			"version":  "v1.2.0",
+			"password": "s3cret",
+			"debug":    false,
+		},
+		"backends": []any{map[string]any{"url": "https://ok.example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+}
+
+func TestTagValidation_InvalidTag(t *testing.T) {
+	var report *ValidationReport
+	err := New(&badTagConfig{}).
+		WithConfigPath("").
+		WithFailFast(false).
+		WithTagValidation().
+		Load(context.Background())
+	if !errors.As(err, &report) || len(report.Issues) != 3 {
+		t.Fatalf("expected three tag issues, got %v", err)
+	}
+	for _, issue := range report.Issues {
+		if issue.Code != "CONFIG_FIELD_TAG_INVALID" {
+			t.Fatalf("expected CONFIG_FIELD_TAG_INVALID, got %+v", issue)
+		}
+	}
+}
+
+type badTagConfig struct {
+	Port  int    `koanf:"port" validate:"min=abc"`
+	Name  string `koanf:"name" validate:"email"`
+	Count int    `koanf:"count" validate:"url"`
+}
+
+func (c *badTagConfig) Validate() error { return nil }
+
+func TestTagValidation_DisabledByDefault(t *testing.T) {
+	err := New(&tagValidationConfig{}).
+		WithConfigPath("").
+		Load(context.Background())
+	if err != nil {
+		t.Fatalf("expected tags to be ignored without WithTagValidation, got %v", err)
+	}
+}
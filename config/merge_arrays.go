@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Array merge directives understood by MergeWithBooleanPrecedence. A source
+// value made only of directive keys updates the destination list instead of
+// replacing it:
+//
+//	{"origins": {"$append": ["https://admin.example.com"]}}
+//	{"servers": {"$merge": [{"id": "api", "port": 9090}], "$key": "id"}}
+const (
+	MergeDirectiveAppend  = "$append"
+	MergeDirectivePrepend = "$prepend"
+	MergeDirectiveReplace = "$replace"
+	MergeDirectiveRemove  = "$remove"
+	MergeDirectiveMerge   = "$merge"
+	MergeDirectiveKey     = "$key"
+)
+
+// DefaultArrayMergeKey is the field used to match objects for $merge and
+// $remove when no $key is given.
+const DefaultArrayMergeKey = "id"
+
+var arrayMergeDirectives = map[string]struct{}{
+	MergeDirectiveAppend:  {},
+	MergeDirectivePrepend: {},
+	MergeDirectiveReplace: {},
+	MergeDirectiveRemove:  {},
+	MergeDirectiveMerge:   {},
+	MergeDirectiveKey:     {},
+}
+
+// isArrayMergeDirective reports whether value is a map whose keys are all
+// array merge directives.
+func isArrayMergeDirective(value any) (map[string]any, bool) {
+	directive, ok := value.(map[string]any)
+	if !ok || len(directive) == 0 {
+		return nil, false
+	}
+	for key := range directive {
+		if _, known := arrayMergeDirectives[key]; !known {
+			return nil, false
+		}
+	}
+	if _, onlyKey := directive[MergeDirectiveKey]; onlyKey && len(directive) == 1 {
+		return nil, false
+	}
+	return directive, true
+}
+
+// applyArrayMergeDirective applies a directive map to the current value at
+// path. Directives run in a fixed order: $replace, $remove, $merge, $prepend
+// and $append. A missing or non list current value counts as an empty list.
+func (m booleanMerger) applyArrayMergeDirective(path string, directive map[string]any, current any) ([]any, error) {
+	key := DefaultArrayMergeKey
+	if rawKey, ok := directive[MergeDirectiveKey]; ok {
+		name, isString := rawKey.(string)
+		if !isString || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("merge directive at %s: %s must be a field name", path, MergeDirectiveKey)
+		}
+		key = strings.TrimSpace(name)
+	}
+
+	list, _ := current.([]any)
+	out := append([]any{}, list...)
+
+	if items, ok := directive[MergeDirectiveReplace]; ok {
+		out = directiveItems(items)
+	}
+
+	if items, ok := directive[MergeDirectiveRemove]; ok {
+		removed := directiveItems(items)
+		kept := out[:0:0]
+		for _, item := range out {
+			if !containsArrayItem(removed, item, key) {
+				kept = append(kept, item)
+			}
+		}
+		out = kept
+	}
+
+	if items, ok := directive[MergeDirectiveMerge]; ok {
+		for _, item := range directiveItems(items) {
+			obj, isMap := item.(map[string]any)
+			id, hasID := obj[key]
+			if !isMap || !hasID {
+				return nil, fmt.Errorf("merge directive at %s: %s items must be objects with a %q field", path, MergeDirectiveMerge, key)
+			}
+			index := indexOfKeyedItem(out, key, id)
+			if index == -1 {
+				out = append(out, item)
+				continue
+			}
+			existing, _ := out[index].(map[string]any)
+			merged := make(map[string]any, len(existing))
+			for k, v := range existing {
+				merged[k] = v
+			}
+			if err := m.merge(path, obj, merged); err != nil {
+				return nil, err
+			}
+			out[index] = merged
+		}
+	}
+
+	if items, ok := directive[MergeDirectivePrepend]; ok {
+		out = append(directiveItems(items), out...)
+	}
+
+	if items, ok := directive[MergeDirectiveAppend]; ok {
+		out = append(out, directiveItems(items)...)
+	}
+
+	return out, nil
+}
+
+// directiveItems accepts a list or a single value, e.g. a value from an
+// environment variable.
+func directiveItems(value any) []any {
+	if value == nil {
+		return []any{}
+	}
+	if list, ok := value.([]any); ok {
+		return append([]any{}, list...)
+	}
+	return []any{value}
+}
+
+// containsArrayItem matches objects by key, either against another object or
+// a bare key value, and any other value by deep equality.
+func containsArrayItem(items []any, item any, key string) bool {
+	for _, candidate := range items {
+		if keyedItemsMatch(candidate, item, key) || reflect.DeepEqual(candidate, item) {
+			return true
+		}
+		if obj, ok := item.(map[string]any); ok {
+			if _, isMap := candidate.(map[string]any); !isMap {
+				if id, has := obj[key]; has && fmt.Sprint(id) == fmt.Sprint(candidate) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func indexOfKeyedItem(items []any, key string, id any) int {
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if existing, has := obj[key]; has && fmt.Sprint(existing) == fmt.Sprint(id) {
+			return i
+		}
+	}
+	return -1
+}
+
+func keyedItemsMatch(a, b any, key string) bool {
+	objA, okA := a.(map[string]any)
+	objB, okB := b.(map[string]any)
+	if !okA || !okB {
+		return false
+	}
+	idA, hasA := objA[key]
+	idB, hasB := objB[key]
+	return hasA && hasB && fmt.Sprint(idA) == fmt.Sprint(idB)
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeWithBooleanPrecedence_ArrayDirectives(t *testing.T) {
+	servers := func() []any {
+		return []any{
+			map[string]any{"id": "api", "port": 8080, "tls": map[string]any{"enabled": false}},
+			map[string]any{"id": "worker", "port": 8081},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		dest     any
+		src      any
+		expected any
+	}{
+		{
+			name:     "append",
+			dest:     []any{"a", "b"},
+			src:      map[string]any{"$append": []any{"c"}},
+			expected: []any{"a", "b", "c"},
+		},
+		{
+			name:     "prepend single value",
+			dest:     []any{"b"},
+			src:      map[string]any{"$prepend": "a"},
+			expected: []any{"a", "b"},
+		},
+		{
+			name:     "replace",
+			dest:     []any{"a", "b"},
+			src:      map[string]any{"$replace": []any{"z"}},
+			expected: []any{"z"},
+		},
+		{
+			name:     "remove",
+			dest:     []any{"a", "b", "a", "c"},
+			src:      map[string]any{"$remove": []any{"a"}},
+			expected: []any{"b", "c"},
+		},
+		{
+			name:     "combined",
+			dest:     []any{"a", "b"},
+			src:      map[string]any{"$remove": "a", "$append": "d", "$prepend": "c"},
+			expected: []any{"c", "b", "d"},
+		},
+		{
+			name:     "missing destination",
+			dest:     nil,
+			src:      map[string]any{"$append": []any{"a"}},
+			expected: []any{"a"},
+		},
+		{
+			name: "keyed merge",
+			dest: servers(),
+			src: map[string]any{"$merge": []any{
+				map[string]any{"id": "api", "tls": map[string]any{"enabled": true}},
+				map[string]any{"id": "cron", "port": 8082},
+			}},
+			expected: []any{
+				map[string]any{"id": "api", "port": 8080, "tls": map[string]any{"enabled": true}},
+				map[string]any{"id": "worker", "port": 8081},
+				map[string]any{"id": "cron", "port": 8082},
+			},
+		},
+		{
+			name: "keyed merge with custom key",
+			dest: []any{map[string]any{"name": "api", "port": 8080}},
+			src: map[string]any{
+				"$key":   "name",
+				"$merge": []any{map[string]any{"name": "api", "port": 9090}},
+			},
+			expected: []any{map[string]any{"name": "api", "port": 9090}},
+		},
+		{
+			name:     "remove objects by id",
+			dest:     servers(),
+			src:      map[string]any{"$remove": []any{"api"}},
+			expected: []any{map[string]any{"id": "worker", "port": 8081}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := map[string]any{}
+			if tt.dest != nil {
+				dest["list"] = tt.dest
+			}
+			src := map[string]any{"list": tt.src}
+
+			if err := MergeWithBooleanPrecedence(src, dest); err != nil {
+				t.Fatalf("MergeWithBooleanPrecedence failed: %v", err)
+			}
+			if !reflect.DeepEqual(dest["list"], tt.expected) {
+				t.Fatalf("expected %#v, got %#v", tt.expected, dest["list"])
+			}
+		})
+	}
+}
+
+func TestMergeWithBooleanPrecedence_ArrayDirectiveErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  map[string]any
+	}{
+		{
+			name: "merge item without key",
+			src:  map[string]any{"list": map[string]any{"$merge": []any{map[string]any{"port": 1}}}},
+		},
+		{
+			name: "merge scalar item",
+			src:  map[string]any{"list": map[string]any{"$merge": []any{"a"}}},
+		},
+		{
+			name: "invalid key",
+			src:  map[string]any{"list": map[string]any{"$merge": []any{}, "$key": 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := MergeWithBooleanPrecedence(tt.src, map[string]any{"list": []any{}})
+			if err == nil || !strings.Contains(err.Error(), "merge directive at list") {
+				t.Fatalf("expected merge directive error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMergeWithBooleanPrecedence_IgnoresMixedMaps(t *testing.T) {
+	src := map[string]any{
+		"list": map[string]any{"$append": []any{"a"}, "other": true},
+	}
+	dest := map[string]any{}
+
+	if err := MergeWithBooleanPrecedence(src, dest); err != nil {
+		t.Fatalf("MergeWithBooleanPrecedence failed: %v", err)
+	}
+	if _, ok := dest["list"].(map[string]any); !ok {
+		t.Fatalf("expected map with non directive keys to merge as a map, got %#v", dest["list"])
+	}
+}
+
+type arrayDirectiveConfig struct {
+	CORS struct {
+		Origins []string `koanf:"origins"`
+	} `koanf:"cors"`
+	Servers []struct {
+		ID   string `koanf:"id"`
+		Port int    `koanf:"port"`
+	} `koanf:"servers"`
+}
+
+func (c *arrayDirectiveConfig) Validate() error { return nil }
+
+func TestContainerArrayDirectivesFollowProviderPriority(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	overlay := filepath.Join(dir, "overlay.json")
+	if err := os.WriteFile(base, []byte(`{
+		"cors": {"origins": ["https://app.example.com"]},
+		"servers": [{"id": "api", "port": 8080}, {"id": "worker", "port": 8081}]
+	}`), 0o600); err != nil {
+		t.Fatalf("write base: %v", err)
+	}
+	if err := os.WriteFile(overlay, []byte(`{
+		"cors": {"origins": {"$append": ["https://admin.example.com"]}},
+		"servers": {"$merge": [{"id": "api", "port": 9090}], "$remove": ["worker"]}
+	}`), 0o600); err != nil {
+		t.Fatalf("write overlay: %v", err)
+	}
+	t.Setenv("ARRDIR_CORS__ORIGINS__$PREPEND", "https://local.test")
+
+	cfg := &arrayDirectiveConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(
+			EnvProvider[*arrayDirectiveConfig]("ARRDIR_", "__"),
+			FileProvider[*arrayDirectiveConfig](overlay, int(PriorityConfig.WithOffset(1))),
+			FileProvider[*arrayDirectiveConfig](base),
+		)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	expectedOrigins := []string{"https://local.test", "https://app.example.com", "https://admin.example.com"}
+	if !reflect.DeepEqual(cfg.CORS.Origins, expectedOrigins) {
+		t.Fatalf("expected origins %v, got %v", expectedOrigins, cfg.CORS.Origins)
+	}
+	if len(cfg.Servers) != 1 || cfg.Servers[0].ID != "api" || cfg.Servers[0].Port != 9090 {
+		t.Fatalf("unexpected servers %+v", cfg.Servers)
+	}
+}
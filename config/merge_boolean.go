@@ -1,26 +1,119 @@
 package config
 
-import "strings"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DeletePolicy controls whether a higher priority layer can delete a key set
+// by a lower layer during MergeWithBooleanPrecedence.
+type DeletePolicy int
+
+const (
+	// DeleteNever keeps the lower layer value for nil and "" (default).
+	DeleteNever DeletePolicy = iota
+	// DeleteOnUnset deletes keys whose value is the UnsetValue sentinel.
+	DeleteOnUnset
+	// DeleteOnNull deletes keys whose value is an explicit null, as well as
+	// keys set to UnsetValue.
+	DeleteOnNull
+)
+
+// UnsetValue is the sentinel that deletes a key under DeleteOnUnset and
+// DeleteOnNull, e.g. {"cache": {"ttl": "$unset"}}.
+const UnsetValue = "$unset"
 
 // MergeWithBooleanPrecedence merges src into dst with OptionalBool-aware precedence logic.
 // OptionalBool values keep their pointer/value form and only overwrite when explicitly set.
+// Array merge directives ($append, $prepend, $replace, $remove, $merge) update
+// the destination list instead of replacing it.
 func MergeWithBooleanPrecedence(src, dst map[string]any) error {
-	return mergeRecursive(src, dst)
+	return booleanMerger{}.merge("", src, dst)
+}
+
+// MergeWithDeletePolicy returns MergeWithBooleanPrecedence with the given
+// delete policy applied.
+func MergeWithDeletePolicy(policy DeletePolicy) func(src, dst map[string]any) error {
+	merger := booleanMerger{deletePolicy: policy}
+	return func(src, dst map[string]any) error {
+		return merger.merge("", src, dst)
+	}
 }
 
-func mergeRecursive(src, dst map[string]any) error {
+type booleanMerger struct {
+	deletePolicy DeletePolicy
+	// nullString treats the string "null" as an explicit null, used for
+	// layers such as env vars that cannot express a real null.
+	nullString bool
+	strategies mergeStrategies
+	// plain overwrites values koanf style for paths without a strategy,
+	// used by providers that do not merge with boolean precedence.
+	plain bool
+	// strict rejects type changes on plain paths like koanf's StrictMerge.
+	strict    bool
+	conflicts *mergeConflictState
+	keep      *mergeKeepState
+	// trust ranks the provider being merged for MergeKeep paths.
+	trust int
+}
+
+func (m booleanMerger) merge(path string, src, dst map[string]any) error {
 	for key, srcVal := range src {
 		dstVal, exists := dst[key]
+		keyPath := joinKeyPath(path, key)
+		strategy, hasStrategy := m.strategies.lookup(keyPath)
+
+		if hasStrategy && strategy == MergeKeep && exists && m.keep.keeps(keyPath, m.trust) {
+			continue
+		}
+
+		if m.deletes(srcVal) {
+			delete(dst, key)
+			continue
+		}
+
+		if directive, ok := isArrayMergeDirective(srcVal); ok {
+			merged, err := m.applyArrayMergeDirective(keyPath, directive, dstVal)
+			if err != nil {
+				return err
+			}
+			dst[key] = merged
+			continue
+		}
+
+		if m.conflicts != nil && exists && m.conflicts.check(keyPath, dstVal, srcVal) {
+			continue
+		}
+
+		if hasStrategy && m.mergeWithStrategy(strategy, dst, key, srcVal) {
+			continue
+		}
 
 		if srcMap, ok := srcVal.(map[string]any); ok {
 			if dstMap, ok := dstVal.(map[string]any); ok {
-				if err := mergeRecursive(srcMap, dstMap); err != nil {
+				if err := m.merge(keyPath, srcMap, dstMap); err != nil {
 					return err
 				}
 				continue
 			}
 		}
 
+		if m.plain {
+			if m.strict && exists && reflect.TypeOf(dstVal) != reflect.TypeOf(srcVal) {
+				return fmt.Errorf("incorrect types at key %s, type %T != %T", keyPath, dstVal, srcVal)
+			}
+			dst[key] = srcVal
+			continue
+		}
+
+		if optional, ok := asOptionalValue(srcVal); ok {
+			if optional.IsSet() {
+				dst[key] = srcVal
+			}
+			continue
+		}
+
 		handled, err := mergeOptionalBoolValue(dst, key, srcVal, dstVal)
 		if err != nil {
 			return err
@@ -36,6 +129,24 @@ func mergeRecursive(src, dst map[string]any) error {
 	return nil
 }
 
+// deletes reports whether value removes its key under the delete policy.
+func (m booleanMerger) deletes(value any) bool {
+	switch m.deletePolicy {
+	case DeleteOnNull:
+		if value == nil {
+			return true
+		}
+		if str, ok := value.(string); ok && m.nullString && strings.EqualFold(strings.TrimSpace(str), "null") {
+			return true
+		}
+	case DeleteOnUnset:
+	default:
+		return false
+	}
+	str, ok := value.(string)
+	return ok && strings.TrimSpace(str) == UnsetValue
+}
+
 func shouldOverwriteValue(src any, dstVal any, dstExists bool) bool {
 	if src == nil {
 		return false
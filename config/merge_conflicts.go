@@ -0,0 +1,231 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goliatone/go-config/logger"
+)
+
+// MergeConflictMode selects what happens when a provider sets a key to a
+// value whose shape (map, list or scalar) differs from the current value.
+type MergeConflictMode int
+
+const (
+	// MergeConflictModeOff disables detection. This is the default.
+	MergeConflictModeOff MergeConflictMode = iota
+	// MergeConflictModeLastWins keeps the later value and records the conflict.
+	MergeConflictModeLastWins
+	// MergeConflictModeWarn keeps the later value and logs a warning.
+	MergeConflictModeWarn
+	// MergeConflictModeError keeps the current value and fails Load with a
+	// MergeConflictError once the provider is merged.
+	MergeConflictModeError
+)
+
+func (m MergeConflictMode) String() string {
+	switch m {
+	case MergeConflictModeOff:
+		return "off"
+	case MergeConflictModeLastWins:
+		return "last-wins"
+	case MergeConflictModeWarn:
+		return "warn"
+	case MergeConflictModeError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// MergeConflict describes a key set to values of different shapes by two
+// providers.
+type MergeConflict struct {
+	Path             string `json:"path"`
+	ExistingType     string `json:"existing_type"`
+	IncomingType     string `json:"incoming_type"`
+	ExistingProvider string `json:"existing_provider"`
+	IncomingProvider string `json:"incoming_provider"`
+}
+
+func (c MergeConflict) String() string {
+	return fmt.Sprintf("%s: %s from %s conflicts with %s from %s",
+		c.Path, c.IncomingType, c.IncomingProvider, c.ExistingType, c.ExistingProvider)
+}
+
+// MergeConflictError reports the conflicts found while merging a provider
+// with MergeConflictModeError.
+type MergeConflictError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *MergeConflictError) Error() string {
+	if e == nil || len(e.Conflicts) == 0 {
+		return "merge conflict"
+	}
+	if len(e.Conflicts) == 1 {
+		return "merge conflict at " + e.Conflicts[0].String()
+	}
+	parts := make([]string, 0, len(e.Conflicts))
+	for _, conflict := range e.Conflicts {
+		parts = append(parts, conflict.String())
+	}
+	return fmt.Sprintf("%d merge conflicts: %s", len(e.Conflicts), strings.Join(parts, "; "))
+}
+
+// keyOrigins tracks which provider set each key during a Load, for merge
+// conflicts and solver trace events.
+type keyOrigins struct {
+	provider string
+	paths    map[string]string
+}
+
+func newKeyOrigins(enabled bool) *keyOrigins {
+	if !enabled {
+		return nil
+	}
+	return &keyOrigins{paths: map[string]string{}}
+}
+
+// setProvider names the provider whose values are recorded next.
+func (o *keyOrigins) setProvider(name string) {
+	if o != nil {
+		o.provider = name
+	}
+}
+
+// record marks every key in src as set by the current provider.
+func (o *keyOrigins) record(path string, src map[string]any) {
+	if o == nil {
+		return
+	}
+	for key, value := range src {
+		keyPath := joinKeyPath(path, key)
+		o.paths[keyPath] = o.provider
+		if child, ok := value.(map[string]any); ok {
+			o.record(keyPath, child)
+		}
+	}
+}
+
+// of returns the provider that set path, or the closest parent of path, and
+// "" when none did.
+func (o *keyOrigins) of(path string) string {
+	if o == nil {
+		return ""
+	}
+	for path != "" {
+		if origin, ok := o.paths[path]; ok {
+			return origin
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return ""
+}
+
+// mergeConflictState collects the conflicts found during a Load.
+type mergeConflictState struct {
+	mode      MergeConflictMode
+	logger    logger.Logger
+	origins   *keyOrigins
+	pending   []MergeConflict
+	conflicts []MergeConflict
+}
+
+func newMergeConflictState(mode MergeConflictMode, lgr logger.Logger, origins *keyOrigins) *mergeConflictState {
+	if mode == MergeConflictModeOff {
+		return nil
+	}
+	return &mergeConflictState{
+		mode:    mode,
+		logger:  lgr,
+		origins: origins,
+	}
+}
+
+// check records a conflict between dstVal and srcVal at path and reports
+// whether the current value must be kept.
+func (s *mergeConflictState) check(path string, dstVal, srcVal any) bool {
+	if isOptionalBoolValue(dstVal) {
+		// OptionalBool accepts {"set": ..., "value": ...} payloads.
+		return false
+	}
+	existing, incoming := valueShape(dstVal), valueShape(srcVal)
+	if existing == "" || incoming == "" || existing == incoming {
+		return false
+	}
+	origin := s.origins.of(path)
+	if origin == "" {
+		origin = "unknown"
+	}
+	s.pending = append(s.pending, MergeConflict{
+		Path:             path,
+		ExistingType:     valueTypeName(dstVal),
+		IncomingType:     valueTypeName(srcVal),
+		ExistingProvider: origin,
+		IncomingProvider: s.origins.provider,
+	})
+	return s.mode == MergeConflictModeError
+}
+
+// commit flushes the conflicts found while merging a provider.
+func (s *mergeConflictState) commit() error {
+	pending := s.pending
+	s.pending = nil
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Path < pending[j].Path })
+	s.conflicts = append(s.conflicts, pending...)
+
+	if s.mode == MergeConflictModeError && len(pending) > 0 {
+		return &MergeConflictError{Conflicts: pending}
+	}
+
+	if s.mode == MergeConflictModeWarn && s.logger != nil {
+		warn := s.logger.Info
+		if w, ok := s.logger.(interface{ Warn(string, ...any) }); ok {
+			warn = w.Warn
+		}
+		for _, conflict := range pending {
+			warn("merge conflict",
+				"path", conflict.Path,
+				"existing_type", conflict.ExistingType,
+				"incoming_type", conflict.IncomingType,
+				"existing_provider", conflict.ExistingProvider,
+				"incoming_provider", conflict.IncomingProvider,
+			)
+		}
+	}
+
+	return nil
+}
+
+// valueShape groups values into map, list or scalar. Nil has no shape.
+func valueShape(value any) string {
+	switch value.(type) {
+	case nil:
+		return ""
+	case map[string]any:
+		return "map"
+	case []any:
+		return "list"
+	}
+	if _, ok := anyList(value); ok {
+		return "list"
+	}
+	return "scalar"
+}
+
+func valueTypeName(value any) string {
+	switch valueShape(value) {
+	case "map":
+		return "map"
+	case "list":
+		return "list"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
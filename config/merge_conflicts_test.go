@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/goliatone/go-errors"
+)
+
+type mergeConflictConfig struct {
+	DB    any      `koanf:"db"`
+	Tags  []string `koanf:"tags"`
+	Level string   `koanf:"level"`
+}
+
+func (c *mergeConflictConfig) Validate() error { return nil }
+
+type recordingLogger struct {
+	warnings []string
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) {}
+func (l *recordingLogger) Info(msg string, args ...any)  {}
+func (l *recordingLogger) Error(msg string, args ...any) {}
+func (l *recordingLogger) Warn(msg string, args ...any) {
+	l.warnings = append(l.warnings, fmt.Sprint(append([]any{msg}, args...)...))
+}
+
+func newMergeConflictContainer(t *testing.T, mode MergeConflictMode) (*Container[*mergeConflictConfig], string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"db": "postgres://localhost/app", "tags": "single", "level": "debug"}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	container := New(&mergeConflictConfig{}).
+		WithConfigPath("").
+		WithMergeConflicts(mode).
+		WithProvider(
+			DefaultValuesProvider[*mergeConflictConfig](map[string]any{
+				"db":    map[string]any{"host": "localhost"},
+				"tags":  []any{"a"},
+				"level": "info",
+			}),
+			FileProvider[*mergeConflictConfig](file),
+		)
+	return container, file
+}
+
+func TestContainerMergeConflictsError(t *testing.T) {
+	container, file := newMergeConflictContainer(t, MergeConflictModeError)
+
+	err := container.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected merge conflict error")
+	}
+
+	expected := []MergeConflict{
+		{Path: "db", ExistingType: "map", IncomingType: "string", ExistingProvider: "default", IncomingProvider: "file:" + file},
+		{Path: "tags", ExistingType: "list", IncomingType: "string", ExistingProvider: "default", IncomingProvider: "file:" + file},
+	}
+
+	var conflictErr *MergeConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("expected MergeConflictError, got %v", err)
+	}
+	if !reflect.DeepEqual(conflictErr.Conflicts, expected) {
+		t.Fatalf("expected conflicts %#v, got %#v", expected, conflictErr.Conflicts)
+	}
+
+	var cfgErr *errors.Error
+	if !errors.As(err, &cfgErr) || cfgErr.TextCode != "CONFIG_LOAD_FAILED" {
+		t.Fatalf("expected CONFIG_LOAD_FAILED, got %v", err)
+	}
+	if !reflect.DeepEqual(cfgErr.Metadata["merge_conflicts"], expected) {
+		t.Fatalf("unexpected merge_conflicts metadata: %#v", cfgErr.Metadata["merge_conflicts"])
+	}
+	if !reflect.DeepEqual(container.MergeConflicts(), expected) {
+		t.Fatalf("expected recorded conflicts, got %#v", container.MergeConflicts())
+	}
+}
+
+func TestContainerMergeConflictsWarn(t *testing.T) {
+	container, _ := newMergeConflictContainer(t, MergeConflictModeWarn)
+	lgr := &recordingLogger{}
+	container.WithLogger(lgr)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if len(lgr.warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", lgr.warnings)
+	}
+	if got := container.Raw().DB; got != "postgres://localhost/app" {
+		t.Fatalf("expected later value to win, got %#v", got)
+	}
+	if len(container.MergeConflicts()) != 2 {
+		t.Fatalf("expected 2 conflicts, got %#v", container.MergeConflicts())
+	}
+}
+
+func TestContainerMergeConflictsLastWins(t *testing.T) {
+	container, _ := newMergeConflictContainer(t, MergeConflictModeLastWins)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := container.Raw().Tags; !reflect.DeepEqual(got, []string{"single"}) {
+		t.Fatalf("expected later value to win, got %#v", got)
+	}
+
+	conflicts := container.MergeConflicts()
+	if len(conflicts) != 2 || conflicts[0].Path != "db" || conflicts[1].Path != "tags" {
+		t.Fatalf("unexpected conflicts %#v", conflicts)
+	}
+}
+
+func TestContainerMergeConflictsOff(t *testing.T) {
+	container, _ := newMergeConflictContainer(t, MergeConflictModeOff)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if conflicts := container.MergeConflicts(); conflicts != nil {
+		t.Fatalf("expected no conflict tracking, got %#v", conflicts)
+	}
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"path"
+	"reflect"
+	"strings"
+)
+
+// MergeStrategy selects how a later provider merges into a key path.
+type MergeStrategy int
+
+const (
+	// MergeDeep merges maps key by key and overwrites other values. This is
+	// the default for every path.
+	MergeDeep MergeStrategy = iota
+	// MergeAppend appends a later list, or a single value such as an env
+	// var, to the current list.
+	MergeAppend
+	// MergeReplace replaces the current value as a whole, maps included.
+	MergeReplace
+	// MergeKeep keeps the value set by the most trusted provider. A later
+	// provider only replaces it when it is trusted more than the provider
+	// that set it, e.g. to stop env vars from overriding secrets read from a
+	// file while still replacing defaults. See WithProviderTrust.
+	MergeKeep
+)
+
+func (s MergeStrategy) String() string {
+	switch s {
+	case MergeDeep:
+		return "deep"
+	case MergeAppend:
+		return "append"
+	case MergeReplace:
+		return "replace"
+	case MergeKeep:
+		return "keep"
+	default:
+		return "unknown"
+	}
+}
+
+type pathMergeStrategy struct {
+	pattern  string
+	strategy MergeStrategy
+}
+
+// mergeStrategies holds per path strategies in registration order.
+type mergeStrategies []pathMergeStrategy
+
+// lookup returns the strategy for key path p. An exact pattern wins over a
+// glob, and among globs the last registered match wins.
+func (s mergeStrategies) lookup(p string) (MergeStrategy, bool) {
+	var (
+		found    bool
+		strategy MergeStrategy
+	)
+	for _, entry := range s {
+		if entry.pattern == p {
+			return entry.strategy, true
+		}
+		if matchKeyPattern(entry.pattern, p) {
+			strategy, found = entry.strategy, true
+		}
+	}
+	return strategy, found
+}
+
+// matchKeyPattern matches a dot separated key path against a pattern where
+// each segment is a path.Match glob and "**" matches any number of segments.
+func matchKeyPattern(pattern, key string) bool {
+	return matchKeySegments(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+func matchKeySegments(pattern, key []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(key); i++ {
+				if matchKeySegments(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(key) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], key[0]); err != nil || !ok {
+			return false
+		}
+		pattern, key = pattern[1:], key[1:]
+	}
+	return len(key) == 0
+}
+
+// mergeWithStrategy applies strategy to key in dst. It reports false when the
+// default merge should run instead.
+func (m booleanMerger) mergeWithStrategy(strategy MergeStrategy, dst map[string]any, key string, srcVal any) bool {
+	dstVal, exists := dst[key]
+	switch strategy {
+	case MergeKeep:
+		if exists && isMapValue(srcVal) && isMapValue(dstVal) {
+			return false
+		}
+		if srcVal != nil {
+			dst[key] = srcVal
+		}
+		return true
+	case MergeReplace:
+		if srcVal != nil {
+			dst[key] = srcVal
+		}
+		return true
+	case MergeAppend:
+		srcList, ok := anyList(srcVal)
+		if !ok {
+			if srcVal == nil || isMapValue(srcVal) {
+				return false
+			}
+			srcList = []any{srcVal}
+		}
+		current, _ := anyList(dstVal)
+		dst[key] = append(current, srcList...)
+		return true
+	default:
+		return false
+	}
+}
+
+// defaultProviderTrust ranks provider types for MergeKeep paths. Defaults and
+// struct layers are fallbacks, files are trusted over env vars and flags.
+var defaultProviderTrust = map[ProviderType]int{
+	ProviderTypeDefault:   0,
+	ProviderTypeStruct:    0,
+	ProviderTypeFlag:      10,
+	ProviderTypeEnv:       10,
+	ProviderTypeLocalFile: 20,
+}
+
+// mergeKeepState tracks the trust of the provider that set each MergeKeep
+// path during a Load.
+type mergeKeepState struct {
+	origins map[string]int
+	refused map[string]struct{}
+}
+
+func newMergeKeepState(strategies mergeStrategies) *mergeKeepState {
+	for _, entry := range strategies {
+		if entry.strategy == MergeKeep {
+			return &mergeKeepState{origins: map[string]int{}, refused: map[string]struct{}{}}
+		}
+	}
+	return nil
+}
+
+// keeps reports whether the current value at path must be kept against a
+// provider with the given trust. Equally trusted providers merge in priority
+// order. Without a state the first value is kept.
+func (s *mergeKeepState) keeps(path string, trust int) bool {
+	if s == nil {
+		return true
+	}
+	origin, ok := s.origins[path]
+	if ok && origin > trust {
+		s.refused[path] = struct{}{}
+		return true
+	}
+	return false
+}
+
+// commit records trust as the origin of every MergeKeep path set by src,
+// skipping the paths refused while merging it.
+func (s *mergeKeepState) commit(strategies mergeStrategies, src map[string]any, trust int) {
+	if s == nil {
+		return
+	}
+	s.recordOrigins(strategies, "", src, trust)
+	s.refused = map[string]struct{}{}
+}
+
+func (s *mergeKeepState) recordOrigins(strategies mergeStrategies, path string, values map[string]any, trust int) {
+	for key, value := range values {
+		keyPath := joinKeyPath(path, key)
+		if _, refused := s.refused[keyPath]; refused {
+			continue
+		}
+		if strategy, ok := strategies.lookup(keyPath); ok && strategy == MergeKeep {
+			s.origins[keyPath] = trust
+		}
+		if child, ok := value.(map[string]any); ok {
+			s.recordOrigins(strategies, keyPath, child, trust)
+		}
+	}
+}
+
+// anyList copies any slice value, e.g. the []string read from a flag, into a
+// []any.
+func anyList(value any) ([]any, bool) {
+	if list, ok := value.([]any); ok {
+		return append([]any{}, list...), true
+	}
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || rv.Kind() != reflect.Slice {
+		return nil, false
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out, true
+}
+
+func isMapValue(value any) bool {
+	_, ok := value.(map[string]any)
+	return ok
+}
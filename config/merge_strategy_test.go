@@ -0,0 +1,260 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestMergeStrategiesLookup(t *testing.T) {
+	strategies := mergeStrategies{
+		{pattern: "features.*", strategy: MergeDeep},
+		{pattern: "cors.origins", strategy: MergeAppend},
+		{pattern: "**.tls", strategy: MergeReplace},
+		{pattern: "servers.*.tls", strategy: MergeKeep},
+		{pattern: "servers.api.tls", strategy: MergeReplace},
+	}
+
+	tests := []struct {
+		path     string
+		expected MergeStrategy
+		found    bool
+	}{
+		{path: "features.search", expected: MergeDeep, found: true},
+		{path: "features", found: false},
+		{path: "features.search.enabled", found: false},
+		{path: "cors.origins", expected: MergeAppend, found: true},
+		{path: "tls", expected: MergeReplace, found: true},
+		{path: "db.primary.tls", expected: MergeReplace, found: true},
+		{path: "servers.worker.tls", expected: MergeKeep, found: true},
+		{path: "servers.api.tls", expected: MergeReplace, found: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			strategy, found := strategies.lookup(tt.path)
+			if found != tt.found || strategy != tt.expected {
+				t.Fatalf("expected (%s, %v), got (%s, %v)", tt.expected, tt.found, strategy, found)
+			}
+		})
+	}
+}
+
+func TestMergeWithStrategies(t *testing.T) {
+	merger := booleanMerger{strategies: mergeStrategies{
+		{pattern: "cors.origins", strategy: MergeAppend},
+		{pattern: "tls", strategy: MergeReplace},
+		{pattern: "secrets.*", strategy: MergeKeep},
+	}}
+
+	dst := map[string]any{
+		"cors":    map[string]any{"origins": []any{"https://app.example.com"}},
+		"tls":     map[string]any{"cert": "base.pem", "key": "base.key"},
+		"secrets": map[string]any{"token": "file-token"},
+		"tags":    []any{"a"},
+	}
+	src := map[string]any{
+		"cors":    map[string]any{"origins": []string{"https://admin.example.com"}},
+		"tls":     map[string]any{"cert": "prod.pem"},
+		"secrets": map[string]any{"token": "env-token", "salt": "env-salt"},
+		"tags":    []any{"b"},
+	}
+
+	if err := merger.merge("", src, dst); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+
+	expected := map[string]any{
+		"cors":    map[string]any{"origins": []any{"https://app.example.com", "https://admin.example.com"}},
+		"tls":     map[string]any{"cert": "prod.pem"},
+		"secrets": map[string]any{"token": "file-token", "salt": "env-salt"},
+		"tags":    []any{"b"},
+	}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, dst)
+	}
+}
+
+func TestMergeKeepRefusesDeletesAndDirectives(t *testing.T) {
+	merger := booleanMerger{
+		deletePolicy: DeleteOnNull,
+		strategies:   mergeStrategies{{pattern: "secrets.**", strategy: MergeKeep}},
+	}
+
+	dst := map[string]any{"secrets": map[string]any{"token": "file-token", "keys": []any{"a"}}}
+	src := map[string]any{"secrets": map[string]any{
+		"token": nil,
+		"keys":  map[string]any{"$append": []any{"b"}},
+	}}
+
+	if err := merger.merge("", src, dst); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+
+	expected := map[string]any{"secrets": map[string]any{"token": "file-token", "keys": []any{"a"}}}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, dst)
+	}
+}
+
+type mergeStrategyConfig struct {
+	Features map[string]map[string]any `koanf:"features"`
+	CORS     struct {
+		Origins []string `koanf:"origins"`
+	} `koanf:"cors"`
+	TLS struct {
+		Cert string `koanf:"cert"`
+		Key  string `koanf:"key"`
+	} `koanf:"tls"`
+	Secrets struct {
+		Token string `koanf:"token"`
+	} `koanf:"secrets"`
+}
+
+func (c *mergeStrategyConfig) Validate() error { return nil }
+
+func TestContainerMergeStrategiesAcrossProviders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{
+		"features": {"search": {"enabled": true}},
+		"cors": {"origins": ["https://app.example.com"]},
+		"tls": {"cert": "prod.pem"},
+		"secrets": {"token": "file-token"}
+	}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	t.Setenv("MSTRAT_SECRETS__TOKEN", "env-token")
+	t.Setenv("MSTRAT_CORS__ORIGINS", "https://env.example.com")
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringSlice("cors.origins", nil, "usage")
+	if err := fs.Parse([]string{"--cors.origins=https://flag.example.com"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	cfg := &mergeStrategyConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithMergeStrategy("cors.origins", MergeAppend).
+		WithMergeStrategy("tls", MergeReplace).
+		WithMergeStrategy("secrets.*", MergeKeep).
+		WithProvider(
+			DefaultValuesProvider[*mergeStrategyConfig](map[string]any{
+				"features": map[string]any{"search": map[string]any{"limit": 10}},
+				"cors":     map[string]any{"origins": []any{"http://localhost"}},
+				"tls":      map[string]any{"cert": "dev.pem", "key": "dev.key"},
+			}),
+			FileProvider[*mergeStrategyConfig](file),
+			EnvProvider[*mergeStrategyConfig]("MSTRAT_", "__"),
+			FlagsProvider[*mergeStrategyConfig](fs),
+		)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	expectedOrigins := []string{
+		"http://localhost",
+		"https://app.example.com",
+		"https://env.example.com",
+		"https://flag.example.com",
+	}
+	if !reflect.DeepEqual(cfg.CORS.Origins, expectedOrigins) {
+		t.Fatalf("expected origins %v, got %v", expectedOrigins, cfg.CORS.Origins)
+	}
+	if cfg.TLS.Cert != "prod.pem" || cfg.TLS.Key != "" {
+		t.Fatalf("expected tls to be replaced, got %+v", cfg.TLS)
+	}
+	if cfg.Secrets.Token != "file-token" {
+		t.Fatalf("expected secrets.token to keep file value, got %q", cfg.Secrets.Token)
+	}
+	search := cfg.Features["search"]
+	if search["enabled"] != true || search["limit"] != 10 {
+		t.Fatalf("expected features to deep merge, got %v", cfg.Features)
+	}
+}
+
+func TestContainerMergeKeepComparesProviderTrust(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"secrets": {"token": "file-token"}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("MTRUST_SECRETS__TOKEN", "env-token")
+
+	load := func(t *testing.T, configure func(*Container[*mergeStrategyConfig]) *Container[*mergeStrategyConfig], providers ...ProviderBuilder[*mergeStrategyConfig]) string {
+		t.Helper()
+		cfg := &mergeStrategyConfig{}
+		container := New(cfg).
+			WithConfigPath("").
+			WithMergeStrategy("secrets.**", MergeKeep).
+			WithProvider(providers...)
+		if configure != nil {
+			container = configure(container)
+		}
+		if err := container.Load(context.Background()); err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		return cfg.Secrets.Token
+	}
+
+	defaults := DefaultValuesProvider[*mergeStrategyConfig](map[string]any{
+		"secrets": map[string]any{"token": ""},
+	})
+	fileProvider := FileProvider[*mergeStrategyConfig](file)
+	envProvider := EnvProvider[*mergeStrategyConfig]("MTRUST_", "__")
+
+	if got := load(t, nil, defaults, fileProvider, envProvider); got != "file-token" {
+		t.Fatalf("expected file value over defaults and env, got %q", got)
+	}
+	if got := load(t, nil, defaults, envProvider); got != "env-token" {
+		t.Fatalf("expected env value over defaults, got %q", got)
+	}
+
+	trustEnv := func(c *Container[*mergeStrategyConfig]) *Container[*mergeStrategyConfig] {
+		return c.WithProviderTrust(ProviderTypeEnv, 30)
+	}
+	if got := load(t, trustEnv, defaults, fileProvider, envProvider); got != "env-token" {
+		t.Fatalf("expected trusted env value, got %q", got)
+	}
+}
+
+func TestContainerMergeStrategiesKeepStrictMerge(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.Int("port", 0, "usage")
+	if err := fs.Parse([]string{"--port=9090"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	newContainer := func() *Container[*strictPortConfig] {
+		return New(&strictPortConfig{}).
+			WithConfigPath("").
+			WithMergeStrategy("tags", MergeAppend).
+			WithProvider(
+				DefaultValuesProvider[*strictPortConfig](map[string]any{"port": "8080"}),
+				FlagsProvider[*strictPortConfig](fs),
+			)
+	}
+
+	if err := newContainer().Load(context.Background()); err == nil || !strings.Contains(err.Error(), "incorrect types at key port") {
+		t.Fatalf("expected strict merge type error, got %v", err)
+	}
+
+	container := newContainer()
+	container.strictMerge = false
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("expected non strict merge to load, got %v", err)
+	}
+}
+
+type strictPortConfig struct {
+	Port int      `koanf:"port"`
+	Tags []string `koanf:"tags"`
+}
+
+func (c *strictPortConfig) Validate() error { return nil }
@@ -0,0 +1,338 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/goliatone/go-config/cfgx"
+)
+
+func init() {
+	cfgx.RegisterTypeHook("config.Optional", isOptionalTarget, decodeOptional)
+}
+
+// Optional carries three states for any value type: unset, or explicitly set
+// to a value, including the zero value. It answers "did the user set
+// timeout: 0?" the same way OptionalBool does for booleans.
+// The zero value is unset.
+type Optional[T any] struct {
+	// State is exported only so deep copies, such as the ones koanf makes
+	// with copystructure, keep it. Use the methods to read and change it.
+	State optionalState[T] `copy:"shallow" koanf:"-" json:"-" mapstructure:"-"`
+}
+
+type optionalState[T any] struct {
+	set   bool
+	value T
+}
+
+// optionalValue is implemented by every *Optional[T] so merge and decode can
+// handle all instantiations.
+type optionalValue interface {
+	IsSet() bool
+	decodeData(data any) error
+}
+
+var optionalValueType = reflect.TypeOf((*optionalValue)(nil)).Elem()
+
+// NewOptional constructs an Optional that is explicitly set.
+func NewOptional[T any](value T) *Optional[T] {
+	o := &Optional[T]{}
+	o.Set(value)
+	return o
+}
+
+// NewOptionalUnset constructs an Optional that starts unset.
+func NewOptionalUnset[T any]() *Optional[T] {
+	return &Optional[T]{}
+}
+
+// Set updates the value and marks the option as present.
+func (o *Optional[T]) Set(v T) {
+	if o == nil {
+		return
+	}
+	o.State.value = v
+	o.State.set = true
+}
+
+// Unset clears the value so callers can detect omission again.
+func (o *Optional[T]) Unset() {
+	if o == nil {
+		return
+	}
+	var zero T
+	o.State.value = zero
+	o.State.set = false
+}
+
+// IsSet reports whether the field was supplied by any provider.
+func (o *Optional[T]) IsSet() bool {
+	if o == nil {
+		return false
+	}
+	return o.State.set
+}
+
+// Value returns the stored value. When unset it returns the zero value.
+func (o *Optional[T]) Value() T {
+	if o == nil {
+		var zero T
+		return zero
+	}
+	return o.State.value
+}
+
+// ValueOr returns the stored value when set, otherwise the supplied default.
+func (o *Optional[T]) ValueOr(def T) T {
+	if o == nil || !o.State.set {
+		return def
+	}
+	return o.State.value
+}
+
+// ValueOK returns the stored value along with the IsSet flag.
+func (o *Optional[T]) ValueOK() (T, bool) {
+	if o == nil {
+		var zero T
+		return zero, false
+	}
+	return o.State.value, o.State.set
+}
+
+// String returns a human readable representation for debugging.
+func (o *Optional[T]) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	if !o.State.set {
+		return "<unset>"
+	}
+	return fmt.Sprint(o.State.value)
+}
+
+// MarshalJSON encodes unset values as null.
+func (o *Optional[T]) MarshalJSON() ([]byte, error) {
+	if o == nil || !o.State.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.State.value)
+}
+
+// UnmarshalJSON accepts null, a JSON encoded T, or a string parsed like
+// UnmarshalText (e.g. "5s" for a time.Duration).
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if o == nil {
+		return fmt.Errorf("optional: nil receiver")
+	}
+
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" || strings.EqualFold(trimmed, "null") {
+		o.Unset()
+		return nil
+	}
+
+	var direct T
+	if err := json.Unmarshal(data, &direct); err == nil {
+		o.Set(direct)
+		return nil
+	}
+
+	var asString string
+	if err := json.Unmarshal(data, &asString); err != nil {
+		return fmt.Errorf("optional: unsupported json payload %q for %T", data, direct)
+	}
+	return o.UnmarshalText([]byte(asString))
+}
+
+// MarshalText encodes unset values as an empty string.
+func (o *Optional[T]) MarshalText() ([]byte, error) {
+	if o == nil || !o.State.set {
+		return []byte(""), nil
+	}
+	if marshaler, ok := any(o.State.value).(encoding.TextMarshaler); ok {
+		return marshaler.MarshalText()
+	}
+	return []byte(fmt.Sprint(o.State.value)), nil
+}
+
+// UnmarshalText parses text into T. Empty text and "null" unset the value.
+func (o *Optional[T]) UnmarshalText(text []byte) error {
+	if o == nil {
+		return fmt.Errorf("optional: nil receiver")
+	}
+	trimmed := strings.TrimSpace(string(text))
+	if trimmed == "" || strings.EqualFold(trimmed, "null") {
+		o.Unset()
+		return nil
+	}
+	value, err := parseOptionalText[T](trimmed)
+	if err != nil {
+		return err
+	}
+	o.Set(value)
+	return nil
+}
+
+// decodeData populates the option from raw provider data: nil, another
+// Optional, a T, a string, a {"set": ..., "value": ...} map or any value
+// convertible to T.
+func (o *Optional[T]) decodeData(data any) error {
+	switch v := data.(type) {
+	case nil:
+		o.Unset()
+		return nil
+	case T:
+		o.Set(v)
+		return nil
+	case *Optional[T]:
+		if value, ok := v.ValueOK(); ok {
+			o.Set(value)
+		} else {
+			o.Unset()
+		}
+		return nil
+	case Optional[T]:
+		if value, ok := v.ValueOK(); ok {
+			o.Set(value)
+		} else {
+			o.Unset()
+		}
+		return nil
+	case string:
+		return o.UnmarshalText([]byte(v))
+	case map[string]any:
+		if set, ok := v["set"].(bool); ok && !set {
+			o.Unset()
+			return nil
+		}
+		if raw, ok := v["value"]; ok {
+			return o.decodeData(raw)
+		}
+		if len(v) == 0 {
+			o.Unset()
+			return nil
+		}
+	}
+
+	value, err := convertOptionalValue[T](data)
+	if err != nil {
+		return err
+	}
+	o.Set(value)
+	return nil
+}
+
+// parseOptionalText parses text for common value types, falling back to
+// encoding.TextUnmarshaler and JSON.
+func parseOptionalText[T any](text string) (T, error) {
+	var out T
+	var err error
+	switch p := any(&out).(type) {
+	case *string:
+		*p = text
+	case *bool:
+		*p, err = parseBoolString(text)
+	case *time.Duration:
+		*p, err = time.ParseDuration(text)
+	case encoding.TextUnmarshaler:
+		err = p.UnmarshalText([]byte(text))
+	default:
+		target := reflect.ValueOf(&out).Elem()
+		if target.Kind() == reflect.String {
+			target.SetString(text)
+			break
+		}
+		err = json.Unmarshal([]byte(text), &out)
+	}
+	if err != nil {
+		return out, fmt.Errorf("optional: cannot parse %q as %T: %w", text, out, err)
+	}
+	return out, nil
+}
+
+// convertOptionalValue converts numbers between kinds, e.g. the float64 read
+// from JSON into an int, and falls back to a JSON round trip.
+func convertOptionalValue[T any](data any) (T, error) {
+	var out T
+	target := reflect.ValueOf(&out).Elem()
+	source := reflect.ValueOf(data)
+
+	if isNumberKind(source.Kind()) && isNumberKind(target.Kind()) {
+		if isFloatKind(source.Kind()) && !isFloatKind(target.Kind()) {
+			if f := source.Float(); f != math.Trunc(f) {
+				return out, fmt.Errorf("optional: cannot convert %v to %T without losing precision", data, out)
+			}
+		}
+		target.Set(source.Convert(target.Type()))
+		return out, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err == nil {
+		err = json.Unmarshal(encoded, &out)
+	}
+	if err != nil {
+		return out, fmt.Errorf("optional: cannot decode %T into %T: %w", data, out, err)
+	}
+	return out, nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// isOptionalTarget reports whether t is an Optional[T] or a pointer to one.
+func isOptionalTarget(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(optionalValueType)
+}
+
+// decodeOptional is the cfgx type hook for every Optional[T] instantiation.
+func decodeOptional(data any, to reflect.Type) (any, error) {
+	base := to
+	if base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+	ptr := reflect.New(base)
+	if err := ptr.Interface().(optionalValue).decodeData(data); err != nil {
+		return nil, err
+	}
+	if to.Kind() == reflect.Ptr {
+		return ptr.Interface(), nil
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+// asOptionalValue returns value as an optionalValue when it is an Optional[T]
+// or a pointer to one.
+func asOptionalValue(value any) (optionalValue, bool) {
+	if ov, ok := value.(optionalValue); ok {
+		return ov, true
+	}
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || rv.Kind() != reflect.Struct || !reflect.PointerTo(rv.Type()).Implements(optionalValueType) {
+		return nil, false
+	}
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	return ptr.Interface().(optionalValue), true
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goliatone/go-config/cfgx"
+	"github.com/knadh/koanf/v2"
+)
+
+func TestOptional_BasicOperations(t *testing.T) {
+	var timeout Optional[time.Duration]
+	if timeout.IsSet() {
+		t.Fatal("zero value should be unset")
+	}
+	if got := timeout.ValueOr(time.Second); got != time.Second {
+		t.Fatalf("expected default from ValueOr, got %v", got)
+	}
+
+	timeout.Set(0)
+	if !timeout.IsSet() {
+		t.Fatal("zero value should be set after Set(0)")
+	}
+	if got := timeout.ValueOr(time.Second); got != 0 {
+		t.Fatalf("expected explicit zero from ValueOr, got %v", got)
+	}
+
+	timeout.Unset()
+	if value, ok := timeout.ValueOK(); ok || value != 0 {
+		t.Fatalf("expected unset after Unset, got (%v, %v)", value, ok)
+	}
+
+	var nilOptional *Optional[int]
+	if nilOptional.IsSet() || nilOptional.ValueOr(7) != 7 || nilOptional.String() != "<nil>" {
+		t.Fatal("nil receiver should behave as unset")
+	}
+}
+
+func TestOptional_JSON(t *testing.T) {
+	type payload struct {
+		Port    *Optional[int]           `json:"port"`
+		Name    *Optional[string]        `json:"name"`
+		Timeout *Optional[time.Duration] `json:"timeout"`
+	}
+
+	var decoded payload
+	if err := json.Unmarshal([]byte(`{"port": 0, "name": null, "timeout": "5s"}`), &decoded); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if !decoded.Port.IsSet() || decoded.Port.Value() != 0 {
+		t.Fatalf("expected port set to 0, got %v", decoded.Port)
+	}
+	if decoded.Name.IsSet() {
+		t.Fatalf("expected null name to be unset, got %v", decoded.Name)
+	}
+	if decoded.Timeout.Value() != 5*time.Second {
+		t.Fatalf("expected timeout 5s, got %v", decoded.Timeout)
+	}
+
+	encoded, err := json.Marshal(payload{
+		Port:    NewOptional(8080),
+		Name:    NewOptionalUnset[string](),
+		Timeout: NewOptional(time.Second),
+	})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if string(encoded) != `{"port":8080,"name":null,"timeout":1000000000}` {
+		t.Fatalf("unexpected json %s", encoded)
+	}
+}
+
+func TestOptional_Text(t *testing.T) {
+	timeout := NewOptional(90 * time.Second)
+	text, err := timeout.MarshalText()
+	if err != nil || string(text) != "1m30s" {
+		t.Fatalf("unexpected text %q (%v)", text, err)
+	}
+
+	var parsed Optional[time.Duration]
+	if err := parsed.UnmarshalText(text); err != nil || parsed.Value() != 90*time.Second {
+		t.Fatalf("round trip failed: %v (%v)", parsed.Value(), err)
+	}
+
+	var port Optional[int]
+	if err := port.UnmarshalText([]byte("abc")); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if err := port.UnmarshalText([]byte(" ")); err != nil || port.IsSet() {
+		t.Fatalf("expected blank text to unset, got %v (%v)", port.String(), err)
+	}
+}
+
+func TestOptional_CfgxDecode(t *testing.T) {
+	type Config struct {
+		Port    Optional[int]            `mapstructure:"port"`
+		Retries *Optional[uint8]         `mapstructure:"retries"`
+		Timeout *Optional[time.Duration] `mapstructure:"timeout"`
+		Name    Optional[string]         `mapstructure:"name"`
+		Ratio   Optional[float64]        `mapstructure:"ratio"`
+	}
+
+	cfg, err := cfgx.Build[Config](map[string]any{
+		"port":    float64(0),
+		"retries": map[string]any{"value": 3},
+		"timeout": "250ms",
+		"ratio":   1,
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if !cfg.Port.IsSet() || cfg.Port.Value() != 0 {
+		t.Fatalf("expected port explicitly 0, got %v", cfg.Port.String())
+	}
+	if cfg.Retries.Value() != 3 {
+		t.Fatalf("expected retries 3, got %v", cfg.Retries)
+	}
+	if cfg.Timeout.Value() != 250*time.Millisecond {
+		t.Fatalf("expected timeout 250ms, got %v", cfg.Timeout)
+	}
+	if cfg.Name.IsSet() {
+		t.Fatalf("expected name unset, got %v", cfg.Name.String())
+	}
+	if cfg.Ratio.Value() != 1 {
+		t.Fatalf("expected ratio 1, got %v", cfg.Ratio.String())
+	}
+
+	if _, err := cfgx.Build[Config](map[string]any{"port": 1.5}); err == nil {
+		t.Fatal("expected lossy conversion error")
+	}
+}
+
+type optionalConfig struct {
+	Server struct {
+		Port    Optional[int]            `koanf:"port"`
+		Timeout *Optional[time.Duration] `koanf:"timeout"`
+		Name    Optional[string]         `koanf:"name"`
+	} `koanf:"server"`
+}
+
+func (c *optionalConfig) Validate() error { return nil }
+
+func TestContainerOptionalAcrossProviders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"server": {"timeout": 0}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("OPTCFG_SERVER__PORT", "9090")
+
+	cfg := &optionalConfig{}
+	cfg.Server.Name.Set("default")
+
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(
+			EnvProvider[*optionalConfig]("OPTCFG_", "__"),
+			FileProvider[*optionalConfig](file),
+		)
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if cfg.Server.Port.Value() != 9090 {
+		t.Fatalf("expected port from env, got %v", cfg.Server.Port.String())
+	}
+	if !cfg.Server.Timeout.IsSet() || cfg.Server.Timeout.Value() != 0 {
+		t.Fatalf("expected explicit zero timeout, got %v", cfg.Server.Timeout)
+	}
+	if cfg.Server.Name.Value() != "default" {
+		t.Fatalf("expected struct default name to survive, got %v", cfg.Server.Name.String())
+	}
+}
+
+func TestMergeWithBooleanPrecedence_Optional(t *testing.T) {
+	dst := map[string]any{"port": NewOptional(8080), "name": "api"}
+	src := map[string]any{"port": NewOptionalUnset[int](), "name": Optional[string]{}}
+
+	if err := MergeWithBooleanPrecedence(src, dst); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if dst["port"].(*Optional[int]).Value() != 8080 || dst["name"] != "api" {
+		t.Fatalf("unset optionals should not overwrite, got %#v", dst)
+	}
+
+	if err := MergeWithBooleanPrecedence(map[string]any{"port": NewOptional(0)}, dst); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if port := dst["port"].(*Optional[int]); !port.IsSet() || port.Value() != 0 {
+		t.Fatalf("set optional should overwrite, got %v", port)
+	}
+}
+
+func TestOptionalSurvivesKoanfCopiesWhileInstantiating(t *testing.T) {
+	k := koanf.New(".")
+	if err := k.Set("port", NewOptional(8080)); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewOptional(int8(1))
+		NewOptional(uint32(1))
+		NewOptional([]string{"a"})
+	}()
+
+	for i := 0; i < 100; i++ {
+		port, ok := k.Raw()["port"].(*Optional[int])
+		if !ok || !port.IsSet() || port.Value() != 8080 {
+			t.Fatalf("expected copied optional to stay set, got %#v", k.Raw()["port"])
+		}
+	}
+	<-done
+
+	var count Optional[int]
+	count.Set(3)
+	if err := k.Set("count", count); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if copied := k.Raw()["count"].(Optional[int]); !copied.IsSet() || copied.Value() != 3 {
+		t.Fatalf("expected copied optional value to stay set, got %v", copied.String())
+	}
+}
@@ -33,9 +33,27 @@ type Provider interface {
 type Loader struct {
 	order        int
 	providerType ProviderType
+	name         string
 	load         func(context.Context, *koanf.Koanf) error
 }
 
+// Name identifies the loader in diagnostics, e.g. "file:config/app.json".
+// It defaults to the provider type.
+func (l *Loader) Name() string {
+	if l.name != "" {
+		return l.name
+	}
+	return string(l.providerType)
+}
+
+// providerName returns the provider Name when available, else its type.
+func providerName(p Provider) string {
+	if named, ok := p.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return string(p.Type())
+}
+
 func (l *Loader) Priority() int {
 	return l.order
 }
@@ -180,7 +198,7 @@ func DefaultValuesProvider[C Validable](def map[string]any, order ...int) Provid
 			providerType: ProviderTypeDefault,
 			order:        getOrder(PriorityDefaults, order...),
 			load: func(ctx context.Context, k *koanf.Koanf) error {
-				if err := k.Load(kprovider, nil); err != nil {
+				if err := k.Load(kprovider, nil, c.mergeOptions(ProviderTypeDefault)...); err != nil {
 					return errors.Wrap(err, errors.CategoryOperation, "failed to load default values").
 						WithTextCode("DEFAULT_VALUES_LOAD_FAILED").
 						WithMetadata(map[string]any{
@@ -204,11 +222,12 @@ func FileProvider[C Validable](filepath string, orders ...int) ProviderBuilder[C
 
 		p := &Loader{
 			providerType: ProviderTypeLocalFile,
+			name:         string(ProviderTypeLocalFile) + ":" + filepath,
 			order:        getOrder(PriorityConfig, orders...),
 			load: func(ctx context.Context, k *koanf.Koanf) error {
 				c.logger.Debug("file provider", "filepath", filepath)
-				merger := koanf.WithMergeFunc(MergeWithBooleanPrecedence)
-				if err := k.Load(kprovider, parser, merger); err != nil {
+				mergeOpts := c.mergeOptions(ProviderTypeLocalFile)
+				if err := k.Load(kprovider, parser, mergeOpts...); err != nil {
 					return errors.Wrap(err, errors.CategoryOperation, "failed to load configuration from file").
 						WithTextCode("FILE_LOAD_FAILED").
 						WithMetadata(map[string]any{
@@ -229,19 +248,21 @@ func EnvProvider[C Validable](prefix, delim string, order ...int) ProviderBuilde
 	return func(c *Container[C]) (Provider, error) {
 		prv := &Loader{
 			providerType: ProviderTypeEnv,
+			name:         string(ProviderTypeEnv) + ":" + prefix,
 			order:        getOrder(PriorityEnv, order...),
 			load: func(ctx context.Context, k *koanf.Koanf) error {
 				parser := json.Parser()
-				merger := koanf.WithMergeFunc(MergeWithBooleanPrecedence)
+				mergeOpts := c.mergeOptions(ProviderTypeEnv)
 				kprov := env.Provider(prefix, ".", func(s string) string {
 					return strings.Replace(strings.ToLower(
 						strings.TrimPrefix(s, prefix)), delim, ".", -1)
 				})
 
 				kprov.SetLogger(c.logger)
+				kprov.SetSensitiveKeys(c.isSensitiveKey)
 
 				c.logger.Debug("env provider")
-				if err := k.Load(kprov, parser, merger); err != nil {
+				if err := k.Load(kprov, parser, mergeOpts...); err != nil {
 					return errors.Wrap(err, errors.CategoryOperation, "failed to load environment variables").
 						WithTextCode("ENV_LOAD_FAILED").
 						WithMetadata(map[string]any{
@@ -270,7 +291,7 @@ func FlagsProvider[C Validable](flagset *pflag.FlagSet, order ...int) ProviderBu
 			load: func(ctx context.Context, k *koanf.Koanf) error {
 				c.logger.Debug("flags provider")
 				prv := posflag.Provider(flagset, DefaultDelimiter, k)
-				if err := k.Load(prv, nil); err != nil {
+				if err := k.Load(prv, nil, c.mergeOptions(ProviderTypeFlag)...); err != nil {
 					return errors.Wrap(err, errors.CategoryOperation, "failed to load configuration from posix flags").
 						WithTextCode("FLAGS_LOAD_FAILED").
 						WithMetadata(map[string]any{
@@ -301,8 +322,8 @@ func StructProvider[C Validable](v Validable, order ...int) ProviderBuilder[C] {
 			order:        getOrder(PriorityStruct, order...),
 			load: func(ctx context.Context, k *koanf.Koanf) error {
 				c.logger.Debug("struct provider")
-				merger := koanf.WithMergeFunc(MergeWithBooleanPrecedence)
-				if err := k.Load(kprv, nil, merger); err != nil {
+				mergeOpts := c.mergeOptions(ProviderTypeStruct)
+				if err := k.Load(kprv, nil, mergeOpts...); err != nil {
 					return errors.Wrap(err,
 						errors.CategoryOperation,
 						"failed to load configuration from struct",
@@ -3,6 +3,9 @@ package config
 import (
 	"context"
 	"testing"
+	"testing/fstest"
+
+	"github.com/goliatone/go-config/koanf/solvers"
 )
 
 func TestContainerSolvers(t *testing.T) {
@@ -34,3 +37,32 @@ func TestContainerSolvers(t *testing.T) {
 		t.Errorf("expected Server.Env to equal Env, got %q", app.Server.Env)
 	}
 }
+
+func TestContainerSolvers_FileNamespaceUsesURISolverFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"version.txt": &fstest.MapFile{Data: []byte("1.2.3\n")},
+	}
+	cfg := &templateConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*templateConfig](map[string]any{
+			"app": map[string]any{
+				"version": "${file:version.txt}",
+				"banner":  "@file://version.txt",
+			},
+		})).
+		WithSolvers(
+			solvers.NewVariablesSolver("${", "}"),
+			solvers.NewURISolverWithFS("@", "://", fsys),
+		)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.App.Version != "1.2.3" {
+		t.Fatalf("expected ${file:} to read from the URI solver fs, got %q", cfg.App.Version)
+	}
+	if cfg.App.Banner != "1.2.3" {
+		t.Fatalf("expected @file:// to read from the URI solver fs, got %q", cfg.App.Banner)
+	}
+}
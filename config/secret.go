@@ -0,0 +1,304 @@
+package config
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goliatone/go-config/cfgx"
+	"github.com/goliatone/go-errors"
+	masker "github.com/goliatone/go-masker"
+)
+
+func init() {
+	cfgx.RegisterTypeHook("config.Secret", isSecretTarget, decodeSecret)
+}
+
+// RedactedValue replaces secret values in output, logs and error metadata.
+const RedactedValue = masker.RedactedValue
+
+// Secret holds a value that redacts itself in String, fmt verbs, MarshalJSON
+// and MarshalText. Only Reveal returns the value.
+type Secret[T any] struct {
+	// State is exported only so deep copies, such as the ones koanf makes
+	// with copystructure, keep it. The value stays unreadable outside the
+	// package.
+	State secretState[T] `copy:"shallow" koanf:"-" json:"-" mapstructure:"-"`
+}
+
+type secretState[T any] struct {
+	value T
+}
+
+// secretValue is implemented by every Secret[T].
+type secretValue interface {
+	revealAny() any
+}
+
+// secretTarget is implemented by every *Secret[T] so decode can handle all
+// instantiations.
+type secretTarget interface {
+	secretValue
+	decodeData(data any) error
+}
+
+var (
+	secretValueType  = reflect.TypeOf((*secretValue)(nil)).Elem()
+	secretTargetType = reflect.TypeOf((*secretTarget)(nil)).Elem()
+)
+
+// NewSecret wraps value in a Secret.
+func NewSecret[T any](value T) Secret[T] {
+	return Secret[T]{State: secretState[T]{value: value}}
+}
+
+// Reveal returns the wrapped value.
+func (s Secret[T]) Reveal() T {
+	return s.State.value
+}
+
+// IsZero reports whether the wrapped value is the zero value of T.
+func (s Secret[T]) IsZero() bool {
+	return reflect.ValueOf(&s.State.value).Elem().IsZero()
+}
+
+func (s Secret[T]) revealAny() any {
+	return s.State.value
+}
+
+// String returns RedactedValue.
+func (s Secret[T]) String() string {
+	return RedactedValue
+}
+
+// GoString returns a redacted representation for %#v.
+func (s Secret[T]) GoString() string {
+	return fmt.Sprintf("config.Secret[%s]", RedactedValue)
+}
+
+// Format redacts the value for every fmt verb.
+func (s Secret[T]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		_, _ = f.Write([]byte(s.GoString()))
+		return
+	}
+	_, _ = f.Write([]byte(RedactedValue))
+}
+
+// MarshalJSON encodes RedactedValue as a JSON string.
+func (s Secret[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(RedactedValue)
+}
+
+// MarshalText returns RedactedValue.
+func (s Secret[T]) MarshalText() ([]byte, error) {
+	return []byte(RedactedValue), nil
+}
+
+// UnmarshalJSON accepts a JSON encoded T or a string parsed like
+// UnmarshalText.
+func (s *Secret[T]) UnmarshalJSON(data []byte) error {
+	if s == nil {
+		return fmt.Errorf("secret: nil receiver")
+	}
+	var direct T
+	if err := json.Unmarshal(data, &direct); err == nil {
+		s.set(direct)
+		return nil
+	}
+	var asString string
+	if err := json.Unmarshal(data, &asString); err != nil {
+		return fmt.Errorf("secret: unsupported json payload for %T", direct)
+	}
+	return s.UnmarshalText([]byte(asString))
+}
+
+// UnmarshalText parses text into T.
+func (s *Secret[T]) UnmarshalText(text []byte) error {
+	if s == nil {
+		return fmt.Errorf("secret: nil receiver")
+	}
+	value, err := parseOptionalText[T](string(text))
+	if err != nil {
+		// the parse error quotes the input, keep it out of the message
+		return fmt.Errorf("secret: cannot parse value as %T", value)
+	}
+	s.set(value)
+	return nil
+}
+
+func (s *Secret[T]) set(value T) {
+	s.State.value = value
+}
+
+// decodeData populates the secret from raw provider data: nil, another
+// Secret, a T, a string or any value convertible to T.
+func (s *Secret[T]) decodeData(data any) error {
+	switch v := data.(type) {
+	case nil:
+		var zero T
+		s.State.value = zero
+		return nil
+	case T:
+		s.set(v)
+		return nil
+	case Secret[T]:
+		s.set(v.State.value)
+		return nil
+	case *Secret[T]:
+		if v != nil {
+			s.set(v.State.value)
+		}
+		return nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			var zero T
+			s.State.value = zero
+			return nil
+		}
+		return s.UnmarshalText([]byte(v))
+	}
+
+	value, err := convertOptionalValue[T](data)
+	if err != nil {
+		return fmt.Errorf("secret: cannot decode %T into %T", data, value)
+	}
+	s.set(value)
+	return nil
+}
+
+// isSecretTarget reports whether t is a Secret[T] or a pointer to one.
+func isSecretTarget(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(secretTargetType)
+}
+
+// decodeSecret is the cfgx type hook for every Secret[T] instantiation.
+func decodeSecret(data any, to reflect.Type) (any, error) {
+	base := to
+	if base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+	ptr := reflect.New(base)
+	if err := ptr.Interface().(secretTarget).decodeData(data); err != nil {
+		return nil, err
+	}
+	if to.Kind() == reflect.Ptr {
+		return ptr.Interface(), nil
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+// RedactSecrets returns a copy of value where every Secret, and every map
+// entry whose dot separated key path is one of the sensitive key paths, is
+// replaced by RedactedValue. Maps and slices are walked recursively.
+func RedactSecrets(value any, sensitiveKeys ...string) any {
+	sensitive := make(map[string]struct{}, len(sensitiveKeys))
+	for _, key := range sensitiveKeys {
+		sensitive[key] = struct{}{}
+	}
+	return redactValue("", value, func(key string) bool {
+		_, ok := sensitive[key]
+		return ok
+	})
+}
+
+// redactValue redacts value, found at key path, and the entries below it.
+// List items share the key path of their list.
+func redactValue(path string, value any, isSensitive func(string) bool) any {
+	if _, ok := value.(secretValue); ok {
+		return RedactedValue
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			keyPath := joinKeyPath(path, key)
+			if isSensitive(keyPath) {
+				out[key] = RedactedValue
+				continue
+			}
+			out[key] = redactValue(keyPath, item, isSensitive)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactValue(path, item, isSensitive)
+		}
+		return out
+	case []map[string]any:
+		out := make([]map[string]any, len(v))
+		for i, item := range v {
+			out[i], _ = redactValue(path, item, isSensitive).(map[string]any)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// redactErrorMetadata redacts secrets in the metadata of every go-errors
+// error in the chain of err.
+func redactErrorMetadata(err error, isSensitive func(string) bool) error {
+	for current := err; current != nil; current = stderrors.Unwrap(current) {
+		cfgErr, ok := current.(*errors.Error)
+		if !ok || cfgErr.Metadata == nil {
+			continue
+		}
+		cfgErr.Metadata, _ = redactValue("", cfgErr.Metadata, isSensitive).(map[string]any)
+	}
+	return err
+}
+
+// secretKeyPaths lists the key paths of Secret fields in t, using tag for the
+// key names like the decoder does.
+func secretKeyPaths(t reflect.Type, tag string) []string {
+	var paths []string
+	collectSecretKeyPaths(t, tag, "", map[reflect.Type]bool{}, &paths)
+	return paths
+}
+
+func collectSecretKeyPaths(t reflect.Type, tag, prefix string, visiting map[reflect.Type]bool, paths *[]string) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if name == "" && field.Anonymous && fieldType.Kind() == reflect.Struct {
+			collectSecretKeyPaths(fieldType, tag, prefix, visiting, paths)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		path := joinKeyPath(prefix, name)
+		if fieldType.Implements(secretValueType) {
+			*paths = append(*paths, path)
+			continue
+		}
+		collectSecretKeyPaths(fieldType, tag, path, visiting, paths)
+	}
+}
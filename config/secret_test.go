@@ -0,0 +1,262 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goliatone/go-config/cfgx"
+	"github.com/goliatone/go-errors"
+	"github.com/knadh/koanf/v2"
+)
+
+func TestSecret_Redacts(t *testing.T) {
+	secret := NewSecret("hunter2")
+	if secret.Reveal() != "hunter2" {
+		t.Fatalf("expected Reveal to return the value, got %q", secret.Reveal())
+	}
+
+	outputs := []string{
+		secret.String(),
+		fmt.Sprint(secret),
+		fmt.Sprintf("%v %+v %s %q %x %d", secret, secret, secret, secret, secret, secret),
+		fmt.Sprintf("%#v", secret),
+		fmt.Sprintf("%v", &secret),
+		fmt.Sprintf("%+v", struct{ Password Secret[string] }{secret}),
+	}
+	encoded, err := json.Marshal(map[string]any{"password": secret, "pointer": &secret})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	text, err := secret.MarshalText()
+	if err != nil {
+		t.Fatalf("marshal text failed: %v", err)
+	}
+	outputs = append(outputs, string(encoded), string(text))
+
+	for _, out := range outputs {
+		if strings.Contains(out, "hunter2") {
+			t.Fatalf("output leaked secret: %s", out)
+		}
+		if !strings.Contains(out, RedactedValue) {
+			t.Fatalf("output missing redaction marker: %s", out)
+		}
+	}
+}
+
+func TestSecret_Unmarshal(t *testing.T) {
+	var payload struct {
+		Token Secret[string] `json:"token"`
+		Pin   Secret[int]    `json:"pin"`
+	}
+	if err := json.Unmarshal([]byte(`{"token": "abc", "pin": "1234"}`), &payload); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if payload.Token.Reveal() != "abc" || payload.Pin.Reveal() != 1234 {
+		t.Fatalf("unexpected values %q %d", payload.Token.Reveal(), payload.Pin.Reveal())
+	}
+
+	var pin Secret[int]
+	err := pin.UnmarshalText([]byte("pin-sentinel"))
+	if err == nil || strings.Contains(err.Error(), "pin-sentinel") {
+		t.Fatalf("expected parse error without the value, got %v", err)
+	}
+}
+
+func TestSecret_CfgxDecode(t *testing.T) {
+	type Config struct {
+		Password Secret[string]   `mapstructure:"password"`
+		Port     *Secret[int]     `mapstructure:"port"`
+		Default  Secret[string]   `mapstructure:"default"`
+		Keys     []Secret[string] `mapstructure:"keys"`
+	}
+
+	cfg, err := cfgx.Build[Config](map[string]any{
+		"password": "hunter2",
+		"port":     float64(5432),
+		"keys":     []any{"a", "b"},
+	}, cfgx.WithDefaults(Config{Default: NewSecret("fallback")}))
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if cfg.Password.Reveal() != "hunter2" || cfg.Port.Reveal() != 5432 {
+		t.Fatalf("unexpected values %q %d", cfg.Password.Reveal(), cfg.Port.Reveal())
+	}
+	if cfg.Default.Reveal() != "fallback" {
+		t.Fatalf("expected default secret to survive cloning, got %q", cfg.Default.Reveal())
+	}
+	if len(cfg.Keys) != 2 || cfg.Keys[1].Reveal() != "b" {
+		t.Fatalf("unexpected keys %v", cfg.Keys)
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	got := RedactSecrets(map[string]any{
+		"token":       NewSecret("a"),
+		"db.password": "b",
+		"nested":      []any{map[string]any{"key": NewSecret(1)}, "keep"},
+	}, "db.password")
+
+	expected := map[string]any{
+		"token":       RedactedValue,
+		"db.password": RedactedValue,
+		"nested":      []any{map[string]any{"key": RedactedValue}, "keep"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, got)
+	}
+}
+
+type secretConfig struct {
+	Database struct {
+		Host     string         `koanf:"host"`
+		Password Secret[string] `koanf:"password"`
+	} `koanf:"database"`
+	API struct {
+		Key *Secret[string] `koanf:"key"`
+	} `koanf:"api"`
+}
+
+func (c *secretConfig) Validate() error { return nil }
+
+type debugLogger struct {
+	entries []string
+}
+
+func (l *debugLogger) Debug(msg string, args ...any) {
+	l.entries = append(l.entries, fmt.Sprint(append([]any{msg}, args...)...))
+}
+func (l *debugLogger) Info(msg string, args ...any)  {}
+func (l *debugLogger) Error(msg string, args ...any) {}
+
+func TestContainerSecrets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"database": {"host": "db", "password": "file-sentinel"}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("SECRETCFG_API__KEY", "env-sentinel")
+
+	lgr := &debugLogger{}
+	cfg := &secretConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithLogger(lgr).
+		WithProvider(
+			EnvProvider[*secretConfig]("SECRETCFG_", "__"),
+			FileProvider[*secretConfig](file),
+		)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.Database.Password.Reveal() != "file-sentinel" || cfg.API.Key.Reveal() != "env-sentinel" {
+		t.Fatalf("unexpected secrets %q %q", cfg.Database.Password.Reveal(), cfg.API.Key.Reveal())
+	}
+
+	if keys := container.SensitiveKeys(); !reflect.DeepEqual(keys, []string{"api.key", "database.password"}) {
+		t.Fatalf("unexpected sensitive keys %v", keys)
+	}
+	logged := strings.Join(lgr.entries, "\n")
+	if strings.Contains(logged, "env-sentinel") || !strings.Contains(logged, RedactedValue) {
+		t.Fatalf("env debug log not redacted: %s", logged)
+	}
+	if exported, _ := json.Marshal(cfg); strings.Contains(string(exported), "sentinel") {
+		t.Fatalf("export leaked secret: %s", exported)
+	}
+}
+
+func TestContainerRedactsErrorMetadata(t *testing.T) {
+	failing := func(c *Container[*secretConfig]) (Provider, error) {
+		return &Loader{
+			providerType: ProviderTypeStruct,
+			load: func(ctx context.Context, k *koanf.Koanf) error {
+				return errors.New("vault unavailable", errors.CategoryOperation).
+					WithMetadata(map[string]any{
+						"database.password": "meta-sentinel",
+						"token":             NewSecret("secret-sentinel"),
+						"custom.api_token":  "custom-sentinel",
+						"endpoint":          "https://vault",
+					})
+			},
+		}, nil
+	}
+
+	container := New(&secretConfig{}).
+		WithConfigPath("").
+		WithSensitiveKeys("custom.*").
+		WithProvider(failing)
+
+	err := container.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected load failure")
+	}
+
+	var cfgErr *errors.Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("expected go-errors wrapper, got %v", err)
+	}
+	rendered := fmt.Sprint(cfgErr.Metadata)
+	for current := error(cfgErr); current != nil; current = errors.Unwrap(current) {
+		if inner, ok := current.(*errors.Error); ok {
+			rendered += fmt.Sprint(inner.Metadata)
+		}
+	}
+	for _, leaked := range []string{"meta-sentinel", "secret-sentinel", "custom-sentinel"} {
+		if strings.Contains(rendered, leaked) {
+			t.Fatalf("metadata leaked %q: %s", leaked, rendered)
+		}
+	}
+	if cfgErr.Metadata["endpoint"] != "https://vault" {
+		t.Fatalf("expected non sensitive metadata to be kept, got %v", cfgErr.Metadata)
+	}
+}
+
+func TestSecretSurvivesKoanfCopiesWhileInstantiating(t *testing.T) {
+	k := koanf.New(".")
+	if err := k.Set("token", NewSecret("s3cr3t")); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewSecret(int8(1))
+		NewSecret(uint32(1))
+		NewSecret([]byte("key"))
+	}()
+
+	for i := 0; i < 100; i++ {
+		if got := k.Raw()["token"].(Secret[string]).Reveal(); got != "s3cr3t" {
+			t.Fatalf("expected copied secret to keep its value, got %q", got)
+		}
+	}
+	<-done
+}
+
+func TestRedactSecretsNestedKeyPaths(t *testing.T) {
+	got := RedactSecrets(map[string]any{
+		"db": map[string]any{
+			"password": "b",
+			"replicas": []any{map[string]any{"password": "c"}},
+		},
+		"cache":    map[string]any{"password": "keep"},
+		"password": "keep",
+	}, "db.password", "db.replicas.password")
+
+	expected := map[string]any{
+		"db": map[string]any{
+			"password": RedactedValue,
+			"replicas": []any{map[string]any{"password": RedactedValue}},
+		},
+		"cache":    map[string]any{"password": "keep"},
+		"password": "keep",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, got)
+	}
+}
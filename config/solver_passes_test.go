@@ -2,7 +2,11 @@ package config
 
 import (
 	"context"
+	"strings"
 	"testing"
+
+	"github.com/goliatone/go-config/koanf/solvers"
+	"github.com/goliatone/go-errors"
 )
 
 type solverPassConfig struct {
@@ -96,3 +100,258 @@ func TestContainerSolvers_SelectTemplateWorksWithCurrentPasses(t *testing.T) {
 		t.Fatalf("expected selected reminder profile max_reminders=2, got %d", cfg.Reminders.MaxReminders)
 	}
 }
+
+func TestContainerSolvers_DependencyGraphResolvesInSinglePass(t *testing.T) {
+	cfg := &solverPassConfig{}
+	defaultValues := map[string]any{
+		"foo":   "${a}",
+		"a":     "${b}",
+		"b":     "bar",
+		"value": `{{ "$" + "{foo}" }}`,
+	}
+
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*solverPassConfig](defaultValues)).
+		WithSolverPasses(1).
+		WithSolverDependencyGraph()
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Foo != "bar" || cfg.Value != "bar" {
+		t.Fatalf("expected foo and value to resolve, got foo=%q value=%q", cfg.Foo, cfg.Value)
+	}
+}
+
+func TestContainerSolvers_DependencyGraphCycleError(t *testing.T) {
+	cfg := &solverPassConfig{}
+	defaultValues := map[string]any{
+		"foo":   "${value}",
+		"value": "${foo}",
+	}
+
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*solverPassConfig](defaultValues)).
+		WithSolverDependencyGraph()
+
+	err := container.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected cycle error")
+	}
+
+	var richErr *errors.Error
+	if !errors.As(err, &richErr) {
+		t.Fatalf("expected go-errors error, got %T", err)
+	}
+	if richErr.TextCode != "CONFIG_REFERENCE_CYCLE" {
+		t.Fatalf("unexpected text code %q", richErr.TextCode)
+	}
+	cycle, ok := richErr.Metadata["cycle"].([]string)
+	if !ok || strings.Join(cycle, " -> ") != "foo -> value -> foo" {
+		t.Fatalf("unexpected cycle metadata %#v", richErr.Metadata["cycle"])
+	}
+}
+
+type refSolverConfig struct {
+	Cache struct {
+		Host string `koanf:"host"`
+		DB   int    `koanf:"db"`
+	} `koanf:"cache"`
+}
+
+func (c *refSolverConfig) Validate() error { return nil }
+
+func TestContainerSolvers_RefSolverCopiesSubtree(t *testing.T) {
+	cfg := &refSolverConfig{}
+	defaultValues := map[string]any{
+		"redis_host": "redis.internal",
+		"shared": map[string]any{
+			"redis": map[string]any{
+				"host": "${redis_host}",
+				"db":   0,
+			},
+		},
+		"cache": map[string]any{
+			"$ref": "#/shared/redis",
+			"db":   2,
+		},
+	}
+
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*refSolverConfig](defaultValues)).
+		WithSolvers(
+			solvers.NewRefSolver(),
+			solvers.NewVariablesSolver("${", "}"),
+		)
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Cache.Host != "redis.internal" || cfg.Cache.DB != 2 {
+		t.Fatalf("unexpected cache config %+v", cfg.Cache)
+	}
+}
+
+func TestContainerSolvers_RefDirectivesOptIn(t *testing.T) {
+	cfg := &refSolverConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*refSolverConfig](map[string]any{
+			"shared": map[string]any{
+				"redis": map[string]any{"host": "redis.internal"},
+			},
+			"cache": map[string]any{
+				"$ref": "#/shared/redis",
+				"db":   2,
+			},
+		})).
+		WithRefDirectives()
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Cache.Host != "redis.internal" || cfg.Cache.DB != 2 {
+		t.Fatalf("unexpected cache config %+v", cfg.Cache)
+	}
+}
+
+type refSchemaConfig struct {
+	Schema map[string]any `koanf:"schema"`
+}
+
+func (c *refSchemaConfig) Validate() error { return nil }
+
+func TestContainerSolvers_RefDirectivesOffByDefault(t *testing.T) {
+	cfg := &refSchemaConfig{}
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*refSchemaConfig](map[string]any{
+			"schema": map[string]any{
+				"properties": map[string]any{
+					"a": map[string]any{"$ref": "#/definitions/a"},
+				},
+			},
+		}))
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	property, _ := cfg.Schema["properties"].(map[string]any)["a"].(map[string]any)
+	if property["$ref"] != "#/definitions/a" {
+		t.Fatalf("expected embedded schema $ref to load unchanged, got %#v", cfg.Schema)
+	}
+}
+
+func TestContainerSolvers_RefSolverError(t *testing.T) {
+	cfg := &refSolverConfig{}
+	defaultValues := map[string]any{
+		"cache": map[string]any{
+			"$ref": "#/cache",
+		},
+	}
+
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*refSolverConfig](defaultValues)).
+		WithSolvers(solvers.NewRefSolver())
+
+	err := container.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected ref error")
+	}
+
+	var richErr *errors.Error
+	if !errors.As(err, &richErr) {
+		t.Fatalf("expected go-errors error, got %T", err)
+	}
+	if richErr.TextCode != "CONFIG_REF_RESOLUTION_FAILED" {
+		t.Fatalf("unexpected text code %q", richErr.TextCode)
+	}
+	if richErr.Metadata["failing_node_path"] != "cache" {
+		t.Fatalf("unexpected metadata %#v", richErr.Metadata)
+	}
+	cycle, ok := richErr.Metadata["cycle"].([]string)
+	if !ok || strings.Join(cycle, " -> ") != "#/cache -> #/cache" {
+		t.Fatalf("unexpected cycle metadata %#v", richErr.Metadata["cycle"])
+	}
+}
+
+type extendsSolverConfig struct {
+	Services struct {
+		API struct {
+			Host  string `koanf:"host"`
+			Port  int    `koanf:"port"`
+			Debug bool   `koanf:"debug"`
+		} `koanf:"api"`
+	} `koanf:"services"`
+}
+
+func (c *extendsSolverConfig) Validate() error { return nil }
+
+func TestContainerSolvers_ExtendsUsesBooleanPrecedence(t *testing.T) {
+	cfg := &extendsSolverConfig{}
+	defaultValues := map[string]any{
+		"services": map[string]any{
+			"base": map[string]any{
+				"host":  "localhost",
+				"port":  8080,
+				"debug": true,
+			},
+			"api": map[string]any{
+				"$extends": "services.base",
+				"host":     "",
+				"port":     "${api_port}",
+			},
+		},
+		"api_port": 9090,
+	}
+
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*extendsSolverConfig](defaultValues))
+
+	if err := container.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	api := cfg.Services.API
+	if api.Host != "localhost" || api.Port != 9090 || !api.Debug {
+		t.Fatalf("unexpected api config %+v", api)
+	}
+}
+
+func TestContainerSolvers_ExtendsError(t *testing.T) {
+	cfg := &extendsSolverConfig{}
+	defaultValues := map[string]any{
+		"services": map[string]any{
+			"api": map[string]any{
+				"$extends": "services.base",
+			},
+		},
+	}
+
+	container := New(cfg).
+		WithConfigPath("").
+		WithProvider(DefaultValuesProvider[*extendsSolverConfig](defaultValues))
+
+	err := container.Load(context.Background())
+	if err == nil {
+		t.Fatal("expected extends error")
+	}
+
+	var richErr *errors.Error
+	if !errors.As(err, &richErr) {
+		t.Fatalf("expected go-errors error, got %T", err)
+	}
+	if richErr.TextCode != "CONFIG_EXTENDS_RESOLUTION_FAILED" {
+		t.Fatalf("unexpected text code %q", richErr.TextCode)
+	}
+	if richErr.Metadata["failing_node_path"] != "services.api" || richErr.Metadata["extends_target"] != "services.base" {
+		t.Fatalf("unexpected metadata %#v", richErr.Metadata)
+	}
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationRule checks a field value against the rule parameter, "1" for
+// `validate:"min=1"` and "" for rules without one. The value is already
+// unwrapped from pointers, Secret and Optional. Return a message-style error
+// such as "must be at least 1"; the field path is added by the caller.
+type ValidationRule func(value reflect.Value, param string) error
+
+// FieldValidationError is the cause of a ValidationIssue reported by a
+// `validate` struct tag rule.
+type FieldValidationError struct {
+	Path  string
+	Rule  string
+	Param string
+	Err   error
+}
+
+func (e *FieldValidationError) Error() string {
+	if e == nil {
+		return "field validation failed"
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *FieldValidationError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// invalidRuleError marks a rule with a bad parameter or used on a field type
+// it cannot check. It is reported as an invalid tag, not an invalid value.
+type invalidRuleError struct {
+	msg string
+}
+
+func (e invalidRuleError) Error() string {
+	return e.msg
+}
+
+func ruleNotApplicable(rule string, t reflect.Type) error {
+	return invalidRuleError{msg: fmt.Sprintf("rule %q does not apply to %s", rule, t)}
+}
+
+func invalidRuleParam(rule, param string) error {
+	return invalidRuleError{msg: fmt.Sprintf("invalid %s parameter %q", rule, param)}
+}
+
+var builtinValidationRules = map[string]ValidationRule{
+	"min":          ruleMin,
+	"max":          ruleMax,
+	"oneof":        ruleOneOf,
+	"url":          ruleURL,
+	"hostport":     ruleHostPort,
+	"duration_min": ruleDurationMin,
+	"duration_max": ruleDurationMax,
+}
+
+func ruleMin(value reflect.Value, param string) error {
+	return compareRule("min", value, param, func(got, limit float64) bool { return got >= limit }, "at least")
+}
+
+func ruleMax(value reflect.Value, param string) error {
+	return compareRule("max", value, param, func(got, limit float64) bool { return got <= limit }, "at most")
+}
+
+// compareRule compares numbers by value and strings, slices and maps by
+// length.
+func compareRule(rule string, value reflect.Value, param string, ok func(got, limit float64) bool, phrase string) error {
+	limit, err := strconv.ParseFloat(strings.TrimSpace(param), 64)
+	if err != nil {
+		return invalidRuleParam(rule, param)
+	}
+
+	var got float64
+	unit := ""
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		got = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		got = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		got = value.Float()
+	case reflect.String:
+		got = float64(utf8.RuneCountInString(value.String()))
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		got = float64(value.Len())
+		unit = " items"
+	default:
+		return ruleNotApplicable(rule, value.Type())
+	}
+
+	if !ok(got, limit) {
+		return fmt.Errorf("must be %s %s%s", phrase, param, unit)
+	}
+	return nil
+}
+
+func ruleOneOf(value reflect.Value, param string) error {
+	options := strings.Fields(param)
+	switch value.Kind() {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return ruleNotApplicable("oneof", value.Type())
+	}
+
+	got := fmt.Sprint(value.Interface())
+	for _, option := range options {
+		if got == option {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of [%s]", strings.Join(options, " "))
+}
+
+// ruleURL accepts an absolute URL with a scheme and host. Empty strings pass;
+// combine with required to reject them.
+func ruleURL(value reflect.Value, _ string) error {
+	if value.Kind() != reflect.String {
+		return ruleNotApplicable("url", value.Type())
+	}
+	raw := value.String()
+	if raw == "" {
+		return nil
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("must be an absolute URL with scheme and host")
+	}
+	return nil
+}
+
+// ruleHostPort accepts "host:port" and ":port" with a port from 0 to 65535.
+// Empty strings pass; combine with required to reject them.
+func ruleHostPort(value reflect.Value, _ string) error {
+	if value.Kind() != reflect.String {
+		return ruleNotApplicable("hostport", value.Type())
+	}
+	raw := value.String()
+	if raw == "" {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(raw)
+	if err == nil {
+		_, err = strconv.ParseUint(port, 10, 16)
+	}
+	if err != nil {
+		return fmt.Errorf("must be host:port with a port from 0 to 65535")
+	}
+	return nil
+}
+
+func ruleDurationMin(value reflect.Value, param string) error {
+	return durationRule("duration_min", value, param, func(got, limit time.Duration) bool { return got >= limit }, "at least")
+}
+
+func ruleDurationMax(value reflect.Value, param string) error {
+	return durationRule("duration_max", value, param, func(got, limit time.Duration) bool { return got <= limit }, "at most")
+}
+
+// durationRule compares time.Duration fields, and strings parsed with
+// time.ParseDuration.
+func durationRule(rule string, value reflect.Value, param string, ok func(got, limit time.Duration) bool, phrase string) error {
+	limit, err := time.ParseDuration(strings.TrimSpace(param))
+	if err != nil {
+		return invalidRuleParam(rule, param)
+	}
+
+	var got time.Duration
+	switch {
+	case value.Type() == reflect.TypeOf(time.Duration(0)):
+		got = time.Duration(value.Int())
+	case value.Kind() == reflect.String:
+		if got, err = time.ParseDuration(value.String()); err != nil {
+			return fmt.Errorf("must be a duration")
+		}
+	default:
+		return ruleNotApplicable(rule, value.Type())
+	}
+
+	if !ok(got, limit) {
+		return fmt.Errorf("must be %s %s", phrase, limit)
+	}
+	return nil
+}
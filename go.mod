@@ -3,6 +3,7 @@ module github.com/goliatone/go-config
 go 1.24.10
 
 require (
+	filippo.io/age v1.2.1
 	github.com/go-viper/mapstructure/v2 v2.4.0
 	github.com/goliatone/go-errors v0.10.0
 	github.com/goliatone/go-logger v0.8.4
@@ -49,6 +50,7 @@ require (
 	github.com/tidwall/gjson v1.14.2 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.0 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
 	golang.org/x/exp v0.0.0-20251209150349-8475f28825e9 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/goliatone/go-config/logger"
+	masker "github.com/goliatone/go-masker"
 	"github.com/tidwall/sjson"
 )
 
@@ -17,6 +18,8 @@ type Env struct {
 	cb     func(key string, value string) (string, any)
 	out    string
 	logger logger.Logger
+	// sensitive reports keys whose values are redacted in debug logs.
+	sensitive func(key string) bool
 }
 
 // Provider works like built in env provider but with support for
@@ -71,6 +74,13 @@ func (e *Env) SetLogger(logger logger.Logger) {
 	e.logger = logger
 }
 
+// SetSensitiveKeys registers a predicate for dot separated keys, after the
+// callback transformation, whose values are redacted in debug logs in
+// addition to the fields masked by logger.MaskSensitive.
+func (e *Env) SetSensitiveKeys(sensitive func(key string) bool) {
+	e.sensitive = sensitive
+}
+
 // ReadBytes reads the contents of a file on disk and returns the bytes.
 func (e *Env) ReadBytes() ([]byte, error) {
 	// Collect the environment variable keys.
@@ -120,6 +130,9 @@ func (e *Env) ReadBytes() ([]byte, error) {
 		e.logger.Error("environment configuration log omitted", "reason", "invalid JSON output")
 		return []byte(e.out), nil
 	}
+	if e.sensitive != nil {
+		output = redactKeys(output, "", e.sensitive)
+	}
 	maskedOutput, err := logger.MaskSensitive(output)
 	if err != nil {
 		e.logger.Error("environment configuration log omitted", "reason", "masking failed")
@@ -145,3 +158,24 @@ func (e *Env) set(key string, value any) error {
 func (e *Env) Read() (map[string]any, error) {
 	return nil, errors.New("envextended provider does not support this method")
 }
+
+// redactKeys replaces the values of sensitive keys in a decoded JSON value.
+func redactKeys(value any, path string, sensitive func(string) bool) any {
+	values, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	out := make(map[string]any, len(values))
+	for key, item := range values {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		if sensitive(keyPath) {
+			out[key] = masker.RedactedValue
+			continue
+		}
+		out[key] = redactKeys(item, keyPath, sensitive)
+	}
+	return out
+}
@@ -232,6 +232,28 @@ func TestProviderLogsMaskedEnvironmentConfiguration(t *testing.T) {
 	assert.NotContains(t, logged, "TEST_TRANSLATION__OPENAI__API_KEY=api-key-sentinel")
 }
 
+func TestProviderRedactsSensitiveKeysInLogs(t *testing.T) {
+	os.Clearenv()
+	t.Setenv("TEST_DATABASE__DSN", "postgres://user:dsn-sentinel@db/app")
+	t.Setenv("TEST_DATABASE__HOST", "db.internal")
+
+	log := &recordingLogger{}
+	provider := Provider("TEST_", "__", func(s string) string {
+		return strings.ToLower(strings.Replace(s, "TEST_", "", 1))
+	})
+	provider.SetLogger(log)
+	provider.SetSensitiveKeys(func(key string) bool { return key == "database.dsn" })
+
+	data, err := provider.ReadBytes()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "dsn-sentinel", "returned config must retain the real value")
+
+	logged := log.String()
+	assert.NotContains(t, logged, "dsn-sentinel")
+	assert.Contains(t, logged, masker.RedactedValue)
+	assert.Contains(t, logged, "db.internal")
+}
+
 type recordingLogger struct {
 	entries []string
 }
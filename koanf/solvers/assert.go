@@ -0,0 +1,289 @@
+package solvers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	opts "github.com/goliatone/go-options"
+	"github.com/knadh/koanf/v2"
+)
+
+const defaultAssertKey = "$assert"
+
+// AssertionError captures a single failed $assert entry.
+type AssertionError struct {
+	NodePath   string
+	Expression string
+	Err        error
+}
+
+func (e *AssertionError) Error() string {
+	if e == nil {
+		return "assertion failed"
+	}
+	msg := fmt.Sprintf("assertion failed at %s: %s", e.NodePath, e.Expression)
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s (%v)", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *AssertionError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// AssertionFailedError aggregates every failed assertion from a solve,
+// ordered by node path.
+type AssertionFailedError struct {
+	Failures []*AssertionError
+}
+
+func (e *AssertionFailedError) Error() string {
+	if e == nil || len(e.Failures) == 0 {
+		return "assertion failed"
+	}
+	if len(e.Failures) == 1 {
+		return e.Failures[0].Error()
+	}
+	return fmt.Sprintf("%d assertions failed", len(e.Failures))
+}
+
+func (e *AssertionFailedError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	out := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		out = append(out, failure)
+	}
+	return out
+}
+
+type AssertSolverOption func(*assertSolver)
+
+type assertSolver struct {
+	key        string
+	delimiters *delimiters
+	evaluator  opts.Evaluator
+	failures   []*AssertionError
+}
+
+// NewAssertSolver evaluates invariants declared in object nodes and removes
+// the directive, for example:
+//
+//	{
+//	  "pool": {"min": 2, "max": 10},
+//	  "$assert": ["{{ pool.max >= pool.min }}", "{{ len(brokers) > 0 }}"]
+//	}
+//
+// Each entry is an expression, optionally wrapped by {{ }}, that must
+// evaluate to true. Identifiers resolve against the node holding the
+// directive first and the config root second. Failures are reported through
+// Err as an *AssertionFailedError. Run it after the other solvers so the
+// values it checks are resolved. The expression solver and the unresolved
+// reference scan skip values under the default $assert key.
+func NewAssertSolver(options ...AssertSolverOption) ConfigSolver {
+	solver := &assertSolver{
+		key:        defaultAssertKey,
+		delimiters: &delimiters{Start: defaultExpressionStart, End: defaultExpressionEnd},
+	}
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		opt(solver)
+	}
+	if solver.evaluator == nil {
+		solver.evaluator = opts.NewExprEvaluator()
+	}
+	return solver
+}
+
+// WithAssertKey sets the directive key (default $assert). Other solvers only
+// skip values under the default key.
+func WithAssertKey(key string) AssertSolverOption {
+	return func(s *assertSolver) {
+		if key = strings.TrimSpace(key); key != "" {
+			s.key = key
+		}
+	}
+}
+
+// WithAssertDelimiters sets the delimiters stripped from assertion entries
+// (default {{ }}).
+func WithAssertDelimiters(start, end string) AssertSolverOption {
+	return func(s *assertSolver) {
+		start, end = normalizeExpressionDelimiters(start, end)
+		s.delimiters = &delimiters{Start: start, End: end}
+	}
+}
+
+// WithAssertEvaluator sets the evaluator used for assertions.
+func WithAssertEvaluator(eval opts.Evaluator) AssertSolverOption {
+	return func(s *assertSolver) {
+		if eval != nil {
+			s.evaluator = eval
+		}
+	}
+}
+
+// isAssertKey reports whether key is a $assert directive or an entry of one,
+// e.g. "db.$assert" or "db.$assert.0".
+func isAssertKey(key, delim string) bool {
+	for _, part := range strings.Split(key, delim) {
+		if part == defaultAssertKey {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *assertSolver) Err() error {
+	if len(s.failures) == 0 {
+		return nil
+	}
+	failures := append([]*AssertionError{}, s.failures...)
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].NodePath < failures[j].NodePath
+	})
+	return &AssertionFailedError{Failures: failures}
+}
+
+// SolveContext implements ContextSolver.
+func (s *assertSolver) SolveContext(ctx context.Context, config *koanf.Koanf) error {
+	return solveInMemory(ctx, s, config)
+}
+
+func (s *assertSolver) Solve(config *koanf.Koanf) *koanf.Koanf {
+	s.failures = nil
+
+	if config == nil {
+		return config
+	}
+
+	root := config.Raw()
+	cleaned, found := s.walk(root, root, "", config.Delim())
+	if !found {
+		return config
+	}
+
+	for key := range root {
+		config.Delete(key)
+	}
+	for key, value := range cleaned.(map[string]any) {
+		config.Set(key, value)
+	}
+
+	return config
+}
+
+// walk evaluates the directives found in value and returns a copy of value
+// without them.
+func (s *assertSolver) walk(value any, root map[string]any, path, delim string) (any, bool) {
+	switch current := value.(type) {
+	case map[string]any:
+		found := false
+		out := make(map[string]any, len(current))
+		for key, child := range current {
+			if key == s.key {
+				found = true
+				continue
+			}
+			cleaned, childFound := s.walk(child, root, joinKey(path, key, delim), delim)
+			found = found || childFound
+			out[key] = cleaned
+		}
+		if directive, ok := current[s.key]; ok {
+			s.assert(directive, out, root, path)
+		}
+		return out, found
+	case []any:
+		found := false
+		out := make([]any, len(current))
+		for i, child := range current {
+			cleaned, childFound := s.walk(child, root, joinKey(path, strconv.Itoa(i), delim), delim)
+			found = found || childFound
+			out[i] = cleaned
+		}
+		return out, found
+	default:
+		return value, false
+	}
+}
+
+func (s *assertSolver) assert(directive any, node, root map[string]any, path string) {
+	nodePath := path
+	if nodePath == "" {
+		nodePath = "<root>"
+	}
+
+	var entries []any
+	switch v := directive.(type) {
+	case []any:
+		entries = v
+	default:
+		entries = []any{v}
+	}
+
+	snapshot := make(map[string]any, len(root)+len(node))
+	for key, value := range root {
+		snapshot[key] = value
+	}
+	for key, value := range node {
+		snapshot[key] = value
+	}
+
+	for _, entry := range entries {
+		// a single string directive is a leaf the expression solver may
+		// already have evaluated
+		if passed, ok := entry.(bool); ok {
+			if !passed {
+				s.failures = append(s.failures, &AssertionError{
+					NodePath:   nodePath,
+					Expression: "false",
+				})
+			}
+			continue
+		}
+
+		raw, ok := entry.(string)
+		if !ok {
+			s.failures = append(s.failures, &AssertionError{
+				NodePath:   nodePath,
+				Expression: fmt.Sprint(entry),
+				Err:        fmt.Errorf("assertion must be an expression string, got %T", entry),
+			})
+			continue
+		}
+
+		expr := s.expression(raw)
+		result, err := s.evaluator.Evaluate(opts.RuleContext{Snapshot: snapshot}, expr)
+		if err == nil {
+			if passed, isBool := result.(bool); !isBool {
+				err = fmt.Errorf("assertion must evaluate to a boolean, got %T", result)
+			} else if passed {
+				continue
+			}
+		}
+		s.failures = append(s.failures, &AssertionError{
+			NodePath:   nodePath,
+			Expression: expr,
+			Err:        err,
+		})
+	}
+}
+
+func (s *assertSolver) expression(raw string) string {
+	expr := strings.TrimSpace(raw)
+	if strings.HasPrefix(expr, s.delimiters.Start) && strings.HasSuffix(expr, s.delimiters.End) &&
+		len(expr) >= len(s.delimiters.Start)+len(s.delimiters.End) {
+		expr = expr[len(s.delimiters.Start) : len(expr)-len(s.delimiters.End)]
+	}
+	return strings.TrimSpace(expr)
+}
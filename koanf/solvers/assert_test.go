@@ -0,0 +1,136 @@
+package solvers
+
+import (
+	"errors"
+	"testing"
+
+	opts "github.com/goliatone/go-options"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertSolver_PassingAssertionsRemoveDirective(t *testing.T) {
+	k := koanf.New(".")
+	_ = k.Load(confmap.Provider(map[string]any{
+		"brokers": []any{"kafka-1:9092"},
+		"db": map[string]any{
+			"pool": map[string]any{
+				"min": 2,
+				"max": 10,
+			},
+			"$assert": []any{"{{ pool.max >= pool.min }}", "len(brokers) > 0"},
+		},
+		"$assert": "{{ db.pool.min > 0 }}",
+	}, "."), nil)
+
+	solver := NewAssertSolver()
+	out := solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.False(t, out.Exists("$assert"))
+	assert.False(t, out.Exists("db.$assert"))
+	assert.Equal(t, 10, out.Get("db.pool.max"))
+	assert.Equal(t, []any{"kafka-1:9092"}, out.Get("brokers"))
+}
+
+func TestAssertSolver_ReportsFailuresWithNodePath(t *testing.T) {
+	k := koanf.New(".")
+	_ = k.Load(confmap.Provider(map[string]any{
+		"brokers": []any{},
+		"db": map[string]any{
+			"pool": map[string]any{
+				"min": 20,
+				"max": 10,
+			},
+			"$assert": []any{"{{ pool.max >= pool.min }}", "{{ pool.max }}", 42},
+		},
+		"$assert": []any{"{{ len(brokers) > 0 }}", "{{ missing( }}"},
+	}, "."), nil)
+
+	solver := NewAssertSolver()
+	out := solver.Solve(k)
+
+	err := solver.(ErrorReporter).Err()
+	var failed *AssertionFailedError
+	require.True(t, errors.As(err, &failed))
+	require.Len(t, failed.Failures, 5)
+
+	assert.Equal(t, "<root>", failed.Failures[0].NodePath)
+	assert.Equal(t, "len(brokers) > 0", failed.Failures[0].Expression)
+	assert.NoError(t, failed.Failures[0].Err)
+	assert.Equal(t, "<root>", failed.Failures[1].NodePath)
+	assert.Error(t, failed.Failures[1].Err)
+
+	assert.Equal(t, "db", failed.Failures[2].NodePath)
+	assert.Equal(t, "pool.max >= pool.min", failed.Failures[2].Expression)
+	assert.Contains(t, failed.Failures[3].Err.Error(), "boolean")
+	assert.Contains(t, failed.Failures[4].Err.Error(), "expression string")
+
+	assert.False(t, out.Exists("$assert"))
+	assert.False(t, out.Exists("db.$assert"))
+}
+
+func TestAssertSolver_NestedInLists(t *testing.T) {
+	k := koanf.New(".")
+	_ = k.Load(confmap.Provider(map[string]any{
+		"servers": []any{
+			map[string]any{
+				"port":    0,
+				"$assert": []any{"port > 0"},
+			},
+		},
+	}, "."), nil)
+
+	solver := NewAssertSolver()
+	out := solver.Solve(k)
+
+	var failed *AssertionFailedError
+	require.True(t, errors.As(solver.(ErrorReporter).Err(), &failed))
+	require.Len(t, failed.Failures, 1)
+	assert.Equal(t, "servers.0", failed.Failures[0].NodePath)
+	assert.Equal(t, []any{map[string]any{"port": 0}}, out.Get("servers"))
+}
+
+func TestAssertSolver_UsesCustomEvaluator(t *testing.T) {
+	registry := opts.NewFunctionRegistry()
+	require.NoError(t, registry.Register("is_port", opts.Function(func(args ...any) (any, error) {
+		port, _ := args[0].(int)
+		return port > 0 && port <= 65535, nil
+	})))
+
+	k := koanf.New(".")
+	_ = k.Load(confmap.Provider(map[string]any{
+		"port":    8080,
+		"$assert": []any{"{{ is_port(port) }}"},
+	}, "."), nil)
+
+	solver := NewAssertSolver(
+		WithAssertEvaluator(opts.NewExprEvaluator(opts.ExprWithFunctionRegistry(registry))),
+	)
+	solver.Solve(k)
+
+	assert.NoError(t, solver.(ErrorReporter).Err())
+	assert.False(t, k.Exists("$assert"))
+}
+
+func TestExpressionSolver_SkipsAssertDirectives(t *testing.T) {
+	k := koanf.New(".")
+	_ = k.Load(confmap.Provider(map[string]any{
+		"db": map[string]any{
+			"min":     2,
+			"max":     10,
+			"$assert": "{{ max >= min }}",
+		},
+		"$assert": []any{"{{ missing_fn() }}"},
+	}, "."), nil)
+
+	solver := NewExpressionSolverWithEvaluator("{{", "}}", nil, OnEvalFail())
+	solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "{{ max >= min }}", k.Get("db.$assert"))
+	assert.Empty(t, solver.(ReferenceScanner).UnresolvedReferences(k))
+	assert.Empty(t, solver.(KeySolver).References("db.$assert", "{{ max >= min }}", k))
+}
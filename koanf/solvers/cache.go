@@ -0,0 +1,291 @@
+package solvers
+
+import (
+	"context"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// URICacheEvictReason tells why a URICache entry was dropped.
+type URICacheEvictReason string
+
+const (
+	URICacheExpired     URICacheEvictReason = "expired"
+	URICacheFileChanged URICacheEvictReason = "file_changed"
+	URICacheInvalidated URICacheEvictReason = "invalidated"
+)
+
+// URICacheOptions configures a URICache.
+type URICacheOptions struct {
+	// TTL maps a protocol to how long its values are kept, e.g.
+	// {"storage": time.Minute}. "include" also covers include+json,
+	// include+yaml and include+toml.
+	TTL map[string]time.Duration
+	// DefaultTTL applies to protocols missing from TTL. Zero disables
+	// caching for them.
+	DefaultTTL time.Duration
+	// OnEvict is called with the URI, e.g. "storage://s3://bucket#key", of
+	// every entry that is dropped.
+	OnEvict func(uri string, reason URICacheEvictReason)
+}
+
+// URICache keeps resolved URI values across solves, so a reload does not
+// read every storage object and included document again. Values are copied
+// in and out, so solvers never modify cached documents.
+//
+// The cache also records the file:// targets read through it. CheckFiles and
+// Watch evict the entries that depend on a changed file. Share a cache only
+// between URI solvers that read files from the same fs.FS.
+type URICache struct {
+	mu      sync.Mutex
+	opts    URICacheOptions
+	now     func() time.Time
+	entries map[string]*uriCacheEntry
+	files   map[string]uriFileState
+}
+
+type uriCacheEntry struct {
+	value   any
+	expires time.Time
+	files   []string
+}
+
+type uriFileState struct {
+	fs      fs.FS
+	modTime time.Time
+	size    int64
+	missing bool
+}
+
+type uriCacheEviction struct {
+	uri    string
+	reason URICacheEvictReason
+}
+
+// NewURICache returns an empty cache. Pass it to URI solvers with
+// WithURICache.
+func NewURICache(opts URICacheOptions) *URICache {
+	ttl := make(map[string]time.Duration, len(opts.TTL))
+	for protocol, d := range opts.TTL {
+		ttl[protocol] = d
+	}
+	opts.TTL = ttl
+	return &URICache{
+		opts:    opts,
+		now:     time.Now,
+		entries: map[string]*uriCacheEntry{},
+		files:   map[string]uriFileState{},
+	}
+}
+
+// WithURICache keeps resolved values in cache across solves. A nil cache
+// disables caching.
+func WithURICache(cache *URICache) URISolverOption {
+	return func(s *uris) {
+		s.cache = cache
+	}
+}
+
+// Len returns the number of cached values, including expired ones that were
+// not looked up since they expired.
+func (c *URICache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Invalidate drops the value cached for uri, e.g. "storage://s3://bucket#key".
+// It reports whether an entry was dropped.
+func (c *URICache) Invalidate(uri string) bool {
+	c.mu.Lock()
+	_, ok := c.entries[uri]
+	delete(c.entries, uri)
+	c.mu.Unlock()
+
+	if ok {
+		c.notify([]uriCacheEviction{{uri: uri, reason: URICacheInvalidated}})
+	}
+	return ok
+}
+
+// InvalidatePrefix drops every value whose URI starts with prefix, e.g.
+// "storage://" for a whole protocol, and returns how many were dropped.
+func (c *URICache) InvalidatePrefix(prefix string) int {
+	c.mu.Lock()
+	var evicted []uriCacheEviction
+	for uri := range c.entries {
+		if strings.HasPrefix(uri, prefix) {
+			delete(c.entries, uri)
+			evicted = append(evicted, uriCacheEviction{uri: uri, reason: URICacheInvalidated})
+		}
+	}
+	c.mu.Unlock()
+
+	c.notify(evicted)
+	return len(evicted)
+}
+
+// Purge drops every cached value.
+func (c *URICache) Purge() {
+	c.InvalidatePrefix("")
+}
+
+// CheckFiles compares the file:// targets read through the cache with their
+// state when they were read. It evicts the entries that depend on a changed
+// file and returns the changed paths.
+func (c *URICache) CheckFiles() []string {
+	c.mu.Lock()
+	var (
+		changed []string
+		evicted []uriCacheEviction
+	)
+	for path, previous := range c.files {
+		current := statURIFile(previous.fs, path)
+		if current.sameAs(previous) {
+			continue
+		}
+		c.files[path] = current
+		changed = append(changed, path)
+		evicted = append(evicted, c.evictFileLocked(path)...)
+	}
+	c.mu.Unlock()
+
+	c.notify(evicted)
+	sort.Strings(changed)
+	return changed
+}
+
+// Watch calls CheckFiles every interval until ctx is done, and onChange with
+// the changed paths, if any. It blocks, so run it in its own goroutine.
+func (c *URICache) Watch(ctx context.Context, interval time.Duration, onChange func(paths []string)) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if changed := c.CheckFiles(); len(changed) > 0 && onChange != nil {
+				onChange(changed)
+			}
+		}
+	}
+}
+
+func (c *URICache) ttl(protocol string) time.Duration {
+	if d, ok := c.opts.TTL[protocol]; ok {
+		return d
+	}
+	if _, include := includeProtocolFormat(protocol); include {
+		if d, ok := c.opts.TTL["include"]; ok {
+			return d
+		}
+	}
+	return c.opts.DefaultTTL
+}
+
+// resolve returns the cached value of protocol://uri or calls resolve and
+// caches its result. Files read while resolving become dependencies of the
+// entry.
+func (c *URICache) resolve(protocol, uri string, state *uriResolveState, resolve func() (any, error)) (any, error) {
+	key := protocol + "://" + uri
+	start := len(state.files)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	var evicted []uriCacheEviction
+	if ok && !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		evicted = append(evicted, uriCacheEviction{uri: key, reason: URICacheExpired})
+		ok = false
+	}
+	c.mu.Unlock()
+	c.notify(evicted)
+
+	if ok {
+		state.files = append(state.files, entry.files...)
+		return copyValue(entry.value), nil
+	}
+
+	value, err := resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := c.ttl(protocol)
+	if ttl <= 0 {
+		return value, nil
+	}
+	c.mu.Lock()
+	c.entries[key] = &uriCacheEntry{
+		value:   copyValue(value),
+		expires: c.now().Add(ttl),
+		files:   append([]string(nil), state.files[start:]...),
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// trackFile records the state of a file:// target before it is read. A file
+// that changed since it was last read evicts its dependents right away.
+func (c *URICache) trackFile(f fs.FS, uri string, state *uriResolveState) {
+	path, err := sanitizeFileURIPath(uri)
+	if err != nil {
+		return
+	}
+	state.files = append(state.files, path)
+	current := statURIFile(f, path)
+
+	c.mu.Lock()
+	previous, known := c.files[path]
+	c.files[path] = current
+	var evicted []uriCacheEviction
+	if known && !current.sameAs(previous) {
+		evicted = c.evictFileLocked(path)
+	}
+	c.mu.Unlock()
+
+	c.notify(evicted)
+}
+
+func (c *URICache) evictFileLocked(path string) []uriCacheEviction {
+	var evicted []uriCacheEviction
+	for uri, entry := range c.entries {
+		for _, file := range entry.files {
+			if file == path {
+				delete(c.entries, uri)
+				evicted = append(evicted, uriCacheEviction{uri: uri, reason: URICacheFileChanged})
+				break
+			}
+		}
+	}
+	return evicted
+}
+
+func (c *URICache) notify(evicted []uriCacheEviction) {
+	if c.opts.OnEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		c.opts.OnEvict(e.uri, e.reason)
+	}
+}
+
+func statURIFile(f fs.FS, path string) uriFileState {
+	info, err := fs.Stat(f, path)
+	if err != nil {
+		return uriFileState{fs: f, missing: true}
+	}
+	return uriFileState{fs: f, modTime: info.ModTime(), size: info.Size()}
+}
+
+func (s uriFileState) sameAs(other uriFileState) bool {
+	return s.missing == other.missing && s.size == other.size && s.modTime.Equal(other.modTime)
+}
@@ -0,0 +1,122 @@
+package solvers
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCachedStorageSolver(cache *URICache, store *mockStorager) ConfigSolver {
+	solver := NewURISolverWithOptions("@", "://", WithURICache(cache))
+	solver.(*uris).newStorager = func(string) (storageReader, error) { return store, nil }
+	return solver
+}
+
+func TestURICache_KeepsValuesAcrossSolves(t *testing.T) {
+	store := &mockStorager{contentPath: map[string]string{"secrets/password.txt": "super-secret\n"}}
+	cache := NewURICache(URICacheOptions{TTL: map[string]time.Duration{"storage": time.Minute}})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	load := func() *koanf.Koanf {
+		k := loadContextTestConfig(t, map[string]any{"password": "@storage://mock://tenant/config#secrets/password.txt"})
+		return newCachedStorageSolver(cache, store).Solve(k)
+	}
+
+	assert.Equal(t, "super-secret", load().String("password"))
+	assert.Equal(t, "super-secret", load().String("password"))
+	assert.Equal(t, 1, store.reads)
+	assert.Equal(t, 1, cache.Len())
+
+	store.contentPath["secrets/password.txt"] = "rotated"
+	now = now.Add(time.Minute)
+	assert.Equal(t, "rotated", load().String("password"))
+	assert.Equal(t, 2, store.reads)
+}
+
+func TestURICache_ProtocolWithoutTTLIsNotCached(t *testing.T) {
+	store := &mockStorager{contentPath: map[string]string{"secrets/password.txt": "super-secret"}}
+	cache := NewURICache(URICacheOptions{TTL: map[string]time.Duration{"include": time.Minute}})
+
+	for i := 0; i < 2; i++ {
+		k := loadContextTestConfig(t, map[string]any{"password": "@storage://mock://tenant/config#secrets/password.txt"})
+		newCachedStorageSolver(cache, store).Solve(k)
+	}
+	assert.Equal(t, 2, store.reads)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestURICache_Invalidate(t *testing.T) {
+	store := &mockStorager{contentPath: map[string]string{"a": "1", "b": "2"}}
+	var evicted []string
+	cache := NewURICache(URICacheOptions{
+		DefaultTTL: time.Hour,
+		OnEvict: func(uri string, reason URICacheEvictReason) {
+			assert.Equal(t, URICacheInvalidated, reason)
+			evicted = append(evicted, uri)
+		},
+	})
+	load := func() {
+		k := loadContextTestConfig(t, map[string]any{
+			"a": "@storage://mock://x#a",
+			"b": "@storage://mock://x#b",
+		})
+		newCachedStorageSolver(cache, store).Solve(k)
+	}
+
+	load()
+	require.Equal(t, 2, cache.Len())
+
+	assert.True(t, cache.Invalidate("storage://mock://x#a"))
+	assert.False(t, cache.Invalidate("storage://mock://x#a"))
+	assert.Equal(t, []string{"storage://mock://x#a"}, evicted)
+
+	load()
+	assert.Equal(t, 3, store.reads)
+
+	assert.Equal(t, 2, cache.InvalidatePrefix("storage://"))
+	cache.Purge()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestURICache_CachedIncludeIsCopied(t *testing.T) {
+	fsys := fstest.MapFS{"common.json": {Data: []byte(`{"db": {"host": "localhost"}}`)}}
+	cache := NewURICache(URICacheOptions{TTL: map[string]time.Duration{"include": time.Minute}})
+	solver := NewURISolverWithFSAndOptions("@", "://", fsys, WithURICache(cache))
+
+	k := loadContextTestConfig(t, map[string]any{"shared": "@include://file://common.json"})
+	solver.Solve(k)
+	require.NoError(t, k.Set("shared.db.host", "changed"))
+
+	k = loadContextTestConfig(t, map[string]any{"shared": "@include://file://common.json"})
+	solver.Solve(k)
+	assert.Equal(t, "localhost", k.String("shared.db.host"))
+}
+
+func TestURICache_FileChangeEvictsDependents(t *testing.T) {
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{"common.json": {Data: []byte(`{"host": "a"}`), ModTime: modTime}}
+	var reasons []URICacheEvictReason
+	cache := NewURICache(URICacheOptions{
+		TTL:     map[string]time.Duration{"include": time.Hour},
+		OnEvict: func(_ string, reason URICacheEvictReason) { reasons = append(reasons, reason) },
+	})
+	solver := NewURISolverWithFSAndOptions("@", "://", fsys, WithURICache(cache))
+	load := func() string {
+		k := loadContextTestConfig(t, map[string]any{"shared": "@include://file://common.json"})
+		return solver.Solve(k).String("shared.host")
+	}
+
+	assert.Equal(t, "a", load())
+	assert.Empty(t, cache.CheckFiles())
+
+	fsys["common.json"] = &fstest.MapFile{Data: []byte(`{"host": "b"}`), ModTime: modTime.Add(time.Second)}
+	assert.Equal(t, "a", load(), "cached until the change is detected")
+	assert.Equal(t, []string{"common.json"}, cache.CheckFiles())
+	assert.Equal(t, []URICacheEvictReason{URICacheFileChanged}, reasons)
+	assert.Equal(t, "b", load())
+}
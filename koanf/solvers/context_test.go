@@ -0,0 +1,118 @@
+package solvers
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.beyondstorage.io/v5/types"
+)
+
+var (
+	_ ContextSolver    = variables{}
+	_ ContextSolver    = uris{}
+	_ ContextSolver    = (*expression)(nil)
+	_ ContextSolver    = (*selectSolver)(nil)
+	_ ContextSolver    = (*extendsSolver)(nil)
+	_ ContextSolver    = (*refSolver)(nil)
+	_ ContextSolver    = (*assertSolver)(nil)
+	_ ContextSolver    = (*graphSolver)(nil)
+	_ ContextKeySolver = uris{}
+)
+
+type legacySolver struct {
+	calls int
+}
+
+func (s *legacySolver) Solve(config *koanf.Koanf) *koanf.Koanf {
+	s.calls++
+	config.Set("legacy", true)
+	return config
+}
+
+type blockingStorager struct{}
+
+func (blockingStorager) ReadWithContext(ctx context.Context, _ string, _ io.Writer, _ ...types.Pair) (int64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func loadContextTestConfig(t *testing.T, values map[string]any) *koanf.Koanf {
+	t.Helper()
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(values, "."), nil))
+	return k
+}
+
+func TestSolveWithContext_LegacySolver(t *testing.T) {
+	solver := &legacySolver{}
+	k := loadContextTestConfig(t, map[string]any{})
+
+	require.NoError(t, SolveWithContext(context.Background(), solver, k))
+	assert.Equal(t, 1, solver.calls)
+	assert.True(t, k.Bool("legacy"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, SolveWithContext(ctx, solver, k), context.Canceled)
+	assert.Equal(t, 1, solver.calls, "canceled context skips the solver")
+}
+
+func TestURISolver_SolveContextStopsHungStorage(t *testing.T) {
+	value := "@storage://mock://tenant/config#secrets/password.txt"
+	k := loadContextTestConfig(t, map[string]any{"password": value})
+
+	solver := NewURISolver("@", "://").(*uris)
+	solver.newStorager = func(string) (storageReader, error) { return blockingStorager{}, nil }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := SolveWithContext(ctx, solver, k)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, value, k.String("password"))
+}
+
+func TestContextProtocolResolver(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "tenant-a")
+	k := loadContextTestConfig(t, map[string]any{"tenant": "@ctx://tenant"})
+
+	solver := NewURISolverWithOptions("@", "://", WithURIContextProtocolResolver("ctx", func(ctx context.Context, uri string) (any, error) {
+		assert.Equal(t, "tenant", uri)
+		return ctx.Value(ctxKey{}), nil
+	}))
+	require.NoError(t, SolveWithContext(ctx, solver, k))
+	assert.Equal(t, "tenant-a", k.String("tenant"))
+
+	// Solve falls back to a background context
+	k = loadContextTestConfig(t, map[string]any{"tenant": "@ctx://tenant"})
+	solver.Solve(k)
+	assert.Equal(t, "", k.String("tenant"))
+}
+
+func TestGraphSolver_SolveContext(t *testing.T) {
+	value := "@storage://mock://tenant/config#secrets/password.txt"
+	k := loadContextTestConfig(t, map[string]any{
+		"password": value,
+		"dsn":      "postgres://app:${password}@db",
+	})
+
+	uriSolver := NewURISolver("@", "://").(*uris)
+	uriSolver.newStorager = func(string) (storageReader, error) { return blockingStorager{}, nil }
+	graph := NewGraphSolver(NewVariablesSolver("${", "}"), uriSolver)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := SolveWithContext(ctx, graph, k)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, graph.(ErrorReporter).Err(), context.DeadlineExceeded)
+}
@@ -0,0 +1,267 @@
+package solvers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EncryptionAlgorithmAES256GCM is the only algorithm understood by the enc
+// protocol. Encrypted values look like:
+//
+//	@enc://AES256GCM:<base64 nonce+ciphertext>
+//	@enc://AES256GCM:<key id>:<base64 nonce+ciphertext>
+//
+// The header before the payload is authenticated, so a value cannot be moved
+// to another key ID without failing to decrypt.
+const EncryptionAlgorithmAES256GCM = "AES256GCM"
+
+// ErrDecryptionFailed is returned when an enc value cannot be decrypted.
+var ErrDecryptionFailed = errors.New("decryption failed")
+
+// DecryptionError reports the keys whose enc values failed to decrypt during
+// a solve. Unlike other resolver errors it fails the solve whatever the
+// URIErrorStrategy, so ciphertext never reaches the decoded config.
+type DecryptionError struct {
+	Keys []string
+	// Err is the failure of the first key.
+	Err error
+}
+
+func (e *DecryptionError) Error() string {
+	if e == nil || len(e.Keys) == 0 {
+		return ErrDecryptionFailed.Error()
+	}
+	if len(e.Keys) == 1 {
+		return fmt.Sprintf("key %s: %v", e.Keys[0], e.Err)
+	}
+	return fmt.Sprintf("keys %s: %v", strings.Join(e.Keys, ", "), e.Err)
+}
+
+func (e *DecryptionError) Unwrap() error {
+	if e == nil || e.Err == nil {
+		return ErrDecryptionFailed
+	}
+	return e.Err
+}
+
+// KeyProvider returns the 32 byte AES-256 key for a key ID. The empty ID
+// names the default key.
+type KeyProvider interface {
+	Key(id string) ([]byte, error)
+}
+
+// KeyProviderFunc adapts a function to KeyProvider.
+type KeyProviderFunc func(id string) ([]byte, error)
+
+func (f KeyProviderFunc) Key(id string) ([]byte, error) {
+	return f(id)
+}
+
+// Keyring maps key IDs to providers so keys can be rotated: new values are
+// encrypted with the new ID while old values still decrypt. The "" entry is
+// used for values without a key ID.
+type Keyring map[string]KeyProvider
+
+func (k Keyring) Key(id string) ([]byte, error) {
+	provider, ok := k[id]
+	if !ok || provider == nil {
+		return nil, fmt.Errorf("unknown encryption key id %q", id)
+	}
+	return provider.Key(id)
+}
+
+// StaticKey returns key for any key ID.
+func StaticKey(key []byte) KeyProvider {
+	return KeyProviderFunc(func(string) ([]byte, error) {
+		return validateKey(key)
+	})
+}
+
+// FileKey reads the key from path on each use. The file holds the raw 32
+// bytes, or their base64 or hex encoding.
+func FileKey(path string) KeyProvider {
+	return KeyProviderFunc(func(string) ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read encryption key: %w", err)
+		}
+		return decodeKey(data)
+	})
+}
+
+// EnvKey reads the base64 or hex encoded key from the environment variable
+// name on each use.
+func EnvKey(name string) KeyProvider {
+	return KeyProviderFunc(func(string) ([]byte, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("encryption key env var %s is not set", name)
+		}
+		return decodeKey([]byte(value))
+	})
+}
+
+// GenerateKey returns a random AES-256 key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SealValue encrypts plaintext with the key keyID and returns the enc payload
+// without the protocol prefix, e.g. "AES256GCM:prod:<base64>".
+func SealValue(plaintext []byte, keyID string, keys KeyProvider) (string, error) {
+	return SealValueWithAAD(plaintext, keyID, keys, nil)
+}
+
+// SealValueWithAAD is SealValue with additional authenticated data, e.g. the
+// key path of the value, which must be passed again to OpenValueWithAAD.
+func SealValueWithAAD(plaintext []byte, keyID string, keys KeyProvider, aad []byte) (string, error) {
+	if strings.Contains(keyID, ":") {
+		return "", fmt.Errorf("encryption key id %q must not contain ':'", keyID)
+	}
+	if keys == nil {
+		return "", fmt.Errorf("no encryption key provider")
+	}
+	key, err := keys.Key(keyID)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	header := encHeader(keyID)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, encAAD(header, aad))
+	return header + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenValue decrypts an enc payload produced by SealValue.
+func OpenValue(payload string, keys KeyProvider) ([]byte, error) {
+	return OpenValueWithAAD(payload, keys, nil)
+}
+
+// OpenValueWithAAD decrypts a payload produced by SealValueWithAAD.
+func OpenValueWithAAD(payload string, keys KeyProvider, aad []byte) ([]byte, error) {
+	if keys == nil {
+		return nil, fmt.Errorf("%w: no encryption key provider", ErrDecryptionFailed)
+	}
+	keyID, encoded, err := parseEncryptedValue(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid base64 payload", ErrDecryptionFailed)
+	}
+	key, err := keys.Key(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: payload too short", ErrDecryptionFailed)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, encAAD(encHeader(keyID), aad))
+	if err != nil {
+		return nil, fmt.Errorf("%w: key id %q", ErrDecryptionFailed, keyID)
+	}
+	return plaintext, nil
+}
+
+// parseEncryptedValue splits an enc payload into its key ID and base64
+// ciphertext.
+func parseEncryptedValue(payload string) (keyID, ciphertext string, err error) {
+	parts := strings.Split(strings.TrimSpace(payload), ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != EncryptionAlgorithmAES256GCM {
+		return "", "", fmt.Errorf("%w: expected %s:[key id:]<base64>", ErrDecryptionFailed, EncryptionAlgorithmAES256GCM)
+	}
+	if len(parts) == 3 {
+		keyID = parts[1]
+	}
+	return keyID, parts[len(parts)-1], nil
+}
+
+// WithURIEncryptionKeys registers the enc protocol, decrypting values with
+// keys. Keys resolved through enc are reported to the sensitive key handler.
+func WithURIEncryptionKeys(keys KeyProvider) URISolverOption {
+	return func(s *uris) {
+		s.registerResolver("enc", func(uri string, _ *uriResolveState) (any, error) {
+			plaintext, err := OpenValue(uri, keys)
+			if err != nil {
+				return nil, err
+			}
+			return string(plaintext), nil
+		})
+		s.markSensitiveProtocol("enc")
+	}
+}
+
+func encHeader(keyID string) string {
+	if keyID == "" {
+		return EncryptionAlgorithmAES256GCM
+	}
+	return EncryptionAlgorithmAES256GCM + ":" + keyID
+}
+
+func encAAD(header string, extra []byte) []byte {
+	if len(extra) == 0 {
+		return []byte(header)
+	}
+	return append([]byte(header+"\x00"), extra...)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	key, err := validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func validateKey(key []byte) ([]byte, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+func decodeKey(data []byte) ([]byte, error) {
+	if len(data) == 32 {
+		return data, nil
+	}
+	text := strings.TrimSpace(string(data))
+	if decoded, err := base64.StdEncoding.DecodeString(text); err == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	if decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(text, "=")); err == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	if decoded, err := hex.DecodeString(text); err == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("encryption key must be 32 bytes, raw or base64/hex encoded")
+}
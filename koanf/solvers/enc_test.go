@@ -0,0 +1,198 @@
+package solvers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	return key
+}
+
+func TestSealOpenValue(t *testing.T) {
+	keys := StaticKey(mustKey(t))
+
+	payload, err := SealValue([]byte("hunter2"), "", keys)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(payload, EncryptionAlgorithmAES256GCM+":"))
+	assert.NotContains(t, payload, "hunter2")
+
+	plaintext, err := OpenValue(payload, keys)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", string(plaintext))
+
+	again, err := SealValue([]byte("hunter2"), "", keys)
+	require.NoError(t, err)
+	assert.NotEqual(t, payload, again, "nonce must be random")
+}
+
+func TestOpenValue_DetectsTampering(t *testing.T) {
+	keys := StaticKey(mustKey(t))
+	payload, err := SealValue([]byte("hunter2"), "", keys)
+	require.NoError(t, err)
+
+	encoded := payload[len(EncryptionAlgorithmAES256GCM)+1:]
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	sealed[len(sealed)-1] ^= 0x01
+	tampered := EncryptionAlgorithmAES256GCM + ":" + base64.StdEncoding.EncodeToString(sealed)
+
+	_, err = OpenValue(tampered, keys)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	_, err = OpenValue(payload, StaticKey(mustKey(t)))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	_, err = OpenValue("AES128:abc", keys)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestOpenValue_KeyRotation(t *testing.T) {
+	oldKey, newKey := StaticKey(mustKey(t)), StaticKey(mustKey(t))
+	keyring := Keyring{"": oldKey, "v2": newKey}
+
+	legacy, err := SealValue([]byte("old"), "", keyring)
+	require.NoError(t, err)
+	rotated, err := SealValue([]byte("new"), "v2", keyring)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rotated, EncryptionAlgorithmAES256GCM+":v2:"))
+
+	plaintext, err := OpenValue(legacy, keyring)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(plaintext))
+
+	plaintext, err = OpenValue(rotated, keyring)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(plaintext))
+
+	// the key id is authenticated, relabelling the payload fails
+	relabelled := strings.Replace(rotated, ":v2:", ":v3:", 1)
+	_, err = OpenValue(relabelled, Keyring{"v3": newKey})
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	_, err = OpenValue(rotated, Keyring{"": oldKey})
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestFileAndEnvKeys(t *testing.T) {
+	key := mustKey(t)
+	dir := t.TempDir()
+
+	rawPath := filepath.Join(dir, "raw.key")
+	require.NoError(t, os.WriteFile(rawPath, key, 0o600))
+	b64Path := filepath.Join(dir, "b64.key")
+	require.NoError(t, os.WriteFile(b64Path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600))
+	t.Setenv("APP_CONFIG_KEY", hex.EncodeToString(key))
+
+	payload, err := SealValue([]byte("hunter2"), "", StaticKey(key))
+	require.NoError(t, err)
+
+	for name, provider := range map[string]KeyProvider{
+		"raw file":    FileKey(rawPath),
+		"base64 file": FileKey(b64Path),
+		"hex env":     EnvKey("APP_CONFIG_KEY"),
+	} {
+		plaintext, err := OpenValue(payload, provider)
+		require.NoError(t, err, name)
+		assert.Equal(t, "hunter2", string(plaintext), name)
+	}
+
+	_, err = OpenValue(payload, EnvKey("APP_CONFIG_KEY_MISSING"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	_, err = OpenValue(payload, FileKey(filepath.Join(dir, "missing.key")))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestURISolver_EncProtocol(t *testing.T) {
+	keys := StaticKey(mustKey(t))
+	password, err := SealValue([]byte("hunter2"), "", keys)
+	require.NoError(t, err)
+	included, err := SealValue([]byte(`{"user":"admin"}`), "", keys)
+	require.NoError(t, err)
+
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(map[string]any{
+		"db": map[string]any{
+			"password": "@enc://" + password,
+			"auth":     "@include://enc://" + included,
+			"host":     "@base64://bG9jYWxob3N0",
+		},
+	}, "."), nil))
+
+	var sensitive []string
+	solver := NewURISolverWithOptions("@", "://",
+		WithURIEncryptionKeys(keys),
+		WithURISensitiveKeyHandler(func(key string) { sensitive = append(sensitive, key) }),
+	)
+	solver.Solve(k)
+
+	assert.Equal(t, "hunter2", k.String("db.password"))
+	assert.Equal(t, "admin", k.String("db.auth.user"))
+	assert.Equal(t, "localhost", k.String("db.host"))
+	assert.ElementsMatch(t, []string{"db.password", "db.auth"}, sensitive)
+}
+
+func TestReplaceURISolverOptions(t *testing.T) {
+	keys := StaticKey(mustKey(t))
+	payload, err := SealValue([]byte("hunter2"), "", keys)
+	require.NoError(t, err)
+
+	original := NewURISolver("@", "://")
+	replaced, ok := ReplaceURISolverOptions(original, WithURIEncryptionKeys(keys))
+	require.True(t, ok)
+
+	load := func() *koanf.Koanf {
+		k := koanf.New(".")
+		require.NoError(t, k.Load(confmap.Provider(map[string]any{"password": "@enc://" + payload}, "."), nil))
+		return k
+	}
+
+	assert.Equal(t, "hunter2", replaced.Solve(load()).String("password"))
+	assert.Equal(t, "@enc://"+payload, original.Solve(load()).String("password"), "original solver must not change")
+
+	_, ok = ReplaceURISolverOptions(NewVariablesSolver("${", "}"), WithURIEncryptionKeys(keys))
+	assert.False(t, ok)
+}
+
+func TestURISolver_EncFailureFailsSolve(t *testing.T) {
+	keys := StaticKey(mustKey(t))
+	other := StaticKey(mustKey(t))
+	password, err := SealValue([]byte("hunter2"), "", other)
+	require.NoError(t, err)
+
+	for _, opts := range [][]URISolverOption{
+		{WithURIEncryptionKeys(keys)},
+		{WithURIEncryptionKeys(keys), WithURIOnErrorRemove()},
+	} {
+		k := koanf.New(".")
+		require.NoError(t, k.Load(confmap.Provider(map[string]any{
+			"db": map[string]any{
+				"password": "@enc://" + password,
+				"host":     "@file://missing.txt",
+			},
+		}, "."), nil))
+
+		solver := NewURISolverWithOptions("@", "://", opts...)
+		err := solver.(ContextSolver).SolveContext(context.Background(), k)
+
+		var decryptErr *DecryptionError
+		require.True(t, errors.As(err, &decryptErr), "got %v", err)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+		assert.Equal(t, []string{"db.password"}, decryptErr.Keys)
+		assert.NotContains(t, err.Error(), "hunter2")
+	}
+}
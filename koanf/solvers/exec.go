@@ -0,0 +1,232 @@
+package solvers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultExecTimeout bounds a single exec command when ExecOptions sets
+	// no timeout.
+	DefaultExecTimeout = 10 * time.Second
+	// DefaultExecMaxOutput is the stdout limit when ExecOptions sets none.
+	DefaultExecMaxOutput = 1 << 20
+
+	execStderrLimit = 4 << 10
+)
+
+// ErrExecNotAllowed is returned for commands that are not in the exec
+// allowlist.
+var ErrExecNotAllowed = errors.New("executable not allowed")
+
+// ExecOptions configures the exec protocol:
+//
+//	@exec://pass show db/prod
+//	@exec://vault-helper --field "api key" secret/app
+//
+// The command line is split like a shell would, honouring quotes and
+// backslash escapes, but no shell runs it.
+type ExecOptions struct {
+	// Allow lists the executables that may run. Bare names match the first
+	// command word exactly and are looked up in PATH; entries with a path
+	// separator match that path only. An empty list allows nothing.
+	Allow []string
+	// Timeout bounds each command. The load context also applies.
+	Timeout time.Duration
+	// MaxOutput caps stdout in bytes.
+	MaxOutput int64
+	// Dir is the working directory, the current one when empty.
+	Dir string
+	// Env is appended to the environment of the current process.
+	Env []string
+}
+
+// WithURIExecProtocol registers the exec protocol, which runs an allowed
+// command and uses its trimmed stdout as the value. The protocol is not
+// registered by default. Values from exec are reported to the sensitive key
+// handler.
+func WithURIExecProtocol(opts ExecOptions) URISolverOption {
+	return func(s *uris) {
+		s.registerResolver("exec", func(uri string, state *uriResolveState) (any, error) {
+			if state == nil {
+				state = s.newResolveState()
+			}
+			cacheKey := "exec://" + uri
+			if content, ok := state.valuesByURI[cacheKey]; ok {
+				return content, nil
+			}
+			content, err := runExecCommand(state.ctx, uri, opts)
+			if err != nil {
+				return nil, err
+			}
+			state.valuesByURI[cacheKey] = content
+			return content, nil
+		})
+		s.markSensitiveProtocol("exec")
+	}
+}
+
+func runExecCommand(ctx context.Context, commandLine string, opts ExecOptions) (string, error) {
+	args, err := splitCommandLine(commandLine)
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("exec: empty command")
+	}
+	if !execAllowed(args[0], opts.Allow) {
+		return "", fmt.Errorf("exec %s: %w", args[0], ErrExecNotAllowed)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultExecTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	maxOutput := opts.MaxOutput
+	if maxOutput <= 0 {
+		maxOutput = DefaultExecMaxOutput
+	}
+	stdout := &limitedBuffer{limit: maxOutput}
+	stderr := &limitedBuffer{limit: execStderrLimit, truncate: true}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = opts.Dir
+	cmd.Env = append(os.Environ(), opts.Env...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%w after %s", ctxErr, timeout)
+		}
+		if stdout.exceeded {
+			err = fmt.Errorf("stdout exceeds %d bytes", maxOutput)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("exec %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("exec %s: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func execAllowed(name string, allow []string) bool {
+	for _, entry := range allow {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.ContainsRune(entry, filepath.Separator) || strings.ContainsRune(entry, '/') {
+			if filepath.Clean(entry) == filepath.Clean(name) {
+				return true
+			}
+			continue
+		}
+		if entry == name {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCommandLine splits s into words like a POSIX shell without expansion:
+// single quotes keep text literally, double quotes allow \" and \\ escapes,
+// and a backslash outside quotes escapes the next character.
+func splitCommandLine(s string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("exec: unterminated quote or escape in %q", s)
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// limitedBuffer stops accepting writes after limit bytes. When truncate is
+// false, going over the limit fails the write so the command stops.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int64
+	truncate bool
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - int64(b.buf.Len())
+	if int64(len(p)) <= remaining {
+		return b.buf.Write(p)
+	}
+	b.exceeded = true
+	if remaining > 0 {
+		b.buf.Write(p[:remaining])
+	}
+	if b.truncate {
+		return len(p), nil
+	}
+	return 0, fmt.Errorf("output exceeds %d bytes", b.limit)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
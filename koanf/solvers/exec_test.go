@@ -0,0 +1,115 @@
+package solvers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	cases := map[string][]string{
+		`pass show db/prod`:               {"pass", "show", "db/prod"},
+		`helper --field "api key"  x`:     {"helper", "--field", "api key", "x"},
+		`helper 'it''s' "a \"b\" \c"`:     {"helper", "its", `a "b" \c`},
+		`helper a\ b ''`:                  {"helper", "a b", ""},
+		"  helper\tone\n":                 {"helper", "one"},
+		`sh -c 'echo "$HOME" | tr a b'`:   {"sh", "-c", `echo "$HOME" | tr a b`},
+		`helper "mixed"'quotes'and\"bare`: {"helper", `mixedquotesand"bare`},
+	}
+	for input, expected := range cases {
+		args, err := splitCommandLine(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, args, input)
+	}
+
+	for _, input := range []string{`helper "open`, `helper 'open`, `helper trailing\`} {
+		_, err := splitCommandLine(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func solveExec(t *testing.T, value string, opts ...URISolverOption) (*koanf.Koanf, []string) {
+	t.Helper()
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(map[string]any{"secret": value}, "."), nil))
+
+	var sensitive []string
+	opts = append(opts, WithURISensitiveKeyHandler(func(key string) { sensitive = append(sensitive, key) }))
+	NewURISolverWithOptions("@", "://", opts...).Solve(k)
+	return k, sensitive
+}
+
+func TestExecProtocol(t *testing.T) {
+	k, sensitive := solveExec(t, `@exec://sh -c 'printf "  s3cr3t\n\n"'`,
+		WithURIExecProtocol(ExecOptions{Allow: []string{"sh"}}))
+	assert.Equal(t, "s3cr3t", k.String("secret"))
+	assert.Equal(t, []string{"secret"}, sensitive)
+
+	k, _ = solveExec(t, `@exec://sh -c 'echo "$APP_EXEC_TEST"'`,
+		WithURIExecProtocol(ExecOptions{Allow: []string{"sh"}, Env: []string{"APP_EXEC_TEST=from-env"}}))
+	assert.Equal(t, "from-env", k.String("secret"))
+}
+
+func TestExecProtocol_OffByDefault(t *testing.T) {
+	value := `@exec://sh -c 'echo nope'`
+	k, sensitive := solveExec(t, value)
+	assert.Equal(t, value, k.String("secret"))
+	assert.Empty(t, sensitive)
+}
+
+func TestExecProtocol_Allowlist(t *testing.T) {
+	opts := ExecOptions{Allow: []string{"sh"}}
+	for _, value := range []string{
+		`@exec:///bin/sh -c 'echo nope'`,
+		`@exec://bash -c 'echo nope'`,
+	} {
+		k, _ := solveExec(t, value, WithURIExecProtocol(opts))
+		assert.Equal(t, value, k.String("secret"))
+	}
+
+	_, err := runExecCommand(context.Background(), `bash -c 'echo nope'`, opts)
+	assert.ErrorIs(t, err, ErrExecNotAllowed)
+	_, err = runExecCommand(context.Background(), `sh -c 'echo nope'`, ExecOptions{})
+	assert.ErrorIs(t, err, ErrExecNotAllowed)
+
+	out, err := runExecCommand(context.Background(), `/bin/sh -c 'echo ok'`, ExecOptions{Allow: []string{"/bin/sh"}})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out)
+}
+
+func TestExecProtocol_Errors(t *testing.T) {
+	opts := ExecOptions{Allow: []string{"sh"}}
+
+	_, err := runExecCommand(context.Background(), `sh -c 'echo "no such entry" >&2; exit 3'`, opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit status 3")
+	assert.Contains(t, err.Error(), "no such entry")
+
+	opts.Timeout = 50 * time.Millisecond
+	start := time.Now()
+	_, err = runExecCommand(context.Background(), `sh -c 'sleep 5'`, opts)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 3*time.Second)
+
+	_, err = runExecCommand(context.Background(), `sh -c 'yes | head -c 4096'`, ExecOptions{Allow: []string{"sh"}, MaxOutput: 16})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds 16 bytes")
+}
+
+func TestExecProtocol_UsesSolverContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	value := `@exec://sh -c 'echo late'`
+	k, sensitive := solveExec(t, value,
+		WithURIContext(ctx),
+		WithURIExecProtocol(ExecOptions{Allow: []string{"sh"}}))
+	assert.Equal(t, value, k.String("secret"))
+	assert.Empty(t, sensitive)
+}
@@ -1,7 +1,12 @@
 package solvers
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 
 	opts "github.com/goliatone/go-options"
@@ -13,41 +18,156 @@ const (
 	defaultExpressionEnd   = "}}"
 )
 
+var (
+	expressionIdentifierPattern    = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*`)
+	expressionStringLiteralPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|` + "`[^`]*`")
+)
+
 // EvalErrorHandler allows custom handling of expression evaluation errors.
-// Return true to mark the error as handled.
+// Return true to mark the error as handled. Either way the value is left
+// as is unless the handler changes it; only failures passed to OnEvalFail
+// are collected and reported through Err.
 type EvalErrorHandler func(key string, expr string, err error, cfg *koanf.Koanf) bool
 
+// ExpressionEvaluationError captures a single failed expression evaluation.
+type ExpressionEvaluationError struct {
+	Key        string
+	Expression string
+	Err        error
+}
+
+func (e *ExpressionEvaluationError) Error() string {
+	if e == nil {
+		return "expression evaluation failed"
+	}
+	return fmt.Sprintf("expression evaluation failed at %s: %s (%v)", e.Key, e.Expression, e.Err)
+}
+
+func (e *ExpressionEvaluationError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// ExpressionResolutionError aggregates every failed expression from a solve,
+// ordered by key.
+type ExpressionResolutionError struct {
+	Failures []*ExpressionEvaluationError
+}
+
+func (e *ExpressionResolutionError) Error() string {
+	if e == nil || len(e.Failures) == 0 {
+		return "expression evaluation failed"
+	}
+	if len(e.Failures) == 1 {
+		return e.Failures[0].Error()
+	}
+	keys := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		keys = append(keys, failure.Key)
+	}
+	return fmt.Sprintf("%d expressions failed to evaluate: %s", len(e.Failures), strings.Join(keys, ", "))
+}
+
+func (e *ExpressionResolutionError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	out := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		out = append(out, failure)
+	}
+	return out
+}
+
+type ExpressionSolverOption func(*expression)
+
 type expression struct {
 	delimiters *delimiters
 	evaluator  opts.Evaluator
 	onError    EvalErrorHandler
+	embedded   bool
+	failures   []*ExpressionEvaluationError
+}
+
+// collects reports whether onError is OnEvalFail, whose failures are
+// reported through Err.
+func (s *expression) collects() bool {
+	return s.onError != nil && reflect.ValueOf(s.onError).Pointer() == reflect.ValueOf(evalFail).Pointer()
 }
 
 // NewExpressionSolver evaluates expressions wrapped by delimiters (default {{ }})
 // using the default expr evaluator.
 func NewExpressionSolver(start, end string) ConfigSolver {
-	return NewExpressionSolverWithEvaluator(start, end, nil, nil)
+	return NewExpressionSolverWithOptions(start, end)
 }
 
 // NewExpressionSolverWithEvaluator allows custom evaluator and error handler.
 func NewExpressionSolverWithEvaluator(start, end string, eval opts.Evaluator, onErr EvalErrorHandler) ConfigSolver {
-	if eval == nil {
-		eval = opts.NewExprEvaluator()
+	return NewExpressionSolverWithOptions(start, end,
+		WithExpressionEvaluator(eval),
+		WithExpressionErrorHandler(onErr),
+	)
+}
+
+// NewExpressionSolverWithOptions evaluates expressions wrapped by delimiters
+// (default {{ }}) configured through ExpressionSolverOption values.
+func NewExpressionSolverWithOptions(start, end string, options ...ExpressionSolverOption) ConfigSolver {
+	start, end = normalizeExpressionDelimiters(start, end)
+	solver := &expression{
+		delimiters: &delimiters{Start: start, End: end},
+	}
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		opt(solver)
 	}
-	if onErr == nil {
-		onErr = OnEvalLeaveUnchanged()
+	if solver.evaluator == nil {
+		solver.evaluator = opts.NewExprEvaluator()
 	}
-	start, end = normalizeExpressionDelimiters(start, end)
+	if solver.onError == nil {
+		solver.onError = OnEvalLeaveUnchanged()
+	}
+	return solver
+}
 
-	return &expression{
-		delimiters: &delimiters{Start: start, End: end},
-		evaluator:  eval,
-		onError:    onErr,
+// WithExpressionEvaluator sets the evaluator. A nil evaluator keeps the
+// default expr evaluator.
+func WithExpressionEvaluator(eval opts.Evaluator) ExpressionSolverOption {
+	return func(s *expression) {
+		s.evaluator = eval
+	}
+}
+
+// WithExpressionErrorHandler sets the evaluation error handler. A nil handler
+// keeps OnEvalLeaveUnchanged.
+func WithExpressionErrorHandler(onErr EvalErrorHandler) ExpressionSolverOption {
+	return func(s *expression) {
+		s.onError = onErr
 	}
 }
 
+// WithEmbeddedExpressions evaluates every {{ }} segment inside a string and
+// concatenates the string forms of the results, for example
+// "port-{{ 8000 + 80 }}" becomes "port-8080". A value that is a single
+// expression still keeps its typed result.
+func WithEmbeddedExpressions() ExpressionSolverOption {
+	return func(s *expression) {
+		s.embedded = true
+	}
+}
+
+// SolveContext implements ContextSolver.
+func (s *expression) SolveContext(ctx context.Context, config *koanf.Koanf) error {
+	return solveInMemory(ctx, s, config)
+}
+
 // Solve will transform a configuration object.
-func (s expression) Solve(config *koanf.Koanf) *koanf.Koanf {
+func (s *expression) Solve(config *koanf.Koanf) *koanf.Koanf {
+	s.failures = nil
+
 	if config == nil {
 		return config
 	}
@@ -58,28 +178,146 @@ func (s expression) Solve(config *koanf.Koanf) *koanf.Koanf {
 		if !ok {
 			continue
 		}
-		expr, ok := s.fullMatch(v2)
-		if !ok {
-			continue
+		s.keypath(key, v2, config)
+	}
+
+	return config
+}
+
+// Err returns an *ExpressionResolutionError listing every expression that
+// failed during the last solve and was not handled by the error handler.
+func (s *expression) Err() error {
+	if len(s.failures) == 0 {
+		return nil
+	}
+	failures := append([]*ExpressionEvaluationError{}, s.failures...)
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Key < failures[j].Key
+	})
+	return &ExpressionResolutionError{Failures: failures}
+}
+
+func (s *expression) resetErr() {
+	s.failures = nil
+}
+
+// SolveKey evaluates the expression in a single key.
+func (s *expression) SolveKey(key string, config *koanf.Koanf) {
+	if val, ok := config.Get(key).(string); ok {
+		s.keypath(key, val, config)
+	}
+}
+
+// References returns the dotted identifiers used by the expressions in value
+// that exist in config. String literals are ignored.
+func (s *expression) References(key string, value string, config *koanf.Koanf) []string {
+	if config != nil && isAssertKey(key, config.Delim()) {
+		return nil
+	}
+	var exprs []string
+	if expr, ok := s.fullMatch(value); ok {
+		exprs = append(exprs, expr)
+	} else if s.embedded {
+		for offset := 0; ; {
+			token, ok := nextDelimitedToken(value, offset, s.delimiters)
+			if !ok {
+				break
+			}
+			offset = token.end
+			if !token.escaped {
+				exprs = append(exprs, token.path)
+			}
 		}
+	}
 
-		expr = strings.TrimSpace(expr)
-		result, err := s.evaluator.Evaluate(opts.RuleContext{Snapshot: config.Raw()}, expr)
-		if err != nil {
-			if s.onError != nil && s.onError(key, expr, err, config) {
+	var out []string
+	for _, expr := range exprs {
+		for _, ident := range expressionIdentifierPattern.FindAllString(stripStringLiterals(expr), -1) {
+			if config != nil && !config.Exists(ident) && len(leafKeysForPath(ident, config)) == 0 {
 				continue
 			}
+			out = append(out, ident)
+		}
+	}
+	return out
+}
+
+func (s *expression) keypath(key, val string, config *koanf.Koanf) {
+	// $assert entries are evaluated by the assert solver in the scope of
+	// their node.
+	if isAssertKey(key, config.Delim()) {
+		return
+	}
+	if expr, ok := s.fullMatch(val); ok {
+		expr = strings.TrimSpace(expr)
+		result, err := s.evaluate(expr, config)
+		if err != nil {
+			s.fail(key, expr, err, config)
+			return
+		}
+		setValue(config, key, result)
+		return
+	}
+
+	if s.embedded {
+		s.interpolate(key, val, config)
+	}
+}
+
+// interpolate evaluates every expression segment in val and writes back the
+// concatenated string. Escaped segments are kept for UnescapeReferences.
+func (s *expression) interpolate(key, val string, config *koanf.Koanf) {
+	var out strings.Builder
+	out.Grow(len(val))
+	offset := 0
+	changed := false
+
+	for {
+		token, ok := nextDelimitedToken(val, offset, s.delimiters)
+		if !ok {
+			out.WriteString(val[offset:])
+			break
+		}
+
+		out.WriteString(val[offset:token.start])
+		offset = token.end
+		if token.escaped {
+			out.WriteString(val[token.start:token.end])
 			continue
 		}
 
-		config.Set(key, result)
+		expr := strings.TrimSpace(token.path)
+		result, err := s.evaluate(expr, config)
+		if err != nil {
+			s.fail(key, expr, err, config)
+			return
+		}
+		out.WriteString(ToString(result))
+		changed = true
+	}
+
+	if changed {
+		config.Set(key, out.String())
 	}
+}
 
-	return config
+func (s *expression) evaluate(expr string, config *koanf.Koanf) (any, error) {
+	return s.evaluator.Evaluate(opts.RuleContext{Snapshot: config.Raw()}, expr)
 }
 
-func (s expression) fullMatch(input string) (string, bool) {
-	if s.delimiters == nil {
+func (s *expression) fail(key, expr string, err error, config *koanf.Koanf) {
+	if s.onError == nil || s.onError(key, expr, err, config) || !s.collects() {
+		return
+	}
+	s.failures = append(s.failures, &ExpressionEvaluationError{
+		Key:        key,
+		Expression: expr,
+		Err:        err,
+	})
+}
+
+func (s *expression) fullMatch(input string) (string, bool) {
+	if s.delimiters == nil || s.isEscaped(input) {
 		return "", false
 	}
 	if !strings.HasPrefix(input, s.delimiters.Start) || !strings.HasSuffix(input, s.delimiters.End) {
@@ -91,9 +329,86 @@ func (s expression) fullMatch(input string) (string, bool) {
 	if end < start {
 		return "", false
 	}
+	// in embedded mode "{{ a }}-{{ b }}" is two segments, not one expression.
+	if s.embedded && strings.Contains(input[start:end], s.delimiters.End) {
+		return "", false
+	}
 	return input[start:end], true
 }
 
+// UnresolvedReferences reports expressions that are still present in config
+// values, with the evaluation error when one is available.
+func (s *expression) UnresolvedReferences(config *koanf.Koanf) []*UnresolvedReferenceError {
+	keys, values := sortedStringLeaves(config)
+	var out []*UnresolvedReferenceError
+
+	for _, key := range keys {
+		value := values[key]
+		if expr, ok := s.fullMatch(value); ok {
+			ref := &UnresolvedReferenceError{
+				Path:   key,
+				Token:  value,
+				Reason: UnresolvedFailed,
+			}
+			if _, err := s.evaluate(strings.TrimSpace(expr), config); err != nil {
+				ref.Detail = err.Error()
+			}
+			out = append(out, ref)
+			continue
+		}
+		if s.hasEmbeddedExpression(value) {
+			detail := "expression is not the entire value"
+			if s.embedded {
+				detail = "embedded expression did not evaluate"
+			}
+			out = append(out, &UnresolvedReferenceError{
+				Path:   key,
+				Token:  value,
+				Reason: UnresolvedFailed,
+				Detail: detail,
+			})
+		}
+	}
+
+	return out
+}
+
+// UnescapeReferences turns escaped expressions (\{{ expr }}) into literals ({{ expr }}).
+func (s *expression) UnescapeReferences(config *koanf.Koanf) {
+	if s.delimiters == nil {
+		return
+	}
+	escape := escapedDelimiter(s.delimiters.Start)
+	if escape == "" {
+		return
+	}
+	unescapeStrings(config, func(value string) string {
+		return strings.ReplaceAll(value, escape, s.delimiters.Start)
+	})
+}
+
+func (s *expression) isEscaped(input string) bool {
+	escape := escapedDelimiter(s.delimiters.Start)
+	return escape != "" && strings.HasPrefix(input, escape)
+}
+
+func (s *expression) hasEmbeddedExpression(input string) bool {
+	for offset := 0; ; {
+		token, ok := nextDelimitedToken(input, offset, s.delimiters)
+		if !ok {
+			return false
+		}
+		if !token.escaped {
+			return true
+		}
+		offset = token.end
+	}
+}
+
+func stripStringLiterals(expr string) string {
+	return expressionStringLiteralPattern.ReplaceAllString(expr, `""`)
+}
+
 func normalizeExpressionDelimiters(start, end string) (string, string) {
 	if start == "" {
 		start = defaultExpressionStart
@@ -104,6 +419,15 @@ func normalizeExpressionDelimiters(start, end string) (string, string) {
 	return start, end
 }
 
+func (s *expression) clone() *expression {
+	return &expression{
+		delimiters: &delimiters{Start: s.delimiters.Start, End: s.delimiters.End},
+		evaluator:  s.evaluator,
+		onError:    s.onError,
+		embedded:   s.embedded,
+	}
+}
+
 // ReplaceExpressionSolverEvaluator returns a copy of solver with eval applied
 // when solver is an expression solver. Non-expression solvers are returned
 // unchanged with ok=false.
@@ -112,12 +436,22 @@ func ReplaceExpressionSolverEvaluator(solver ConfigSolver, eval opts.Evaluator)
 	if !ok || eval == nil {
 		return solver, false
 	}
-	return NewExpressionSolverWithEvaluator(
-		exprSolver.delimiters.Start,
-		exprSolver.delimiters.End,
-		eval,
-		exprSolver.onError,
-	), true
+	clone := exprSolver.clone()
+	clone.evaluator = eval
+	return clone, true
+}
+
+// ReplaceExpressionSolverErrorHandler returns a copy of solver with onErr
+// applied when solver is an expression solver. Non-expression solvers are
+// returned unchanged with ok=false.
+func ReplaceExpressionSolverErrorHandler(solver ConfigSolver, onErr EvalErrorHandler) (updated ConfigSolver, ok bool) {
+	exprSolver, ok := solver.(*expression)
+	if !ok || onErr == nil {
+		return solver, false
+	}
+	clone := exprSolver.clone()
+	clone.onError = onErr
+	return clone, true
 }
 
 // OnEvalLogAndPanic logs the error then panics.
@@ -132,6 +466,17 @@ func OnEvalLogAndPanic(logger *log.Logger) EvalErrorHandler {
 	}
 }
 
+// OnEvalFail keeps the original value and collects the error, so the solver
+// reports every failing key through Err and Load fails with
+// CONFIG_EXPRESSION_FAILED.
+func OnEvalFail() EvalErrorHandler {
+	return evalFail
+}
+
+func evalFail(_ string, _ string, _ error, _ *koanf.Koanf) bool {
+	return false
+}
+
 // OnEvalLeaveUnchanged keeps the original value.
 func OnEvalLeaveUnchanged() EvalErrorHandler {
 	return func(_ string, _ string, _ error, _ *koanf.Koanf) bool {
@@ -104,3 +104,156 @@ func TestReplaceExpressionSolverEvaluator(t *testing.T) {
 	replaced.Solve(k)
 	assert.Equal(t, "f9d293c", k.Get("app.hash"))
 }
+
+func TestExpressionSolver_OnEvalFailCollectsEveryFailure(t *testing.T) {
+	defaultValues := map[string]any{
+		"bad":    "{{ }}",
+		"broken": "{{ missing_fn() }}",
+		"ok":     "{{ 1 + 1 }}",
+	}
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(defaultValues, "."), nil)
+
+	solver := NewExpressionSolverWithEvaluator("{{", "}}", nil, OnEvalFail())
+	out := solver.Solve(k)
+
+	assert.EqualValues(t, 2, out.Get("ok"))
+	assert.Equal(t, "{{ }}", out.Get("bad"))
+
+	err := solver.(ErrorReporter).Err()
+	var exprErr *ExpressionResolutionError
+	if assert.True(t, errors.As(err, &exprErr)) {
+		assert.Len(t, exprErr.Failures, 2)
+		assert.Equal(t, "bad", exprErr.Failures[0].Key)
+		assert.Equal(t, "broken", exprErr.Failures[1].Key)
+		assert.Equal(t, "missing_fn()", exprErr.Failures[1].Expression)
+		assert.Error(t, exprErr.Failures[1].Err)
+	}
+
+	var evalErr *ExpressionEvaluationError
+	assert.True(t, errors.As(err, &evalErr))
+
+	k.Set("bad", "{{ 2 }}")
+	k.Set("broken", "{{ 3 }}")
+	solver.Solve(k)
+	assert.NoError(t, solver.(ErrorReporter).Err())
+}
+
+func TestExpressionSolver_DefaultHandlerReportsNoError(t *testing.T) {
+	k := koanf.New(".")
+	k.Load(confmap.Provider(map[string]any{"bad": "{{ }}"}, "."), nil)
+
+	solver := NewExpressionSolver("{{", "}}")
+	solver.Solve(k)
+
+	assert.NoError(t, solver.(ErrorReporter).Err())
+}
+
+func TestExpressionSolver_CustomHandlerReturningFalseReportsNoError(t *testing.T) {
+	k := koanf.New(".")
+	k.Load(confmap.Provider(map[string]any{"bad": "{{ }}"}, "."), nil)
+
+	var logged []string
+	solver := NewExpressionSolverWithEvaluator("{{", "}}", nil, func(key, _ string, _ error, _ *koanf.Koanf) bool {
+		logged = append(logged, key)
+		return false
+	})
+	solver.Solve(k)
+
+	assert.Equal(t, []string{"bad"}, logged)
+	assert.Equal(t, "{{ }}", k.Get("bad"))
+	assert.NoError(t, solver.(ErrorReporter).Err())
+}
+
+func TestReplaceExpressionSolverErrorHandler(t *testing.T) {
+	original := NewExpressionSolver("{{", "}}")
+	replaced, ok := ReplaceExpressionSolverErrorHandler(original, OnEvalFail())
+	assert.True(t, ok)
+
+	_, ok = ReplaceExpressionSolverErrorHandler(NewVariablesSolver("${", "}"), OnEvalFail())
+	assert.False(t, ok)
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(map[string]any{"bad": "{{ }}"}, "."), nil)
+	replaced.Solve(k)
+
+	assert.Error(t, replaced.(ErrorReporter).Err())
+}
+
+func TestExpressionSolver_EmbeddedExpressions(t *testing.T) {
+	defaultValues := map[string]any{
+		"app": map[string]any{
+			"name": "api",
+			"port": 8000,
+		},
+		"embedded": "prefix {{ 1 + 1 }}",
+		"multi":    `{{ app.name }}:{{ app.port + 80 }}`,
+		"typed":    "{{ app.port + 80 }}",
+		"escaped":  `literal \{{ braces }} and {{ 2 * 2 }}`,
+		"plain":    "no expressions",
+	}
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(defaultValues, "."), nil)
+
+	solver := NewExpressionSolverWithOptions("{{", "}}", WithEmbeddedExpressions())
+	out := solver.Solve(k)
+
+	assert.Equal(t, "prefix 2", out.Get("embedded"))
+	assert.Equal(t, "api:8080", out.Get("multi"))
+	assert.EqualValues(t, 8080, out.Get("typed"))
+	assert.Equal(t, `literal \{{ braces }} and 4`, out.Get("escaped"))
+	assert.Equal(t, "no expressions", out.Get("plain"))
+
+	solver.(ReferenceScanner).UnescapeReferences(k)
+	assert.Equal(t, "literal {{ braces }} and 4", out.Get("escaped"))
+}
+
+func TestExpressionSolver_EmbeddedExpressionFailure(t *testing.T) {
+	defaultValues := map[string]any{
+		"value": "a-{{ 1 + 1 }}-{{ missing_fn() }}",
+	}
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(defaultValues, "."), nil)
+
+	solver := NewExpressionSolverWithOptions("{{", "}}",
+		WithEmbeddedExpressions(),
+		WithExpressionErrorHandler(OnEvalFail()),
+	)
+	solver.Solve(k)
+
+	assert.Equal(t, "a-{{ 1 + 1 }}-{{ missing_fn() }}", k.Get("value"))
+
+	var exprErr *ExpressionResolutionError
+	if assert.True(t, errors.As(solver.(ErrorReporter).Err(), &exprErr)) {
+		assert.Equal(t, "missing_fn()", exprErr.Failures[0].Expression)
+	}
+}
+
+func TestReplaceExpressionSolverEvaluator_KeepsEmbeddedMode(t *testing.T) {
+	original := NewExpressionSolverWithOptions("{{", "}}", WithEmbeddedExpressions())
+	replaced, ok := ReplaceExpressionSolverEvaluator(original, opts.NewExprEvaluator())
+	assert.True(t, ok)
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(map[string]any{"value": "v{{ 1 + 1 }}"}, "."), nil)
+	replaced.Solve(k)
+
+	assert.Equal(t, "v2", k.Get("value"))
+}
+
+func TestExpressionSolver_ReplacesTypeUnderStrictMerge(t *testing.T) {
+	k := koanf.NewWithConf(koanf.Conf{Delim: ".", StrictMerge: true})
+	k.Load(confmap.Provider(map[string]any{
+		"sum":   "{{ 1 + 2 }}",
+		"debug": "{{ true }}",
+	}, "."), nil)
+
+	solver := NewExpressionSolver("{{", "}}")
+	out := solver.Solve(k)
+
+	assert.EqualValues(t, 3, out.Get("sum"))
+	assert.Equal(t, true, out.Get("debug"))
+}
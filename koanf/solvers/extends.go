@@ -0,0 +1,356 @@
+package solvers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+const defaultExtendsKey = "$extends"
+
+// MergeFunc merges src into dst, e.g. config.MergeWithBooleanPrecedence.
+type MergeFunc func(src, dst map[string]any) error
+
+// ExtendsResolutionError captures details about an unresolved $extends
+// directive.
+type ExtendsResolutionError struct {
+	NodePath string
+	Target   string
+	Chain    []string
+	Reason   string
+	Err      error
+}
+
+func (e *ExtendsResolutionError) Error() string {
+	if e == nil {
+		return "extends resolution failed"
+	}
+	base := strings.TrimSpace(e.Reason)
+	if base == "" {
+		base = "extends resolution failed"
+	}
+	if strings.TrimSpace(e.NodePath) == "" {
+		return base
+	}
+	return fmt.Sprintf("%s at %s", base, e.NodePath)
+}
+
+func (e *ExtendsResolutionError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+type extendsSolver struct {
+	key   string
+	merge MergeFunc
+	err   error
+}
+
+type extendsState struct {
+	root     map[string]any
+	delim    string
+	resolved map[string]map[string]any
+	stack    []string
+}
+
+// NewExtendsSolver resolves object inheritance declared inside object nodes,
+// for example:
+//
+//	{
+//	  "services": {
+//	    "base": {"port": 8080, "log": {"level": "info"}},
+//	    "api": {"$extends": "services.base", "log": {"level": "debug"}}
+//	  }
+//	}
+//
+// The referenced node, itself resolved first so chains work, is deep merged
+// underneath the node's own keys with merge. A list of targets is merged in
+// order, later targets winning. A nil merge replaces values and merges nested
+// objects.
+func NewExtendsSolver(key string, merge MergeFunc) ConfigSolver {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		key = defaultExtendsKey
+	}
+	if merge == nil {
+		merge = mergeOverride
+	}
+	return &extendsSolver{
+		key:   key,
+		merge: merge,
+	}
+}
+
+func (s *extendsSolver) Err() error {
+	return s.err
+}
+
+// SolveContext implements ContextSolver.
+func (s *extendsSolver) SolveContext(ctx context.Context, config *koanf.Koanf) error {
+	return solveInMemory(ctx, s, config)
+}
+
+func (s *extendsSolver) Solve(config *koanf.Koanf) *koanf.Koanf {
+	s.err = nil
+
+	if config == nil {
+		return config
+	}
+
+	root := config.Raw()
+	if !s.hasExtends(root) {
+		return config
+	}
+
+	state := &extendsState{
+		root:     root,
+		delim:    config.Delim(),
+		resolved: map[string]map[string]any{},
+	}
+	resolved, err := s.resolveNode(state, "", root)
+	if err != nil {
+		s.err = err
+		return config
+	}
+
+	for key := range root {
+		config.Delete(key)
+	}
+	for key, value := range resolved {
+		config.Set(key, value)
+	}
+
+	return config
+}
+
+// resolveNode returns a copy of node with every $extends directive in it and
+// below it applied. Results are memoized by path so shared bases resolve once.
+func (s *extendsSolver) resolveNode(state *extendsState, path string, node map[string]any) (map[string]any, error) {
+	if resolved, ok := state.resolved[path]; ok {
+		return resolved, nil
+	}
+	for i, pending := range state.stack {
+		if pending == path {
+			chain := append(append([]string{}, state.stack[i:]...), path)
+			return nil, &ExtendsResolutionError{
+				NodePath: normalizePath(state.stack[len(state.stack)-1]),
+				Target:   path,
+				Chain:    chain,
+				Reason:   fmt.Sprintf("extends cycle: %s", strings.Join(chain, " -> ")),
+				Err:      ErrCyclicReference,
+			}
+		}
+	}
+
+	state.stack = append(state.stack, path)
+	defer func() {
+		state.stack = state.stack[:len(state.stack)-1]
+	}()
+
+	own := make(map[string]any, len(node))
+	for _, key := range sortedKeys(node) {
+		if key == s.key {
+			continue
+		}
+		resolved, err := s.resolveAny(state, joinKey(path, key, state.delim), node[key])
+		if err != nil {
+			return nil, err
+		}
+		own[key] = resolved
+	}
+
+	raw, hasExtends := node[s.key]
+	if !hasExtends {
+		state.resolved[path] = own
+		return own, nil
+	}
+
+	targets, err := s.targets(raw, path)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]any{}
+	for _, target := range targets {
+		base, err := s.resolveTarget(state, path, target)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.merge(copyMap(base), out); err != nil {
+			return nil, s.mergeError(path, target, err)
+		}
+	}
+	if err := s.merge(copyMap(own), out); err != nil {
+		return nil, s.mergeError(path, "", err)
+	}
+
+	state.resolved[path] = out
+	return out, nil
+}
+
+func (s *extendsSolver) resolveAny(state *extendsState, path string, value any) (any, error) {
+	switch current := value.(type) {
+	case map[string]any:
+		return s.resolveNode(state, path, current)
+	case []any:
+		out := make([]any, len(current))
+		for i, child := range current {
+			resolved, err := s.resolveAny(state, joinKey(path, strconv.Itoa(i), state.delim), child)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+func (s *extendsSolver) resolveTarget(state *extendsState, path, target string) (map[string]any, error) {
+	value, found := lookupNode(state.root, target, state.delim)
+	if !found {
+		return nil, &ExtendsResolutionError{
+			NodePath: normalizePath(path),
+			Target:   target,
+			Reason:   fmt.Sprintf("extends target %q not found", target),
+		}
+	}
+	node, ok := value.(map[string]any)
+	if !ok {
+		return nil, &ExtendsResolutionError{
+			NodePath: normalizePath(path),
+			Target:   target,
+			Reason:   fmt.Sprintf("extends target %q must be an object, got %T", target, value),
+		}
+	}
+	return s.resolveNode(state, target, node)
+}
+
+func (s *extendsSolver) targets(raw any, path string) ([]string, error) {
+	var targets []string
+	switch v := raw.(type) {
+	case string:
+		targets = []string{v}
+	case []any:
+		for _, item := range v {
+			target, ok := item.(string)
+			if !ok {
+				targets = nil
+				break
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	out := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if target = strings.TrimSpace(target); target != "" {
+			out = append(out, target)
+		}
+	}
+	if len(out) == 0 {
+		return nil, &ExtendsResolutionError{
+			NodePath: normalizePath(path),
+			Reason:   fmt.Sprintf("%s must be a path or a list of paths", s.key),
+		}
+	}
+	return out, nil
+}
+
+func (s *extendsSolver) mergeError(path, target string, err error) error {
+	return &ExtendsResolutionError{
+		NodePath: normalizePath(path),
+		Target:   target,
+		Reason:   fmt.Sprintf("failed to merge extends target: %v", err),
+		Err:      err,
+	}
+}
+
+func (s *extendsSolver) hasExtends(value any) bool {
+	switch current := value.(type) {
+	case map[string]any:
+		if _, ok := current[s.key]; ok {
+			return true
+		}
+		for _, child := range current {
+			if s.hasExtends(child) {
+				return true
+			}
+		}
+	case []any:
+		for _, child := range current {
+			if s.hasExtends(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookupNode walks root along a delimited path.
+func lookupNode(root map[string]any, path, delim string) (any, bool) {
+	var current any = root
+	for _, part := range strings.Split(path, delim) {
+		node, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = node[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// copyMap deep copies maps and lists so merges never alias resolved nodes.
+func copyMap(src map[string]any) map[string]any {
+	out := make(map[string]any, len(src))
+	for key, value := range src {
+		out[key] = copyValue(value)
+	}
+	return out
+}
+
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return copyMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = copyValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// mergeOverride merges src into dst, merging nested objects and replacing
+// every other value.
+func mergeOverride(src, dst map[string]any) error {
+	keys := make([]string, 0, len(src))
+	for key := range src {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		srcMap, srcIsMap := src[key].(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			if err := mergeOverride(srcMap, dstMap); err != nil {
+				return err
+			}
+			continue
+		}
+		dst[key] = src[key]
+	}
+	return nil
+}
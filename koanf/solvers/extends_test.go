@@ -0,0 +1,173 @@
+package solvers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtendsSolver_ResolvesChains(t *testing.T) {
+	k := koanf.New(".")
+	_ = k.Load(confmap.Provider(map[string]any{
+		"services": map[string]any{
+			"base": map[string]any{
+				"port": 8080,
+				"log": map[string]any{
+					"level":  "info",
+					"format": "json",
+				},
+				"tags": []any{"base"},
+			},
+			"api": map[string]any{
+				"$extends": "services.base",
+				"log": map[string]any{
+					"level": "debug",
+				},
+			},
+			"worker": map[string]any{
+				"$extends": "services.api",
+				"port":     9090,
+			},
+		},
+	}, "."), nil)
+
+	solver := NewExtendsSolver("$extends", nil)
+	out := solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, 8080, out.Get("services.api.port"))
+	assert.Equal(t, "debug", out.Get("services.api.log.level"))
+	assert.Equal(t, "json", out.Get("services.api.log.format"))
+	assert.False(t, out.Exists("services.api.$extends"))
+
+	assert.Equal(t, 9090, out.Get("services.worker.port"))
+	assert.Equal(t, "debug", out.Get("services.worker.log.level"))
+	assert.Equal(t, []any{"base"}, out.Get("services.worker.tags"))
+	assert.False(t, out.Exists("services.worker.$extends"))
+
+	assert.Equal(t, "info", out.Get("services.base.log.level"))
+}
+
+func TestExtendsSolver_MergesTargetListInOrder(t *testing.T) {
+	k := koanf.New(".")
+	_ = k.Load(confmap.Provider(map[string]any{
+		"defaults": map[string]any{"timeout": "5s", "retries": 1},
+		"fast":     map[string]any{"timeout": "1s"},
+		"client": map[string]any{
+			"$extends": []any{"defaults", "fast"},
+			"name":     "billing",
+		},
+	}, "."), nil)
+
+	solver := NewExtendsSolver("", nil)
+	out := solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "1s", out.Get("client.timeout"))
+	assert.Equal(t, 1, out.Get("client.retries"))
+	assert.Equal(t, "billing", out.Get("client.name"))
+}
+
+func TestExtendsSolver_UsesMergeFunc(t *testing.T) {
+	k := koanf.New(".")
+	_ = k.Load(confmap.Provider(map[string]any{
+		"base": map[string]any{"host": "localhost"},
+		"api": map[string]any{
+			"$extends": "base",
+			"host":     "",
+		},
+	}, "."), nil)
+
+	keepNonEmpty := func(src, dst map[string]any) error {
+		for key, value := range src {
+			if value == "" {
+				continue
+			}
+			dst[key] = value
+		}
+		return nil
+	}
+
+	solver := NewExtendsSolver("$extends", keepNonEmpty)
+	out := solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "localhost", out.Get("api.host"))
+}
+
+func TestExtendsSolver_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]any
+		nodePath string
+		target   string
+		cycle    bool
+	}{
+		{
+			name: "missing target",
+			values: map[string]any{
+				"api": map[string]any{"$extends": "services.base"},
+			},
+			nodePath: "api",
+			target:   "services.base",
+		},
+		{
+			name: "scalar target",
+			values: map[string]any{
+				"base": "value",
+				"api":  map[string]any{"$extends": "base"},
+			},
+			nodePath: "api",
+			target:   "base",
+		},
+		{
+			name: "invalid directive",
+			values: map[string]any{
+				"api": map[string]any{"$extends": 42},
+			},
+			nodePath: "api",
+		},
+		{
+			name: "cycle",
+			values: map[string]any{
+				"a": map[string]any{"$extends": "b"},
+				"b": map[string]any{"$extends": "c"},
+				"c": map[string]any{"$extends": "a"},
+			},
+			nodePath: "c",
+			target:   "a",
+			cycle:    true,
+		},
+		{
+			name: "ancestor",
+			values: map[string]any{
+				"services": map[string]any{
+					"api": map[string]any{"$extends": "services"},
+				},
+			},
+			nodePath: "services.api",
+			target:   "services",
+			cycle:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := koanf.New(".")
+			_ = k.Load(confmap.Provider(tt.values, "."), nil)
+
+			solver := NewExtendsSolver("$extends", nil)
+			solver.Solve(k)
+
+			var extendsErr *ExtendsResolutionError
+			require.True(t, errors.As(solver.(ErrorReporter).Err(), &extendsErr))
+			assert.Equal(t, tt.nodePath, extendsErr.NodePath)
+			assert.Equal(t, tt.target, extendsErr.Target)
+			assert.Equal(t, tt.cycle, errors.Is(extendsErr, ErrCyclicReference))
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package solvers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// maxKeyResolveIterations caps how many times the key solver chain is
+// re-applied to a single key whose value keeps producing new references.
+const maxKeyResolveIterations = 16
+
+// KeySolver is an optional extension for solvers that can resolve a single
+// key and report which config paths its value references. The graph solver
+// uses it to resolve keys in dependency order.
+type KeySolver interface {
+	SolveKey(key string, config *koanf.Koanf)
+	References(key, value string, config *koanf.Koanf) []string
+}
+
+// ContextKeySolver is the context aware variant of KeySolver.SolveKey, used
+// by the graph solver under SolveContext.
+type ContextKeySolver interface {
+	SolveKeyContext(ctx context.Context, key string, config *koanf.Koanf) error
+}
+
+// ReferenceCycleError reports a reference cycle found while ordering keys.
+type ReferenceCycleError struct {
+	Cycle []string
+}
+
+func (e *ReferenceCycleError) Error() string {
+	if e == nil || len(e.Cycle) == 0 {
+		return ErrCyclicReference.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrCyclicReference, strings.Join(e.Cycle, " -> "))
+}
+
+func (e *ReferenceCycleError) Unwrap() error {
+	return ErrCyclicReference
+}
+
+type graphSolver struct {
+	keySolvers  []KeySolver
+	treeSolvers []ConfigSolver
+	err         error
+	// trace and pass are set by TraceSolve.
+	trace TraceFunc
+	pass  int
+}
+
+type graphState struct {
+	ctx    context.Context
+	config *koanf.Koanf
+	status map[string]graphStatus
+}
+
+type graphStatus int
+
+const (
+	graphPending graphStatus = iota
+	graphVisiting
+	graphDone
+)
+
+// NewGraphSolver resolves keys in dependency order in a single pass instead
+// of repeating every solver over every key.
+//
+// Solvers implementing KeySolver (variables, URI, expression) are applied per
+// key after the keys it references are resolved. Other solvers (select) run
+// once over the whole tree afterwards, followed by one more graph sweep for
+// references into nodes they produced. Reference cycles fail with a
+// *ReferenceCycleError naming the full cycle path.
+func NewGraphSolver(slvrs ...ConfigSolver) ConfigSolver {
+	g := &graphSolver{}
+	for _, solver := range slvrs {
+		if solver == nil {
+			continue
+		}
+		if keySolver, ok := solver.(KeySolver); ok {
+			g.keySolvers = append(g.keySolvers, keySolver)
+			continue
+		}
+		g.treeSolvers = append(g.treeSolvers, solver)
+	}
+	return g
+}
+
+func (g *graphSolver) Err() error {
+	return g.err
+}
+
+func (g *graphSolver) Solve(config *koanf.Koanf) *koanf.Koanf {
+	g.err = g.solve(context.Background(), config)
+	return config
+}
+
+// SolveContext implements ContextSolver. The context is checked before each
+// key and passed to key solvers implementing ContextKeySolver.
+func (g *graphSolver) SolveContext(ctx context.Context, config *koanf.Koanf) error {
+	g.err = g.solve(ctx, config)
+	return g.err
+}
+
+func (g *graphSolver) solve(ctx context.Context, config *koanf.Koanf) error {
+	if config == nil {
+		return nil
+	}
+
+	if err := g.resolveAll(ctx, config); err != nil {
+		return err
+	}
+
+	if len(g.treeSolvers) == 0 {
+		return g.keySolverErr()
+	}
+
+	for _, solver := range g.treeSolvers {
+		if err := TraceSolve(ctx, solver, config, g.pass, g.trace); err != nil {
+			return err
+		}
+	}
+
+	if err := g.resolveAll(ctx, config); err != nil {
+		return err
+	}
+	return g.keySolverErr()
+}
+
+// errorResetter is implemented by key solvers that accumulate errors across
+// SolveKey calls.
+type errorResetter interface {
+	resetErr()
+}
+
+// sweepSolver is implemented by key solvers that share state between the
+// keys of one sweep, such as the included documents of the URI solver.
+type sweepSolver interface {
+	beginSweep(ctx context.Context)
+	endSweep()
+}
+
+func (g *graphSolver) resolveAll(ctx context.Context, config *koanf.Koanf) error {
+	for _, solver := range g.keySolvers {
+		if resetter, ok := solver.(errorResetter); ok {
+			resetter.resetErr()
+		}
+		if sweeper, ok := solver.(sweepSolver); ok {
+			sweeper.beginSweep(ctx)
+			defer sweeper.endSweep()
+		}
+	}
+
+	state := &graphState{
+		ctx:    ctx,
+		config: config,
+		status: map[string]graphStatus{},
+	}
+
+	keys := config.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := g.resolve(state, key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keySolverErr returns the first error reported by a key solver during the
+// last sweep.
+func (g *graphSolver) keySolverErr() error {
+	for _, solver := range g.keySolvers {
+		if reporter, ok := solver.(ErrorReporter); ok {
+			if err := reporter.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *graphSolver) resolve(state *graphState, key string, stack []string) error {
+	switch state.status[key] {
+	case graphDone:
+		return nil
+	case graphVisiting:
+		return &ReferenceCycleError{Cycle: cyclePath(stack, key)}
+	}
+	if err := state.ctx.Err(); err != nil {
+		return err
+	}
+
+	state.status[key] = graphVisiting
+	stack = append(stack, key)
+
+	for i := 0; i < maxKeyResolveIterations; i++ {
+		value, ok := state.config.Get(key).(string)
+		if !ok {
+			break
+		}
+
+		for _, dep := range g.dependencies(key, value, state.config) {
+			if err := g.resolve(state, dep, stack); err != nil {
+				return err
+			}
+		}
+
+		for _, solver := range g.keySolvers {
+			if err := g.solveKey(state, solver, key); err != nil {
+				return err
+			}
+		}
+
+		next := state.config.Get(key)
+		if nextStr, ok := next.(string); ok && nextStr != value {
+			continue
+		}
+		if _, ok := next.(map[string]any); ok {
+			for _, child := range state.config.Cut(key).Keys() {
+				if err := g.resolve(state, joinKey(key, child, state.config.Delim()), stack); err != nil {
+					return err
+				}
+			}
+		}
+		break
+	}
+
+	state.status[key] = graphDone
+	return nil
+}
+
+func (g *graphSolver) solveKey(state *graphState, solver KeySolver, key string) error {
+	var before any
+	var start time.Time
+	if g.trace != nil {
+		before = state.config.Get(key)
+		start = time.Now()
+	}
+
+	if contextSolver, ok := solver.(ContextKeySolver); ok {
+		if err := contextSolver.SolveKeyContext(state.ctx, key, state.config); err != nil {
+			return err
+		}
+	} else {
+		solver.SolveKey(key, state.config)
+	}
+
+	if g.trace != nil {
+		after := state.config.Get(key)
+		if !reflect.DeepEqual(before, after) {
+			g.trace(TraceEvent{
+				Pass:     g.pass,
+				Solver:   SolverName(solver.(ConfigSolver)),
+				Key:      key,
+				Before:   before,
+				After:    after,
+				Duration: time.Since(start),
+			})
+		}
+	}
+	return nil
+}
+
+// dependencies maps the paths referenced by value to the leaf keys that must
+// be resolved first.
+func (g *graphSolver) dependencies(key, value string, config *koanf.Koanf) []string {
+	seen := map[string]struct{}{}
+	var out []string
+	add := func(dep string) {
+		if _, ok := seen[dep]; ok {
+			return
+		}
+		seen[dep] = struct{}{}
+		out = append(out, dep)
+	}
+
+	for _, solver := range g.keySolvers {
+		for _, path := range solver.References(key, value, config) {
+			for _, dep := range leafKeysForPath(path, config) {
+				// a key inside a referenced object is not a self reference
+				if dep == key && path != key {
+					continue
+				}
+				add(dep)
+			}
+		}
+	}
+	return out
+}
+
+// leafKeysForPath returns the leaf keys a reference to path depends on: the
+// key itself, every leaf below it when it is an object, or the closest leaf
+// ancestor that may expand into it (for example an include).
+func leafKeysForPath(path string, config *koanf.Koanf) []string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil
+	}
+	delim := config.Delim()
+
+	if config.Exists(path) {
+		if _, ok := config.Get(path).(map[string]any); !ok {
+			return []string{path}
+		}
+		children := config.Cut(path).Keys()
+		out := make([]string, 0, len(children))
+		for _, child := range children {
+			out = append(out, joinKey(path, child, delim))
+		}
+		return out
+	}
+
+	parts := strings.Split(path, delim)
+	for i := len(parts) - 1; i > 0; i-- {
+		prefix := strings.Join(parts[:i], delim)
+		if !config.Exists(prefix) {
+			continue
+		}
+		if _, ok := config.Get(prefix).(map[string]any); ok {
+			return nil
+		}
+		return []string{prefix}
+	}
+	return nil
+}
+
+func cyclePath(stack []string, key string) []string {
+	for i, entry := range stack {
+		if entry == key {
+			cycle := append([]string{}, stack[i:]...)
+			return append(cycle, key)
+		}
+	}
+	return append(append([]string{}, stack...), key)
+}
+
+func joinKey(base, key, delim string) string {
+	if base == "" {
+		return key
+	}
+	return base + delim + key
+}
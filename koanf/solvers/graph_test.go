@@ -0,0 +1,168 @@
+package solvers
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphSolver_resolvesDeepChainsInOnePass(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"a": "${b}",
+		"b": "${c}-b",
+		"c": "${d}-c",
+		"d": "{{ 1 + 1 }}",
+		"e": `{{ "$" + "{a}" }}`,
+	})
+
+	solver := NewGraphSolver(
+		NewVariablesSolver("${", "}"),
+		NewURISolver("@", "://"),
+		NewExpressionSolver("{{", "}}"),
+	)
+	solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "2-c-b", k.Get("a"))
+	assert.Equal(t, "2-c-b", k.Get("e"))
+}
+
+func TestGraphSolver_expressionReferences(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"pool": map[string]any{
+			"min":   "${defaults.min}",
+			"max":   "{{ pool.min * 4 }}",
+			"valid": "{{ pool.max >= pool.min }}",
+			"size":  "{{ len(pool) }}",
+		},
+		"defaults": map[string]any{
+			"min": 2,
+		},
+	})
+
+	solver := NewGraphSolver(
+		NewVariablesSolver("${", "}"),
+		NewExpressionSolver("{{", "}}"),
+	)
+	solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, 2, k.Get("pool.min"))
+	assert.Equal(t, 8, k.Get("pool.max"))
+	assert.Equal(t, true, k.Get("pool.valid"))
+}
+
+func TestGraphSolver_includeThenReference(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"version": "${release.version}",
+		"release": "@include://file://release.json",
+	})
+
+	testFS := fstest.MapFS{
+		"release.json": &fstest.MapFile{Data: []byte(`{"version": "${tag}"}`)},
+	}
+	k.Set("tag", "1.2.3")
+
+	solver := NewGraphSolver(
+		NewVariablesSolver("${", "}"),
+		NewURISolverWithFS("@", "://", testFS),
+	)
+	solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "1.2.3", k.Get("release.version"))
+	assert.Equal(t, "1.2.3", k.Get("version"))
+}
+
+func TestGraphSolver_sharesURIStateAcrossKeys(t *testing.T) {
+	store := &mockStorager{contentPath: map[string]string{"secret": "s3cr3t"}}
+	uriSolver := NewURISolverWithOptions("@", "://")
+	uriSolver.(*uris).newStorager = func(string) (storageReader, error) { return store, nil }
+	solver := NewGraphSolver(NewVariablesSolver("${", "}"), uriSolver)
+
+	load := func() {
+		k := loadUnresolvedTestConfig(t, map[string]any{
+			"db":    map[string]any{"password": "@storage://mock://x#secret"},
+			"cache": map[string]any{"password": "@storage://mock://x#secret"},
+		})
+		solver.Solve(k)
+		require.NoError(t, solver.(ErrorReporter).Err())
+		assert.Equal(t, "s3cr3t", k.Get("db.password"))
+		assert.Equal(t, "s3cr3t", k.Get("cache.password"))
+	}
+
+	load()
+	assert.Equal(t, 1, store.reads, "keys of one sweep share the resolve state")
+
+	load()
+	assert.Equal(t, 2, store.reads, "the state does not outlive the sweep")
+}
+
+func TestGraphSolver_selectAfterKeys(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"app": map[string]any{
+			"env": `{{ "development" }}`,
+		},
+		"limit": "${reminders.max_reminders}",
+		"reminders": map[string]any{
+			"$select":  "${app.env}",
+			"$default": "production",
+			"development": map[string]any{
+				"max_reminders": 2,
+			},
+			"production": map[string]any{
+				"max_reminders": 5,
+			},
+		},
+	})
+
+	solver := NewGraphSolver(
+		NewVariablesSolver("${", "}"),
+		NewExpressionSolver("{{", "}}"),
+		NewSelectSolver("$select", "$default"),
+	)
+	solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, 2, k.Get("reminders.max_reminders"))
+	assert.Equal(t, 2, k.Get("limit"))
+}
+
+func TestGraphSolver_cycleNamesFullPath(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"a": "${b}",
+		"b": "x-${c}",
+		"c": "{{ a }}",
+	})
+
+	solver := NewGraphSolver(
+		NewVariablesSolver("${", "}"),
+		NewExpressionSolver("{{", "}}"),
+	)
+	solver.Solve(k)
+
+	err := solver.(ErrorReporter).Err()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrCyclicReference))
+
+	var cycleErr *ReferenceCycleError
+	require.True(t, errors.As(err, &cycleErr))
+	assert.Equal(t, []string{"a", "b", "c", "a"}, cycleErr.Cycle)
+	assert.Equal(t, "cyclic reference: a -> b -> c -> a", err.Error())
+}
+
+func TestGraphSolver_selfReference(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"a": "${a}",
+	})
+
+	solver := NewGraphSolver(NewVariablesSolver("${", "}"))
+	solver.Solve(k)
+
+	var cycleErr *ReferenceCycleError
+	require.True(t, errors.As(solver.(ErrorReporter).Err(), &cycleErr))
+	assert.Equal(t, []string{"a", "a"}, cycleErr.Cycle)
+}
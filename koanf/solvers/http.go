@@ -0,0 +1,202 @@
+package solvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHTTPTimeout bounds a single http(s) request when HTTPOptions sets
+	// no timeout.
+	DefaultHTTPTimeout = 10 * time.Second
+	// DefaultHTTPMaxBytes is the response body limit when HTTPOptions sets
+	// none.
+	DefaultHTTPMaxBytes = 1 << 20
+)
+
+// ErrHTTPHostNotAllowed is returned for URLs whose host is not in the http
+// allowlist.
+var ErrHTTPHostNotAllowed = errors.New("host not allowed")
+
+// HTTPOptions configures the http and https protocols:
+//
+//	@https://config.internal/flags/checkout.txt
+//	@include://https://config.internal/flags.json
+type HTTPOptions struct {
+	// Allow lists the hosts that may be fetched, checked again on every
+	// redirect. Entries match the host name, or the host and port when they
+	// have one; a "*." prefix matches subdomains. An empty list allows
+	// nothing.
+	Allow []string
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+	// Header is added to every request, e.g. an Authorization token.
+	Header http.Header
+	// Timeout bounds each request. The solver context also applies.
+	Timeout time.Duration
+	// MaxBytes caps the response body.
+	MaxBytes int64
+}
+
+// WithURIHTTPProtocol registers the http and https protocols, which fetch
+// the trimmed response body of a GET to an allowed host. The protocols are
+// not registered by default, since any config source could otherwise reach
+// internal endpoints. Headers are added to the ones set by earlier options.
+func WithURIHTTPProtocol(opts HTTPOptions) URISolverOption {
+	return func(s *uris) {
+		for _, scheme := range []string{"http", "https"} {
+			s.registerResolver(scheme, func(uri string, state *uriResolveState) (any, error) {
+				if state == nil {
+					state = s.newResolveState()
+				}
+				return resolveHTTPProtocol(scheme, uri, state)
+			})
+		}
+
+		header := s.http.Header.Clone()
+		for name, values := range opts.Header {
+			for _, value := range values {
+				if header == nil {
+					header = http.Header{}
+				}
+				header.Add(name, value)
+			}
+		}
+		opts.Header = header
+		s.http = opts
+	}
+}
+
+// WithURIHTTPHeader adds a header to every http and https request. The
+// header is sent to every allowed host, so keep the allowlist to hosts that
+// should see it. It does not register the protocols, see WithURIHTTPProtocol.
+func WithURIHTTPHeader(name, value string) URISolverOption {
+	return func(s *uris) {
+		if s.http.Header == nil {
+			s.http.Header = http.Header{}
+		}
+		s.http.Header.Add(name, value)
+	}
+}
+
+func resolveHTTPProtocol(scheme, uri string, state *uriResolveState) (string, error) {
+	if state == nil {
+		state = newURIResolveState()
+	}
+	opts := state.http
+	target := scheme + "://" + uri
+	if content, ok := state.valuesByURI[target]; ok {
+		return content, nil
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultHTTPMaxBytes
+	}
+	client := allowlistClient(opts.Client, opts.Allow)
+
+	ctx, cancel := context.WithTimeout(state.ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", err
+	}
+	if !httpHostAllowed(req.URL, opts.Allow) {
+		return "", fmt.Errorf("GET %s: %w", redactURL(req), ErrHTTPHostNotAllowed)
+	}
+	for name, values := range opts.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", fmt.Errorf("GET %s: %w", redactURL(req), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("GET %s: unexpected status %s", redactURL(req), resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(body)) > maxBytes {
+		return "", fmt.Errorf("GET %s: response exceeds %d bytes", redactURL(req), maxBytes)
+	}
+
+	content := strings.TrimRight(string(body), "\n")
+	state.valuesByURI[target] = content
+	return content, nil
+}
+
+// redactURL drops credentials and the query string, which may hold tokens,
+// from error messages.
+func redactURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = ""
+	return u.String()
+}
+
+// allowlistClient returns a copy of client, http.DefaultClient when nil, that
+// refuses redirects to hosts outside allow.
+func allowlistClient(client *http.Client, allow []string) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	out := *client
+	next := client.CheckRedirect
+	out.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !httpHostAllowed(req.URL, allow) {
+			return fmt.Errorf("redirect to %s: %w", redactURL(req), ErrHTTPHostNotAllowed)
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &out
+}
+
+func httpHostAllowed(u *url.URL, allow []string) bool {
+	host := strings.ToLower(u.Host)
+	name := strings.ToLower(u.Hostname())
+	for _, entry := range allow {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if suffix, ok := strings.CutPrefix(entry, "*."); ok {
+			if strings.HasSuffix(name, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if entry == host || entry == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package solvers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHTTPTestServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version.txt", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		_, _ = w.Write([]byte("1.2.3\n"))
+	})
+	mux.HandleFunc("/flags.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"checkout": true, "beta": {"rollout": 10}}`))
+	})
+	mux.HandleFunc("/private.txt", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			http.Error(w, "denied", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("private"))
+	})
+	mux.HandleFunc("/large.txt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 64)))
+	})
+	mux.HandleFunc("/redirect.txt", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost:1/internal.txt", http.StatusFound)
+	})
+	mux.HandleFunc("/slow.txt", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func httpTestAllow(server *httptest.Server) HTTPOptions {
+	return HTTPOptions{Allow: []string{strings.TrimPrefix(server.URL, "http://")}}
+}
+
+func solveHTTP(t *testing.T, values map[string]any, opts ...URISolverOption) *koanf.Koanf {
+	t.Helper()
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(values, "."), nil))
+	NewURISolverWithOptions("@", "://", opts...).Solve(k)
+	return k
+}
+
+func TestHTTPProtocol(t *testing.T) {
+	server, hits := newHTTPTestServer(t)
+
+	k := solveHTTP(t, map[string]any{
+		"version": "@" + server.URL + "/version.txt",
+		"release": map[string]any{"version": "@" + server.URL + "/version.txt"},
+		"flags":   "@include://" + server.URL + "/flags.json",
+		"private": "@" + server.URL + "/private.txt",
+	}, WithURIHTTPProtocol(httpTestAllow(server)))
+
+	assert.Equal(t, "1.2.3", k.String("version"))
+	assert.Equal(t, "1.2.3", k.String("release.version"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(hits), "same URI is fetched once per solve")
+	assert.Equal(t, true, k.Bool("flags.checkout"))
+	assert.Equal(t, 10, k.Int("flags.beta.rollout"))
+	assert.Equal(t, "@"+server.URL+"/private.txt", k.String("private"), "failed requests leave the value unchanged")
+}
+
+func TestHTTPProtocol_NotRegisteredByDefault(t *testing.T) {
+	server, hits := newHTTPTestServer(t)
+
+	k := solveHTTP(t, map[string]any{
+		"version": "@" + server.URL + "/version.txt",
+		"flags":   "@include://" + server.URL + "/flags.json",
+	})
+
+	assert.Equal(t, "@"+server.URL+"/version.txt", k.String("version"))
+	assert.Equal(t, "@include://"+server.URL+"/flags.json", k.String("flags"))
+	assert.Equal(t, int32(0), atomic.LoadInt32(hits))
+}
+
+func TestHTTPProtocol_Allowlist(t *testing.T) {
+	server, hits := newHTTPTestServer(t)
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	k := solveHTTP(t, map[string]any{"version": "@" + server.URL + "/version.txt"},
+		WithURIHTTPProtocol(HTTPOptions{}))
+	assert.Equal(t, "@"+server.URL+"/version.txt", k.String("version"), "an empty allowlist allows nothing")
+	assert.Equal(t, int32(0), atomic.LoadInt32(hits))
+
+	state := newURIResolveState()
+	state.http = HTTPOptions{Allow: []string{"config.internal"}}
+	_, err := resolveHTTPProtocol("http", host+"/version.txt", state)
+	assert.ErrorIs(t, err, ErrHTTPHostNotAllowed)
+	assert.Equal(t, int32(0), atomic.LoadInt32(hits))
+
+	state.http = HTTPOptions{Allow: []string{host}}
+	_, err = resolveHTTPProtocol("http", host+"/redirect.txt", state)
+	assert.ErrorIs(t, err, ErrHTTPHostNotAllowed, "redirects are checked against the allowlist")
+
+	for _, allow := range []string{"127.0.0.1", host, "*.internal"} {
+		assert.Equal(t, allow != "*.internal", httpHostAllowed(&url.URL{Host: host}, []string{allow}), allow)
+	}
+	assert.True(t, httpHostAllowed(&url.URL{Host: "flags.config.internal"}, []string{"*.internal"}))
+	assert.False(t, httpHostAllowed(&url.URL{Host: "internal"}, []string{"*.internal"}))
+}
+
+func TestHTTPProtocol_Headers(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	values := map[string]any{"private": "@" + server.URL + "/private.txt"}
+	allow := httpTestAllow(server)
+
+	k := solveHTTP(t, values, WithURIHTTPHeader("Authorization", "Bearer t0ken"), WithURIHTTPProtocol(allow))
+	assert.Equal(t, "private", k.String("private"))
+
+	k = solveHTTP(t, values, WithURIHTTPProtocol(HTTPOptions{
+		Allow:  allow.Allow,
+		Client: server.Client(),
+		Header: http.Header{"Authorization": []string{"Bearer t0ken"}},
+	}))
+	assert.Equal(t, "private", k.String("private"))
+
+	k = solveHTTP(t, values,
+		WithURIHTTPHeader("Authorization", "Bearer t0ken"),
+		WithURIHTTPProtocol(HTTPOptions{Allow: allow.Allow, Timeout: time.Second}),
+	)
+	assert.Equal(t, "private", k.String("private"), "options keep earlier headers")
+}
+
+func TestHTTPProtocol_Limits(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	state := newURIResolveState()
+
+	allow := httpTestAllow(server).Allow
+
+	state.http = HTTPOptions{Allow: allow, MaxBytes: 16}
+	_, err := resolveHTTPProtocol("http", strings.TrimPrefix(server.URL, "http://")+"/large.txt", state)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds 16 bytes")
+
+	state.http = HTTPOptions{Allow: allow, Timeout: 50 * time.Millisecond}
+	_, err = resolveHTTPProtocol("http", strings.TrimPrefix(server.URL, "http://")+"/slow.txt", state)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	state.http = HTTPOptions{Allow: allow}
+	_, err = resolveHTTPProtocol("http", strings.TrimPrefix(server.URL, "http://")+"/private.txt?token=abc", state)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.NotContains(t, err.Error(), "token=abc")
+}
+
+func TestReplaceURISolverOptions_HTTPHeader(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	original := NewURISolverWithOptions("@", "://", WithURIHTTPProtocol(httpTestAllow(server)))
+	replaced, ok := ReplaceURISolverOptions(original, WithURIHTTPHeader("Authorization", "Bearer t0ken"))
+	require.True(t, ok)
+
+	load := func() *koanf.Koanf {
+		k := koanf.New(".")
+		require.NoError(t, k.Load(confmap.Provider(map[string]any{"private": "@" + server.URL + "/private.txt"}, "."), nil))
+		return k
+	}
+	assert.Equal(t, "private", replaced.Solve(load()).String("private"))
+	assert.Equal(t, "@"+server.URL+"/private.txt", original.Solve(load()).String("private"))
+}
+
+func TestReplaceURISolverOptions_IncludeUsesAddedProtocols(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	replaced, ok := ReplaceURISolverOptions(NewURISolver("@", "://"), WithURIHTTPProtocol(httpTestAllow(server)))
+	require.True(t, ok)
+
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(map[string]any{"flags": "@include://" + server.URL + "/flags.json"}, "."), nil))
+	replaced.Solve(k)
+	assert.Equal(t, true, k.Bool("flags.checkout"))
+}
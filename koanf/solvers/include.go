@@ -0,0 +1,175 @@
+package solvers
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/parsers/yaml"
+)
+
+// Include payload formats. The include protocol picks one from the file
+// extension of the nested URI, JSON by default; include+yaml://,
+// include+toml:// and include+json:// force one.
+const (
+	IncludeFormatJSON = "json"
+	IncludeFormatYAML = "yaml"
+	IncludeFormatTOML = "toml"
+)
+
+var includeFormats = []string{IncludeFormatJSON, IncludeFormatYAML, IncludeFormatTOML}
+
+// includeProtocolFormat reports whether protocol is include or include+format,
+// and the forced format if any.
+func includeProtocolFormat(protocol string) (format string, ok bool) {
+	if protocol == "include" {
+		return "", true
+	}
+	format, found := strings.CutPrefix(protocol, "include+")
+	if !found {
+		return "", false
+	}
+	for _, known := range includeFormats {
+		if format == known {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+func inferIncludeFormat(uri string) string {
+	if idx := strings.Index(uri, "?"); idx != -1 {
+		uri = uri[:idx]
+	}
+	switch strings.ToLower(path.Ext(uri)) {
+	case ".yaml", ".yml":
+		return IncludeFormatYAML
+	case ".toml":
+		return IncludeFormatTOML
+	default:
+		return IncludeFormatJSON
+	}
+}
+
+// splitIncludeFragment splits "common.yaml#database.primary" into the
+// document URI and the fragment. Storage URIs already use the first '#' to
+// separate connection and path, so their fragment follows a second '#'.
+func splitIncludeFragment(protocol, uri string) (documentURI, fragment string) {
+	search := uri
+	offset := 0
+	if protocol == "storage" {
+		first := strings.Index(uri, "#")
+		if first == -1 {
+			return uri, ""
+		}
+		offset = first + 1
+		search = uri[offset:]
+	}
+	idx := strings.Index(search, "#")
+	if idx == -1 {
+		return uri, ""
+	}
+	return uri[:offset+idx], strings.TrimSpace(search[idx+1:])
+}
+
+// selectIncludeFragment returns the node at the dot separated path in doc.
+// List items are selected by index, e.g. "servers.0.host".
+func selectIncludeFragment(doc any, fragment string) (any, error) {
+	current := doc
+	for _, segment := range strings.Split(fragment, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("include fragment %q: key %q not found", fragment, segment)
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("include fragment %q: invalid list index %q", fragment, segment)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("include fragment %q: %q is not an object or list", fragment, segment)
+		}
+	}
+	return current, nil
+}
+
+func decodeIncludedValue(value any, format string) (any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("include payload is nil")
+	case string:
+		return parseIncludePayload(v, format)
+	case []byte:
+		return parseIncludePayload(string(v), format)
+	default:
+		// If a custom resolver already returned an object, accept it.
+		return v, nil
+	}
+}
+
+func parseIncludePayload(input, format string) (any, error) {
+	switch format {
+	case IncludeFormatYAML:
+		out, err := yaml.Parser().Unmarshal([]byte(input))
+		if err != nil {
+			return nil, fmt.Errorf("include yaml: %w", err)
+		}
+		return normalizeIncludedValue(out), nil
+	case IncludeFormatTOML:
+		out, err := toml.Parser().Unmarshal([]byte(input))
+		if err != nil {
+			return nil, fmt.Errorf("include toml: %w", err)
+		}
+		return normalizeIncludedValue(out), nil
+	default:
+		return parseJSON(input)
+	}
+}
+
+func parseJSON(input string) (any, error) {
+	var out any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(input)), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// normalizeIncludedValue converts the nested maps and typed lists returned by
+// the YAML and TOML parsers into map[string]any and []any, like JSON.
+func normalizeIncludedValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = normalizeIncludedValue(item)
+		}
+		return out
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[fmt.Sprint(key)] = normalizeIncludedValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalizeIncludedValue(item)
+		}
+		return out
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalizeIncludedValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
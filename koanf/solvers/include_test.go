@@ -0,0 +1,127 @@
+package solvers
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var includeTestFS = fstest.MapFS{
+	"common.yaml": &fstest.MapFile{Data: []byte(`
+database:
+  primary:
+    host: db1.internal
+    port: 5432
+  replicas:
+    - host: db2.internal
+    - host: db3.internal
+features: [search, billing]
+`)},
+	"common.toml": &fstest.MapFile{Data: []byte(`
+[cache]
+ttl = "5m"
+size = 128
+
+[[cache.tiers]]
+name = "hot"
+`)},
+	"common.json": &fstest.MapFile{Data: []byte(`{"log": {"level": "debug"}}`)},
+	"common.conf": &fstest.MapFile{Data: []byte("log:\n  level: warn\n")},
+}
+
+func solveIncludes(t *testing.T, values map[string]any) *koanf.Koanf {
+	t.Helper()
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(values, "."), nil))
+	return NewURISolverWithFS("@", "://", includeTestFS).Solve(k)
+}
+
+func TestIncludeProtocol_Formats(t *testing.T) {
+	out := solveIncludes(t, map[string]any{
+		"from_yaml": "@include://file://common.yaml",
+		"from_toml": "@include://file://common.toml",
+		"from_json": "@include://file://common.json",
+		"hinted":    "@include+yaml://file://common.conf",
+	})
+
+	assert.Equal(t, "db1.internal", out.String("from_yaml.database.primary.host"))
+	assert.Equal(t, 5432, out.Int("from_yaml.database.primary.port"))
+	assert.Equal(t, []string{"search", "billing"}, out.Strings("from_yaml.features"))
+	assert.Equal(t, "5m", out.String("from_toml.cache.ttl"))
+	assert.Equal(t, 128, out.Int("from_toml.cache.size"))
+	assert.Equal(t, "hot", out.Get("from_toml.cache.tiers").([]any)[0].(map[string]any)["name"])
+	assert.Equal(t, "debug", out.String("from_json.log.level"))
+	assert.Equal(t, "warn", out.String("hinted.log.level"))
+}
+
+func TestIncludeProtocol_Fragment(t *testing.T) {
+	out := solveIncludes(t, map[string]any{
+		"primary":  "@include://file://common.yaml#database.primary",
+		"replica":  "@include://file://common.yaml#database.replicas.1.host",
+		"features": "@include://file://common.yaml#features",
+		"cache":    "@include+toml://file://common.toml#cache",
+		"missing":  "@include://file://common.yaml#database.nope",
+	})
+
+	assert.Equal(t, "db1.internal", out.String("primary.host"))
+	assert.Equal(t, "db3.internal", out.String("replica"))
+	assert.Equal(t, []string{"search", "billing"}, out.Strings("features"))
+	assert.Equal(t, "5m", out.String("cache.ttl"))
+	assert.Equal(t, "@include://file://common.yaml#database.nope", out.String("missing"))
+}
+
+func TestIncludeProtocol_FragmentCachesDocument(t *testing.T) {
+	calls := 0
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(map[string]any{
+		"a": "@include+yaml://mockraw://shared#one",
+		"b": "@include+yaml://mockraw://shared#two",
+	}, "."), nil))
+
+	NewURISolverWithOptions("@", "://", WithURIProtocolResolver("mockraw", func(uri string, _ *uriResolveState) (any, error) {
+		calls++
+		assert.Equal(t, "shared", uri)
+		return "one: 1\ntwo: 2\n", nil
+	})).Solve(k)
+
+	assert.Equal(t, 1, k.Int("a"))
+	assert.Equal(t, 2, k.Int("b"))
+	assert.Equal(t, 1, calls)
+}
+
+func TestSplitIncludeFragment(t *testing.T) {
+	cases := []struct {
+		protocol, uri, document, fragment string
+	}{
+		{"file", "common.yaml", "common.yaml", ""},
+		{"file", "common.yaml#a.b", "common.yaml", "a.b"},
+		{"https", "config.internal/flags.json#beta", "config.internal/flags.json", "beta"},
+		{"storage", "fs:///tmp#objects/latest.json", "fs:///tmp#objects/latest.json", ""},
+		{"storage", "fs:///tmp#objects/latest.yaml#release", "fs:///tmp#objects/latest.yaml", "release"},
+	}
+	for _, tc := range cases {
+		document, fragment := splitIncludeFragment(tc.protocol, tc.uri)
+		assert.Equal(t, tc.document, document, tc.uri)
+		assert.Equal(t, tc.fragment, fragment, tc.uri)
+	}
+}
+
+func TestRefSolver_IncludesYAMLDocument(t *testing.T) {
+	k := loadRefTestConfig(map[string]any{
+		"primary": map[string]any{
+			"$ref": "include://file://common.yaml#/database/primary",
+			"port": 6432,
+		},
+	})
+
+	solver := NewRefSolverWithFS(includeTestFS)
+	out := solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "db1.internal", out.Get("primary.host"))
+	assert.Equal(t, 6432, out.Get("primary.port"))
+}
@@ -0,0 +1,379 @@
+package solvers
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+const defaultRefKey = "$ref"
+
+// RefResolutionError captures details about an unresolved $ref directive.
+// Chain lists the references of a cycle, ending with the one that repeats.
+type RefResolutionError struct {
+	NodePath string
+	Ref      string
+	Chain    []string
+	Err      error
+}
+
+func (e *RefResolutionError) Error() string {
+	if e == nil {
+		return "ref resolution failed"
+	}
+	msg := fmt.Sprintf("ref %q at %s", e.Ref, normalizePath(e.NodePath))
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *RefResolutionError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+type refSolver struct {
+	key  string
+	uris *uris
+	err  error
+	// stack holds the references being resolved, for cycle chains.
+	stack []refFrame
+}
+
+type refFrame struct {
+	id  string
+	ref string
+}
+
+// refDocument is the document a JSON pointer is resolved against: the config
+// itself (uri "") or a document loaded through include://.
+type refDocument struct {
+	uri  string
+	root any
+}
+
+// NewRefSolver resolves $ref directives inside object nodes by copying the
+// referenced subtree, for example:
+//
+//	{
+//	  "shared": {"redis": {"host": "localhost", "port": 6379}},
+//	  "cache": {"$ref": "#/shared/redis", "db": 1},
+//	  "queue": {"$ref": "include://file://common.json#/redis"}
+//	}
+//
+// The reference is a JSON pointer into the config ("#/shared/redis") or into
+// a document loaded with the URI solver include protocol. Sibling keys of the
+// directive override the copied values, merging nested objects. References
+// inside a copied subtree resolve against the document it came from.
+func NewRefSolver() ConfigSolver {
+	return NewRefSolverWithFS(os.DirFS("."))
+}
+
+// NewRefSolverWithFS reads included documents from f. URI solver options,
+// such as custom protocol resolvers, apply to included documents.
+func NewRefSolverWithFS(f fs.FS, opts ...URISolverOption) ConfigSolver {
+	return &refSolver{
+		key:  defaultRefKey,
+		uris: NewURISolverWithFSAndOptions("@", "://", f, opts...).(*uris),
+	}
+}
+
+// ReplaceRefSolverOptions returns a copy of solver with the URI solver opts
+// applied to the included documents when solver is a ref solver. Other
+// solvers are returned unchanged with ok=false.
+func ReplaceRefSolverOptions(solver ConfigSolver, opts ...URISolverOption) (updated ConfigSolver, ok bool) {
+	ref, ok := solver.(*refSolver)
+	if !ok || len(opts) == 0 {
+		return solver, false
+	}
+	uriSolver, _ := ReplaceURISolverOptions(ref.uris, opts...)
+	return &refSolver{key: ref.key, uris: uriSolver.(*uris)}, true
+}
+
+func (s *refSolver) Err() error {
+	return s.err
+}
+
+func (s *refSolver) Solve(config *koanf.Koanf) *koanf.Koanf {
+	s.solve(config, s.uris.newResolveState())
+	return config
+}
+
+// SolveContext implements ContextSolver. Included documents are read with
+// ctx.
+func (s *refSolver) SolveContext(ctx context.Context, config *koanf.Koanf) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	state := s.uris.newResolveState()
+	state.ctx = ctx
+	s.solve(config, state)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.err
+}
+
+func (s *refSolver) solve(config *koanf.Koanf, state *uriResolveState) *koanf.Koanf {
+	s.err = nil
+	s.stack = nil
+
+	if config == nil {
+		return config
+	}
+
+	root := config.Raw()
+	if !s.hasRef(root) {
+		return config
+	}
+
+	doc := &refDocument{root: root}
+	resolved, err := s.resolveAny(root, doc, "", state)
+	if err != nil {
+		s.err = err
+		return config
+	}
+
+	resolvedRoot, ok := resolved.(map[string]any)
+	if !ok {
+		s.err = &RefResolutionError{
+			NodePath: "<root>",
+			Err:      fmt.Errorf("root reference must resolve to an object"),
+		}
+		return config
+	}
+
+	for key := range root {
+		config.Delete(key)
+	}
+	for key, value := range resolvedRoot {
+		config.Set(key, value)
+	}
+
+	return config
+}
+
+// resolveAny returns a copy of value with every $ref directive replaced.
+func (s *refSolver) resolveAny(value any, doc *refDocument, path string, state *uriResolveState) (any, error) {
+	switch current := value.(type) {
+	case map[string]any:
+		if _, hasRef := current[s.key]; hasRef {
+			return s.resolveRefMap(current, doc, path, state)
+		}
+		out := make(map[string]any, len(current))
+		for _, key := range sortedKeys(current) {
+			resolved, err := s.resolveAny(current[key], doc, joinPath(path, key), state)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(current))
+		for i, child := range current {
+			resolved, err := s.resolveAny(child, doc, joinPath(path, strconv.Itoa(i)), state)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+func (s *refSolver) resolveRefMap(node map[string]any, doc *refDocument, path string, state *uriResolveState) (any, error) {
+	ref, ok := node[s.key].(string)
+	if !ok || strings.TrimSpace(ref) == "" {
+		return nil, &RefResolutionError{
+			NodePath: path,
+			Ref:      fmt.Sprint(node[s.key]),
+			Err:      fmt.Errorf("%s must be a non empty string", s.key),
+		}
+	}
+	ref = strings.TrimSpace(ref)
+
+	target, targetDoc, pointer, err := s.lookup(ref, doc, state)
+	if err != nil {
+		return nil, &RefResolutionError{NodePath: path, Ref: ref, Err: err}
+	}
+
+	// the pending set is shared with include:// so cycles through included
+	// documents and local pointers are detected alike
+	id := targetDoc.uri + "#" + pointer
+	if _, pending := state.includePending[id]; pending {
+		chain := s.cycleChain(id, ref)
+		return nil, &RefResolutionError{
+			NodePath: path,
+			Ref:      ref,
+			Chain:    chain,
+			Err:      fmt.Errorf("%w: %s", ErrCyclicReference, strings.Join(chain, " -> ")),
+		}
+	}
+	state.includePending[id] = struct{}{}
+	s.stack = append(s.stack, refFrame{id: id, ref: ref})
+	resolved, err := s.resolveAny(target, targetDoc, path, state)
+	s.stack = s.stack[:len(s.stack)-1]
+	delete(state.includePending, id)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]any, len(node))
+	for key, child := range node {
+		if key != s.key {
+			overrides[key] = child
+		}
+	}
+	if len(overrides) == 0 {
+		return resolved, nil
+	}
+
+	base, ok := resolved.(map[string]any)
+	if !ok {
+		return nil, &RefResolutionError{
+			NodePath: path,
+			Ref:      ref,
+			Err:      fmt.Errorf("sibling keys require the reference to resolve to an object, got %T", resolved),
+		}
+	}
+	resolvedOverrides, err := s.resolveAny(overrides, doc, path, state)
+	if err != nil {
+		return nil, err
+	}
+	// base is a fresh copy from resolveAny, so it can be merged in place
+	_ = mergeOverride(resolvedOverrides.(map[string]any), base)
+	return base, nil
+}
+
+// cycleChain returns the references from the first one pointing at id to
+// ref, which points at id again.
+func (s *refSolver) cycleChain(id, ref string) []string {
+	var chain []string
+	for i, frame := range s.stack {
+		if frame.id == id {
+			for _, pending := range s.stack[i:] {
+				chain = append(chain, pending.ref)
+			}
+			break
+		}
+	}
+	return append(chain, ref)
+}
+
+// lookup returns the value a reference points to and the document holding it.
+func (s *refSolver) lookup(ref string, doc *refDocument, state *uriResolveState) (any, *refDocument, string, error) {
+	location, pointer := splitRefFragment(ref)
+
+	targetDoc := doc
+	if location != "" {
+		protocol, uri, err := parseProtocolURI(location, s.uris.delimeters.End)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		_, isInclude := includeProtocolFormat(protocol)
+		if !isInclude {
+			return nil, nil, "", fmt.Errorf("unsupported ref protocol %q, expected include", protocol)
+		}
+		included, err := s.uris.resolveByProtocol(protocol, uri, state)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		targetDoc = &refDocument{uri: location, root: included}
+	}
+
+	target, err := resolveJSONPointer(targetDoc.root, pointer)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return target, targetDoc, pointer, nil
+}
+
+func (s *refSolver) hasRef(value any) bool {
+	switch current := value.(type) {
+	case map[string]any:
+		if _, ok := current[s.key]; ok {
+			return true
+		}
+		for _, child := range current {
+			if s.hasRef(child) {
+				return true
+			}
+		}
+	case []any:
+		for _, child := range current {
+			if s.hasRef(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitRefFragment splits a reference into its document location and JSON
+// pointer. The fragment starts at the last "#" followed by "/" or at a
+// trailing "#", so storage URIs keep their own "#" separator.
+func splitRefFragment(ref string) (location, pointer string) {
+	idx := strings.LastIndex(ref, "#")
+	if idx == -1 {
+		return ref, ""
+	}
+	fragment := ref[idx+1:]
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		return ref, ""
+	}
+	return ref[:idx], fragment
+}
+
+// resolveJSONPointer resolves an RFC 6901 pointer such as "/shared/redis".
+// An empty pointer refers to the whole document.
+func resolveJSONPointer(root any, pointer string) (any, error) {
+	if pointer == "" {
+		return root, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+
+	current := root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("json pointer %q: key %q not found", pointer, token)
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("json pointer %q: invalid index %q", pointer, token)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("json pointer %q: cannot descend into %T at %q", pointer, current, token)
+		}
+	}
+	return current, nil
+}
+
+func sortedKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
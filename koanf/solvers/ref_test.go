@@ -0,0 +1,242 @@
+package solvers
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadRefTestConfig(values map[string]any) *koanf.Koanf {
+	k := koanf.New(".")
+	_ = k.Load(confmap.Provider(values, "."), nil)
+	return k
+}
+
+func TestRefSolver_CopiesLocalSubtreeWithOverrides(t *testing.T) {
+	k := loadRefTestConfig(map[string]any{
+		"shared": map[string]any{
+			"redis": map[string]any{
+				"host": "localhost",
+				"port": 6379,
+				"tls": map[string]any{
+					"enabled": false,
+					"ca":      "/etc/ca.pem",
+				},
+			},
+		},
+		"cache": map[string]any{
+			"$ref": "#/shared/redis",
+			"db":   1,
+			"tls": map[string]any{
+				"enabled": true,
+			},
+		},
+		"queue": map[string]any{
+			"$ref": "#/shared/redis",
+		},
+		"hosts": []any{
+			map[string]any{"$ref": "#/shared/redis/host"},
+		},
+	})
+
+	solver := NewRefSolver()
+	out := solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "localhost", out.Get("cache.host"))
+	assert.Equal(t, 1, out.Get("cache.db"))
+	assert.Equal(t, true, out.Get("cache.tls.enabled"))
+	assert.Equal(t, "/etc/ca.pem", out.Get("cache.tls.ca"))
+	assert.False(t, out.Exists("cache.$ref"))
+	assert.Equal(t, 6379, out.Get("queue.port"))
+	assert.Equal(t, false, out.Get("queue.tls.enabled"))
+	assert.Equal(t, []any{"localhost"}, out.Get("hosts"))
+	assert.Equal(t, false, out.Get("shared.redis.tls.enabled"))
+}
+
+func TestRefSolver_ResolvesNestedReferences(t *testing.T) {
+	k := loadRefTestConfig(map[string]any{
+		"base": map[string]any{
+			"timeout": "5s",
+		},
+		"shared": map[string]any{
+			"http": map[string]any{
+				"$ref":    "#/base",
+				"retries": 3,
+			},
+		},
+		"client": map[string]any{
+			"$ref": "#/shared/http",
+		},
+	})
+
+	solver := NewRefSolver()
+	out := solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "5s", out.Get("client.timeout"))
+	assert.Equal(t, 3, out.Get("client.retries"))
+}
+
+func TestRefSolver_IncludesExternalDocument(t *testing.T) {
+	fsys := fstest.MapFS{
+		"common.json": &fstest.MapFile{Data: []byte(`{
+			"redis": {"host": "redis.internal", "port": 6380, "auth": {"$ref": "#/auth"}},
+			"auth": {"user": "app"},
+			"users/admins": ["root"]
+		}`)},
+	}
+	k := loadRefTestConfig(map[string]any{
+		"auth": map[string]any{
+			"user": "local",
+		},
+		"queue": map[string]any{
+			"$ref": "include://file://common.json#/redis",
+			"port": 6381,
+		},
+		"admins": map[string]any{
+			"$ref": "include://file://common.json#/users~1admins",
+		},
+	})
+
+	solver := NewRefSolverWithFS(fsys)
+	out := solver.Solve(k)
+
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "redis.internal", out.Get("queue.host"))
+	assert.Equal(t, 6381, out.Get("queue.port"))
+	assert.Equal(t, "app", out.Get("queue.auth.user"))
+	assert.Equal(t, []any{"root"}, out.Get("admins"))
+}
+
+func TestRefSolver_DetectsCycles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"node": {"$ref": "include://file://b.json#/node"}}`)},
+		"b.json": &fstest.MapFile{Data: []byte(`{"node": {"$ref": "include://file://a.json#/node"}}`)},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]any
+		chain  []string
+	}{
+		{
+			name: "local",
+			values: map[string]any{
+				"a": map[string]any{"$ref": "#/b"},
+				"b": map[string]any{"$ref": "#/a"},
+			},
+			chain: []string{"#/b", "#/a", "#/b"},
+		},
+		{
+			name: "ancestor",
+			values: map[string]any{
+				"a": map[string]any{
+					"child": map[string]any{"$ref": "#/a"},
+				},
+			},
+			chain: []string{"#/a", "#/a"},
+		},
+		{
+			name: "included",
+			values: map[string]any{
+				"root": map[string]any{"$ref": "include://file://a.json#/node"},
+			},
+			chain: []string{
+				"include://file://a.json#/node",
+				"include://file://b.json#/node",
+				"include://file://a.json#/node",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solver := NewRefSolverWithFS(fsys)
+			solver.Solve(loadRefTestConfig(tt.values))
+
+			err := solver.(ErrorReporter).Err()
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrCyclicReference), err.Error())
+
+			var refErr *RefResolutionError
+			require.True(t, errors.As(err, &refErr))
+			assert.Equal(t, tt.chain, refErr.Chain)
+		})
+	}
+}
+
+func TestRefSolver_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]any
+		nodePath string
+	}{
+		{
+			name: "missing pointer",
+			values: map[string]any{
+				"cache": map[string]any{"$ref": "#/shared/missing"},
+			},
+			nodePath: "cache",
+		},
+		{
+			name: "scalar with overrides",
+			values: map[string]any{
+				"host":  "localhost",
+				"cache": map[string]any{"$ref": "#/host", "db": 1},
+			},
+			nodePath: "cache",
+		},
+		{
+			name: "unsupported protocol",
+			values: map[string]any{
+				"cache": map[string]any{"$ref": "file://common.json#/redis"},
+			},
+			nodePath: "cache",
+		},
+		{
+			name: "missing document",
+			values: map[string]any{
+				"cache": map[string]any{"$ref": "include://file://missing.json#/redis"},
+			},
+			nodePath: "cache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := loadRefTestConfig(tt.values)
+			solver := NewRefSolverWithFS(fstest.MapFS{})
+			out := solver.Solve(k)
+
+			var refErr *RefResolutionError
+			require.True(t, errors.As(solver.(ErrorReporter).Err(), &refErr))
+			assert.Equal(t, tt.nodePath, refErr.NodePath)
+			assert.True(t, out.Exists("cache.$ref"), "config is left unchanged on error")
+		})
+	}
+}
+
+func TestReplaceRefSolverOptions(t *testing.T) {
+	original := NewRefSolverWithFS(fstest.MapFS{})
+	replaced, ok := ReplaceRefSolverOptions(original, WithURIFS(fstest.MapFS{
+		"common.json": &fstest.MapFile{Data: []byte(`{"redis": {"host": "redis.internal"}}`)},
+	}))
+	require.True(t, ok)
+
+	values := map[string]any{"cache": map[string]any{"$ref": "include://file://common.json#/redis"}}
+	out := replaced.Solve(loadRefTestConfig(values))
+	require.NoError(t, replaced.(ErrorReporter).Err())
+	assert.Equal(t, "redis.internal", out.Get("cache.host"))
+
+	original.Solve(loadRefTestConfig(values))
+	assert.Error(t, original.(ErrorReporter).Err(), "the original solver is unchanged")
+
+	_, ok = ReplaceRefSolverOptions(NewVariablesSolver("${", "}"), WithURIFS(fstest.MapFS{}))
+	assert.False(t, ok)
+}
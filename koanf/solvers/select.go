@@ -1,6 +1,7 @@
 package solvers
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -70,6 +71,11 @@ func (s *selectSolver) Err() error {
 	return s.err
 }
 
+// SolveContext implements ContextSolver.
+func (s *selectSolver) SolveContext(ctx context.Context, config *koanf.Koanf) error {
+	return solveInMemory(ctx, s, config)
+}
+
 func (s *selectSolver) Solve(config *koanf.Koanf) *koanf.Koanf {
 	s.err = nil
 
@@ -1,7 +1,9 @@
 package solvers
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/knadh/koanf/v2"
 )
@@ -10,6 +12,41 @@ type ConfigSolver interface {
 	Solve(config *koanf.Koanf) *koanf.Koanf
 }
 
+// ContextSolver is an optional extension for solvers that honour
+// cancellation. SolveContext returns the error an ErrorReporter would report,
+// or ctx.Err() when the context ended during the solve. Container Load
+// prefers SolveContext over Solve.
+type ContextSolver interface {
+	ConfigSolver
+	SolveContext(ctx context.Context, config *koanf.Koanf) error
+}
+
+// SolveWithContext runs solver through SolveContext when it implements
+// ContextSolver. Other solvers run through Solve once ctx is checked, and
+// their ErrorReporter error is returned.
+func SolveWithContext(ctx context.Context, solver ConfigSolver, config *koanf.Koanf) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if contextSolver, ok := solver.(ContextSolver); ok {
+		return contextSolver.SolveContext(ctx, config)
+	}
+	return solveInMemory(ctx, solver, config)
+}
+
+// solveInMemory implements SolveContext for solvers that do no I/O, so the
+// context is only checked before they run.
+func solveInMemory(ctx context.Context, solver ConfigSolver, config *koanf.Koanf) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	solver.Solve(config)
+	if reporter, ok := solver.(ErrorReporter); ok {
+		return reporter.Err()
+	}
+	return nil
+}
+
 // ErrorReporter is an optional extension for solvers that can surface
 // recoverable solver errors to container orchestration code.
 type ErrorReporter interface {
@@ -24,3 +61,58 @@ type delimiters struct {
 	Start string
 	End   string
 }
+
+type delimitedToken struct {
+	start   int
+	end     int
+	path    string
+	escaped bool
+}
+
+// nextDelimitedToken finds the next delimited token at or after offset.
+// Escaped tokens (\${path}) are reported with escaped set so callers keep
+// them as-is.
+func nextDelimitedToken(input string, offset int, d *delimiters) (delimitedToken, bool) {
+	if d == nil || d.Start == "" || d.End == "" || offset >= len(input) {
+		return delimitedToken{}, false
+	}
+
+	startIndex := strings.Index(input[offset:], d.Start)
+	if startIndex == -1 {
+		return delimitedToken{}, false
+	}
+	startIndex += offset
+	contentStart := startIndex + len(d.Start)
+
+	escaped := false
+	escape := escapedDelimiter(d.Start)
+	if escapeIndex := strings.Index(input[offset:], escape); escapeIndex != -1 && offset+escapeIndex <= startIndex {
+		startIndex = offset + escapeIndex
+		contentStart = startIndex + len(escape)
+		escaped = true
+	}
+
+	endOffset := strings.Index(input[contentStart:], d.End)
+	if endOffset == -1 {
+		return delimitedToken{}, false
+	}
+
+	contentEnd := contentStart + endOffset
+	return delimitedToken{
+		start:   startIndex,
+		end:     contentEnd + len(d.End),
+		path:    input[contentStart:contentEnd],
+		escaped: escaped,
+	}, true
+}
+
+// setValue writes value at key. koanf merges on Set, and under StrictMerge a
+// merge that changes the value type is rejected, so the key is cleared and
+// written again to let solvers replace a string with a typed result.
+func setValue(config *koanf.Koanf, key string, value any) {
+	if err := config.Set(key, value); err == nil {
+		return
+	}
+	config.Delete(key)
+	config.Set(key, value)
+}
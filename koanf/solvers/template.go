@@ -0,0 +1,258 @@
+package solvers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/knadh/koanf/v2"
+)
+
+const (
+	defaultTemplateStart = "{%"
+	defaultTemplateEnd   = "%}"
+)
+
+var (
+	templateFieldPattern      = regexp.MustCompile(`(?:^|[^A-Za-z0-9_)\]])\.([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*)`)
+	templateFunctionNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// TemplateFunction is a function callable from templates, for example
+// {% githash 7 %}.
+type TemplateFunction func(args ...any) (any, error)
+
+// TemplateRenderError captures a single template that failed to parse or
+// execute.
+type TemplateRenderError struct {
+	Key      string
+	Template string
+	Err      error
+}
+
+func (e *TemplateRenderError) Error() string {
+	if e == nil {
+		return "template rendering failed"
+	}
+	return fmt.Sprintf("template rendering failed at %s: %v", e.Key, e.Err)
+}
+
+func (e *TemplateRenderError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// TemplateResolutionError aggregates every failed template from a solve,
+// ordered by key.
+type TemplateResolutionError struct {
+	Failures []*TemplateRenderError
+}
+
+func (e *TemplateResolutionError) Error() string {
+	if e == nil || len(e.Failures) == 0 {
+		return "template rendering failed"
+	}
+	if len(e.Failures) == 1 {
+		return e.Failures[0].Error()
+	}
+	keys := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		keys = append(keys, failure.Key)
+	}
+	return fmt.Sprintf("%d templates failed to render: %s", len(e.Failures), strings.Join(keys, ", "))
+}
+
+func (e *TemplateResolutionError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	out := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		out = append(out, failure)
+	}
+	return out
+}
+
+type TemplateSolverOption func(*templateSolver)
+
+type templateSolver struct {
+	delimiters *delimiters
+	funcs      map[string]TemplateFunction
+	failures   []*TemplateRenderError
+}
+
+// NewTemplateSolver renders string values that contain a Go text/template
+// action, with start and end as action delimiters (default {% %}):
+//
+//	"upstream": "{% range .backends %}server {% .host %}:{% .port %};\n{% end %}"
+//
+// The data is the config snapshot, so ".database.host" reads that key.
+// Missing keys fail the template. The result is always a string. Failures
+// are reported through Err with the key path and leave the value unchanged.
+func NewTemplateSolver(start, end string, options ...TemplateSolverOption) ConfigSolver {
+	if start == "" {
+		start = defaultTemplateStart
+	}
+	if end == "" {
+		end = defaultTemplateEnd
+	}
+	solver := &templateSolver{
+		delimiters: &delimiters{Start: start, End: end},
+		funcs:      map[string]TemplateFunction{},
+	}
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		opt(solver)
+	}
+	return solver
+}
+
+// WithTemplateFunctions adds functions callable from templates. Names that
+// are not valid template identifiers are ignored.
+func WithTemplateFunctions(funcs map[string]TemplateFunction) TemplateSolverOption {
+	return func(s *templateSolver) {
+		for name, fn := range funcs {
+			if fn == nil || !templateFunctionNameRegex.MatchString(name) {
+				continue
+			}
+			s.funcs[name] = fn
+		}
+	}
+}
+
+// ReplaceTemplateSolverFunctions returns a copy of solver with funcs added
+// when solver is a template solver. Other solvers are returned unchanged
+// with ok=false.
+func ReplaceTemplateSolverFunctions(solver ConfigSolver, funcs map[string]TemplateFunction) (updated ConfigSolver, ok bool) {
+	tplSolver, ok := solver.(*templateSolver)
+	if !ok || len(funcs) == 0 {
+		return solver, false
+	}
+	clone := tplSolver.clone()
+	WithTemplateFunctions(funcs)(clone)
+	return clone, true
+}
+
+// SolveContext implements ContextSolver.
+func (s *templateSolver) SolveContext(ctx context.Context, config *koanf.Koanf) error {
+	return solveInMemory(ctx, s, config)
+}
+
+func (s *templateSolver) Solve(config *koanf.Koanf) *koanf.Koanf {
+	s.failures = nil
+
+	if config == nil {
+		return config
+	}
+
+	for key, val := range config.All() {
+		if v2, ok := val.(string); ok {
+			s.keypath(key, v2, config)
+		}
+	}
+
+	return config
+}
+
+// Err returns a *TemplateResolutionError listing every template that failed
+// during the last solve.
+func (s *templateSolver) Err() error {
+	if len(s.failures) == 0 {
+		return nil
+	}
+	failures := append([]*TemplateRenderError{}, s.failures...)
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Key < failures[j].Key
+	})
+	return &TemplateResolutionError{Failures: failures}
+}
+
+func (s *templateSolver) resetErr() {
+	s.failures = nil
+}
+
+// SolveKey renders the template in a single key.
+func (s *templateSolver) SolveKey(key string, config *koanf.Koanf) {
+	if val, ok := config.Get(key).(string); ok {
+		s.keypath(key, val, config)
+	}
+}
+
+// References returns the dotted fields used by the actions in value that
+// exist in config. Fields relative to a range or with block are included
+// when a top level key has the same name.
+func (s *templateSolver) References(_ string, value string, config *koanf.Koanf) []string {
+	var out []string
+	for offset := 0; ; {
+		token, ok := nextDelimitedToken(value, offset, s.delimiters)
+		if !ok {
+			break
+		}
+		offset = token.end
+		action := stripStringLiterals(token.path)
+		for _, match := range templateFieldPattern.FindAllStringSubmatch(action, -1) {
+			field := match[1]
+			if config != nil && !config.Exists(field) && len(leafKeysForPath(field, config)) == 0 {
+				continue
+			}
+			out = append(out, field)
+		}
+	}
+	return out
+}
+
+func (s *templateSolver) keypath(key, val string, config *koanf.Koanf) {
+	if !strings.Contains(val, s.delimiters.Start) {
+		return
+	}
+	rendered, err := s.render(key, val, config.Raw())
+	if err != nil {
+		s.failures = append(s.failures, &TemplateRenderError{
+			Key:      key,
+			Template: val,
+			Err:      err,
+		})
+		return
+	}
+	setValue(config, key, rendered)
+}
+
+func (s *templateSolver) render(key, text string, data map[string]any) (string, error) {
+	funcs := make(template.FuncMap, len(s.funcs))
+	for name, fn := range s.funcs {
+		funcs[name] = fn
+	}
+
+	tpl, err := template.New(key).
+		Delims(s.delimiters.Start, s.delimiters.End).
+		Option("missingkey=error").
+		Funcs(funcs).
+		Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	if err := tpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (s *templateSolver) clone() *templateSolver {
+	funcs := make(map[string]TemplateFunction, len(s.funcs))
+	for name, fn := range s.funcs {
+		funcs[name] = fn
+	}
+	return &templateSolver{
+		delimiters: &delimiters{Start: s.delimiters.Start, End: s.delimiters.End},
+		funcs:      funcs,
+	}
+}
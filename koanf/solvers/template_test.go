@@ -0,0 +1,91 @@
+package solvers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateSolver_RendersWithSnapshot(t *testing.T) {
+	k := loadContextTestConfig(t, map[string]any{
+		"database": map[string]any{"host": "db.local", "port": 5432, "ssl": true},
+		"backends": []any{
+			map[string]any{"host": "10.0.0.1", "port": 8080},
+			map[string]any{"host": "10.0.0.2", "port": 8081},
+		},
+		"dsn":      "postgres://{% .database.host %}:{% .database.port %}/app{% if .database.ssl %}?sslmode=require{% end %}",
+		"upstream": "{% range .backends %}server {% .host %}:{% .port %};\n{% end %}",
+		"plain":    "no template here",
+	})
+
+	solver := NewTemplateSolver("", "")
+	solver.Solve(k)
+	require.NoError(t, solver.(ErrorReporter).Err())
+
+	assert.Equal(t, "postgres://db.local:5432/app?sslmode=require", k.String("dsn"))
+	assert.Equal(t, "server 10.0.0.1:8080;\nserver 10.0.0.2:8081;\n", k.String("upstream"))
+	assert.Equal(t, "no template here", k.String("plain"))
+}
+
+func TestTemplateSolver_Functions(t *testing.T) {
+	k := loadContextTestConfig(t, map[string]any{
+		"name":  "api",
+		"label": "<< upper .name >>-<< suffix >>",
+	})
+
+	solver := NewTemplateSolver("<<", ">>", WithTemplateFunctions(map[string]TemplateFunction{
+		"upper": func(args ...any) (any, error) {
+			return strings.ToUpper(fmt.Sprint(args...)), nil
+		},
+		"suffix": func(...any) (any, error) { return "v2", nil },
+		"not-valid": func(...any) (any, error) {
+			return nil, nil
+		},
+	}))
+	solver.Solve(k)
+	require.NoError(t, solver.(ErrorReporter).Err())
+	assert.Equal(t, "API-v2", k.String("label"))
+}
+
+func TestTemplateSolver_ReportsFailuresByKey(t *testing.T) {
+	boom := errors.New("boom")
+	k := loadContextTestConfig(t, map[string]any{
+		"b": "{% .missing.key %}",
+		"a": "{% if %}",
+		"c": "{% fail %}",
+	})
+
+	solver := NewTemplateSolver("", "", WithTemplateFunctions(map[string]TemplateFunction{
+		"fail": func(...any) (any, error) { return nil, boom },
+	}))
+	solver.Solve(k)
+
+	var tplErr *TemplateResolutionError
+	require.ErrorAs(t, solver.(ErrorReporter).Err(), &tplErr)
+	require.Len(t, tplErr.Failures, 3)
+	assert.Equal(t, "a", tplErr.Failures[0].Key)
+	assert.Equal(t, "b", tplErr.Failures[1].Key)
+	assert.Equal(t, "c", tplErr.Failures[2].Key)
+	assert.ErrorIs(t, tplErr, boom)
+	assert.Equal(t, "{% .missing.key %}", k.String("b"), "failed templates stay unchanged")
+}
+
+func TestTemplateSolver_GraphOrdering(t *testing.T) {
+	k := loadContextTestConfig(t, map[string]any{
+		"host": "${env.host}",
+		"env":  map[string]any{"host": "db.prod"},
+		"url":  "https://{% .host %}/",
+	})
+
+	tpl := NewTemplateSolver("", "")
+	assert.Equal(t, []string{"host"}, tpl.(KeySolver).References("url", "https://{% .host %}/{% .nope %}", k))
+
+	graph := NewGraphSolver(NewVariablesSolver("${", "}"), tpl)
+	graph.Solve(k)
+	require.NoError(t, graph.(ErrorReporter).Err())
+	assert.Equal(t, "https://db.prod/", k.String("url"))
+}
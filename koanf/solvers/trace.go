@@ -0,0 +1,221 @@
+package solvers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// TraceEvent records one change a solver made to a key.
+type TraceEvent struct {
+	// Pass is the solver pass, starting at 1.
+	Pass int
+	// Solver is the short solver name, see SolverName.
+	Solver string
+	Key    string
+	// Before is nil when the key was added, After when it was removed. A key
+	// expanded into an object, e.g. by include, has the object as After.
+	Before any
+	After  any
+	// Duration is the time spent on the key when the solver runs per key,
+	// as in the graph solver, and the time of the whole solve otherwise.
+	Duration time.Duration
+	// Provider names the provider that set Key, or its closest parent,
+	// before solving. It is filled by the config container and empty when a
+	// solver added the key.
+	Provider string
+}
+
+// TraceFunc receives trace events.
+type TraceFunc func(TraceEvent)
+
+// SolverName returns the name used in trace events: variables, uri,
+// expression, template, select, extends, ref, assert or graph for the
+// built-in solvers, the result of a Name() string method when the solver
+// has one, and the Go type otherwise.
+func SolverName(solver ConfigSolver) string {
+	switch s := solver.(type) {
+	case variables, *variables:
+		return "variables"
+	case uris, *uris:
+		return "uri"
+	case *expression:
+		return "expression"
+	case *templateSolver:
+		return "template"
+	case *selectSolver:
+		return "select"
+	case *extendsSolver:
+		return "extends"
+	case *refSolver:
+		return "ref"
+	case *assertSolver:
+		return "assert"
+	case *graphSolver:
+		return "graph"
+	case interface{ Name() string }:
+		return s.Name()
+	default:
+		return fmt.Sprintf("%T", solver)
+	}
+}
+
+// TraceSolve runs solver like SolveWithContext and reports every key it
+// changed to trace. The graph solver reports each key solver step on its
+// own.
+func TraceSolve(ctx context.Context, solver ConfigSolver, config *koanf.Koanf, pass int, trace TraceFunc) error {
+	if trace == nil || config == nil {
+		return SolveWithContext(ctx, solver, config)
+	}
+	if g, ok := solver.(*graphSolver); ok {
+		g.trace, g.pass = trace, pass
+		defer func() { g.trace, g.pass = nil, 0 }()
+		return SolveWithContext(ctx, g, config)
+	}
+	return traceTreeSolve(ctx, solver, config, pass, trace)
+}
+
+func traceTreeSolve(ctx context.Context, solver ConfigSolver, config *koanf.Koanf, pass int, trace TraceFunc) error {
+	before := config.All()
+	start := time.Now()
+	err := SolveWithContext(ctx, solver, config)
+	duration := time.Since(start)
+	after := config.All()
+
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	name := SolverName(solver)
+	for _, key := range keys {
+		oldValue, hadKey := before[key]
+		newValue, hasKey := after[key]
+		if hadKey && hasKey && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if !hasKey {
+			newValue = config.Get(key)
+		}
+		trace(TraceEvent{
+			Pass:     pass,
+			Solver:   name,
+			Key:      key,
+			Before:   oldValue,
+			After:    newValue,
+			Duration: duration,
+		})
+	}
+	return err
+}
+
+// TraceCollector keeps trace events and renders the resolution chain of each
+// key. The zero value is ready to use:
+//
+//	collector := &solvers.TraceCollector{}
+//	container.WithSolverTrace(collector.Record)
+type TraceCollector struct {
+	mu     sync.Mutex
+	events []TraceEvent
+}
+
+// Record adds event. It is safe for concurrent use.
+func (c *TraceCollector) Record(event TraceEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+// Events returns the recorded events in order.
+func (c *TraceCollector) Events() []TraceEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]TraceEvent(nil), c.events...)
+}
+
+// Reset drops the recorded events, e.g. before a reload.
+func (c *TraceCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = nil
+}
+
+// Keys returns the traced keys, sorted.
+func (c *TraceCollector) Keys() []string {
+	seen := map[string]struct{}{}
+	var keys []string
+	for _, event := range c.Events() {
+		if _, ok := seen[event.Key]; !ok {
+			seen[event.Key] = struct{}{}
+			keys = append(keys, event.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Chain returns the events of key in order.
+func (c *TraceCollector) Chain(key string) []TraceEvent {
+	var chain []TraceEvent
+	for _, event := range c.Events() {
+		if event.Key == key {
+			chain = append(chain, event)
+		}
+	}
+	return chain
+}
+
+// Render returns the resolution chain of key, one step per line, starting
+// with the provider that set it when known:
+//
+//	db.host: "${hosts.primary}" from file:config.yaml
+//	  pass 1 variables: "@file://host.txt" (3µs)
+//	  pass 1 uri: "db.local" (41µs)
+func (c *TraceCollector) Render(key string) string {
+	chain := c.Chain(key)
+	if len(chain) == 0 {
+		return ""
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s: %s", key, formatTraceValue(chain[0].Before))
+	if chain[0].Provider != "" {
+		fmt.Fprintf(&out, " from %s", chain[0].Provider)
+	}
+	out.WriteString("\n")
+	for _, event := range chain {
+		fmt.Fprintf(&out, "  pass %d %s: %s (%s)\n", event.Pass, event.Solver, formatTraceValue(event.After), event.Duration)
+	}
+	return out.String()
+}
+
+// String renders the chain of every traced key.
+func (c *TraceCollector) String() string {
+	var out strings.Builder
+	for _, key := range c.Keys() {
+		out.WriteString(c.Render(key))
+	}
+	return out.String()
+}
+
+func formatTraceValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "<unset>"
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
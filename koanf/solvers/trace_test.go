@@ -0,0 +1,104 @@
+package solvers
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namedSolver struct{}
+
+func (namedSolver) Solve(config *koanf.Koanf) *koanf.Koanf { return config }
+func (namedSolver) Name() string                           { return "custom" }
+
+func TestSolverName(t *testing.T) {
+	assert.Equal(t, "variables", SolverName(NewVariablesSolver("${", "}")))
+	assert.Equal(t, "uri", SolverName(NewURISolver("@", "://")))
+	assert.Equal(t, "expression", SolverName(NewExpressionSolver("{{", "}}")))
+	assert.Equal(t, "template", SolverName(NewTemplateSolver("", "")))
+	assert.Equal(t, "select", SolverName(NewSelectSolver("", "")))
+	assert.Equal(t, "graph", SolverName(NewGraphSolver()))
+	assert.Equal(t, "custom", SolverName(namedSolver{}))
+	assert.Equal(t, "*solvers.legacySolver", SolverName(&legacySolver{}))
+}
+
+func TestTraceSolve_RecordsChainAcrossSolvers(t *testing.T) {
+	fsys := fstest.MapFS{"host.txt": {Data: []byte("db.local\n")}}
+	k := loadContextTestConfig(t, map[string]any{
+		"hosts":   map[string]any{"primary": "@file://host.txt"},
+		"db":      map[string]any{"host": "${hosts.primary}"},
+		"unknown": "static",
+	})
+
+	collector := &TraceCollector{}
+	ordered := []ConfigSolver{
+		NewVariablesSolver("${", "}"),
+		NewURISolverWithFS("@", "://", fsys),
+	}
+	for _, solver := range ordered {
+		require.NoError(t, TraceSolve(context.Background(), solver, k, 1, collector.Record))
+	}
+
+	chain := collector.Chain("db.host")
+	require.Len(t, chain, 2)
+	assert.Equal(t, TraceEvent{Pass: 1, Solver: "variables", Key: "db.host", Before: "${hosts.primary}", After: "@file://host.txt", Duration: chain[0].Duration}, chain[0])
+	assert.Equal(t, "uri", chain[1].Solver)
+	assert.Equal(t, "@file://host.txt", chain[1].Before)
+	assert.Equal(t, "db.local", chain[1].After)
+
+	assert.Equal(t, []string{"db.host", "hosts.primary"}, collector.Keys())
+	assert.Empty(t, collector.Chain("unknown"))
+
+	rendered := collector.Render("db.host")
+	assert.Contains(t, rendered, "db.host: \"${hosts.primary}\"\n")
+	assert.Contains(t, rendered, "  pass 1 variables: \"@file://host.txt\" (")
+	assert.Contains(t, rendered, "  pass 1 uri: \"db.local\" (")
+	assert.Contains(t, collector.String(), "hosts.primary: \"@file://host.txt\"\n")
+
+	collector.Reset()
+	assert.Empty(t, collector.Events())
+}
+
+func TestTraceSolve_IncludeExpandsKey(t *testing.T) {
+	fsys := fstest.MapFS{"common.json": {Data: []byte(`{"host": "a"}`)}}
+	k := loadContextTestConfig(t, map[string]any{"shared": "@include://file://common.json"})
+
+	collector := &TraceCollector{}
+	require.NoError(t, TraceSolve(context.Background(), NewURISolverWithFS("@", "://", fsys), k, 1, collector.Record))
+
+	shared := collector.Chain("shared")
+	require.Len(t, shared, 1)
+	assert.Equal(t, map[string]any{"host": "a"}, shared[0].After)
+	added := collector.Chain("shared.host")
+	require.Len(t, added, 1)
+	assert.Nil(t, added[0].Before)
+	assert.Equal(t, "a", added[0].After)
+}
+
+func TestTraceSolve_GraphReportsKeySteps(t *testing.T) {
+	fsys := fstest.MapFS{"host.txt": {Data: []byte("db.local")}}
+	k := loadContextTestConfig(t, map[string]any{
+		"hosts": map[string]any{"primary": "@file://host.txt"},
+		"dsn":   "postgres://${hosts.primary}/app",
+	})
+
+	var events []TraceEvent
+	graph := NewGraphSolver(NewVariablesSolver("${", "}"), NewURISolverWithFS("@", "://", fsys))
+	require.NoError(t, TraceSolve(context.Background(), graph, k, 1, func(e TraceEvent) { events = append(events, e) }))
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "hosts.primary", events[0].Key)
+	assert.Equal(t, "uri", events[0].Solver)
+	assert.Equal(t, "dsn", events[1].Key)
+	assert.Equal(t, "variables", events[1].Solver)
+	assert.Equal(t, "postgres://db.local/app", events[1].After)
+
+	// tracing is only active during TraceSolve
+	events = nil
+	graph.Solve(loadContextTestConfig(t, map[string]any{"dsn": "${x}", "x": "1"}))
+	assert.Empty(t, events)
+}
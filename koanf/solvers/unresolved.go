@@ -0,0 +1,110 @@
+package solvers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// ErrCyclicReference is wrapped by resolution errors caused by reference cycles.
+var ErrCyclicReference = errors.New("cyclic reference")
+
+type UnresolvedReason string
+
+const (
+	UnresolvedMissingPath     UnresolvedReason = "missing_path"
+	UnresolvedUnknownProtocol UnresolvedReason = "unknown_protocol"
+	UnresolvedCycle           UnresolvedReason = "cycle"
+	UnresolvedFailed          UnresolvedReason = "resolve_failed"
+)
+
+// UnresolvedReferenceError describes a reference token left in a config value
+// after all solvers ran.
+type UnresolvedReferenceError struct {
+	Path   string
+	Token  string
+	Reason UnresolvedReason
+	Detail string
+}
+
+func (e *UnresolvedReferenceError) Error() string {
+	if e == nil {
+		return "unresolved reference"
+	}
+	msg := fmt.Sprintf("unresolved reference %q", e.Token)
+	if strings.TrimSpace(e.Path) != "" {
+		msg = fmt.Sprintf("%s at %s", msg, e.Path)
+	}
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Reason)
+	}
+	if strings.TrimSpace(e.Detail) != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Detail)
+	}
+	return msg
+}
+
+// ReferenceScanner is an optional extension for solvers whose syntax can be
+// left behind in config values. Escaped tokens (the start delimiter prefixed
+// with a backslash, e.g. \${literal}) are skipped by Solve and
+// UnresolvedReferences, and turned into literals by UnescapeReferences.
+type ReferenceScanner interface {
+	UnresolvedReferences(config *koanf.Koanf) []*UnresolvedReferenceError
+	UnescapeReferences(config *koanf.Koanf)
+}
+
+// escapeCharacter prefixes a start delimiter to keep the token literal.
+// Doubling the first character instead would collide with existing syntax
+// such as mustache triple braces ({{{ body }}}) or shell escapes ($${HOME}).
+const escapeCharacter = `\`
+
+// escapedDelimiter returns the escaped form of a start delimiter, for example
+// "${" becomes "\${".
+func escapedDelimiter(start string) string {
+	if start == "" {
+		return ""
+	}
+	return escapeCharacter + start
+}
+
+// unescapeStrings rewrites every string leaf using fn.
+func unescapeStrings(config *koanf.Koanf, fn func(string) string) {
+	if config == nil {
+		return
+	}
+	for key, val := range config.All() {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		if next := fn(str); next != str {
+			config.Set(key, next)
+		}
+	}
+}
+
+// sortedStringLeaves returns string leaves ordered by key for deterministic
+// scans. $assert entries are left to the assert solver.
+func sortedStringLeaves(config *koanf.Koanf) ([]string, map[string]string) {
+	values := map[string]string{}
+	if config == nil {
+		return nil, values
+	}
+	for key, val := range config.All() {
+		if isAssertKey(key, config.Delim()) {
+			continue
+		}
+		if str, ok := val.(string); ok {
+			values[key] = str
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, values
+}
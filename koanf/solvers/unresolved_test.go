@@ -0,0 +1,161 @@
+package solvers
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadUnresolvedTestConfig(t *testing.T, values map[string]any) *koanf.Koanf {
+	t.Helper()
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(values, "."), nil))
+	return k
+}
+
+func TestVariablesUnresolvedReferences(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"host":    "localhost",
+		"missing": "${nope}",
+		"self":    "${self}",
+		"a":       "${b}",
+		"b":       "${a}",
+		"env":     "${env:GO_CONFIG_TEST_UNSET_VAR}",
+		"escaped": `\${host}`,
+		"ok":      "${host}",
+	})
+
+	solver := NewVariablesSolver("${", "}")
+	solver.Solve(k)
+
+	refs := solver.(ReferenceScanner).UnresolvedReferences(k)
+	byPath := map[string]*UnresolvedReferenceError{}
+	for _, ref := range refs {
+		byPath[ref.Path] = ref
+	}
+
+	require.Len(t, refs, 5)
+	assert.Equal(t, UnresolvedMissingPath, byPath["missing"].Reason)
+	assert.Equal(t, "${nope}", byPath["missing"].Token)
+	assert.Equal(t, UnresolvedCycle, byPath["self"].Reason)
+	assert.Equal(t, "self -> self", byPath["self"].Detail)
+	assert.Equal(t, UnresolvedCycle, byPath["a"].Reason)
+	assert.Equal(t, UnresolvedCycle, byPath["b"].Reason)
+	assert.Equal(t, UnresolvedFailed, byPath["env"].Reason)
+	assert.Contains(t, byPath["env"].Detail, "GO_CONFIG_TEST_UNSET_VAR")
+	assert.NotContains(t, byPath, "escaped")
+}
+
+func TestVariablesEscapeSyntax(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"host":    "localhost",
+		"escaped": `literal \${host} and ${host}`,
+	})
+
+	solver := NewVariablesSolver("${", "}")
+	solver.Solve(k)
+	assert.Equal(t, `literal \${host} and localhost`, k.String("escaped"))
+
+	solver.(ReferenceScanner).UnescapeReferences(k)
+	assert.Equal(t, "literal ${host} and localhost", k.String("escaped"))
+}
+
+func TestURIUnresolvedReferences(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"unknown": "@vault://db/pass",
+		"missing": "@file://missing.txt",
+		"cycle":   "@include://include://file://loop.json",
+		"escaped": `\@file://version.txt`,
+		"email":   `\@handle`,
+	})
+
+	solver := NewURISolverWithFS("@", "://", fstest.MapFS{})
+	solver.Solve(k)
+
+	refs := solver.(ReferenceScanner).UnresolvedReferences(k)
+	byPath := map[string]*UnresolvedReferenceError{}
+	for _, ref := range refs {
+		byPath[ref.Path] = ref
+	}
+
+	require.Len(t, refs, 3)
+	assert.Equal(t, UnresolvedUnknownProtocol, byPath["unknown"].Reason)
+	assert.Equal(t, UnresolvedFailed, byPath["missing"].Reason)
+	assert.Equal(t, UnresolvedFailed, byPath["cycle"].Reason)
+
+	solver.(ReferenceScanner).UnescapeReferences(k)
+	assert.Equal(t, "@file://version.txt", k.String("escaped"))
+	assert.Equal(t, `\@handle`, k.String("email"))
+}
+
+func TestURIUnresolvedReferences_cycle(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"cycle": "@include://loop://a",
+	})
+
+	var solver ConfigSolver
+	solver = NewURISolverWithFSAndOptions("@", "://", fstest.MapFS{},
+		WithURIProtocolResolver("loop", func(uri string, state *uriResolveState) (any, error) {
+			return solver.(*uris).resolveIncludeProtocol("loop://a", state)
+		}),
+	)
+	solver.Solve(k)
+
+	refs := solver.(ReferenceScanner).UnresolvedReferences(k)
+	require.Len(t, refs, 1)
+	assert.Equal(t, UnresolvedCycle, refs[0].Reason)
+}
+
+func TestExpressionUnresolvedReferences(t *testing.T) {
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"broken":   "{{ missing_fn() }}",
+		"embedded": "prefix {{ 1 + 1 }}",
+		"escaped":  `\{{ literal }}`,
+		"mustache": "<p>{{{ body }}}</p>",
+		"ok":       "{{ 1 + 1 }}",
+	})
+
+	solver := NewExpressionSolver("{{", "}}")
+	solver.Solve(k)
+
+	refs := solver.(ReferenceScanner).UnresolvedReferences(k)
+	byPath := map[string]*UnresolvedReferenceError{}
+	for _, ref := range refs {
+		byPath[ref.Path] = ref
+	}
+
+	require.Len(t, refs, 3)
+	assert.Equal(t, UnresolvedFailed, byPath["broken"].Reason)
+	assert.NotEmpty(t, byPath["broken"].Detail)
+	assert.Equal(t, UnresolvedFailed, byPath["embedded"].Reason)
+	assert.Equal(t, UnresolvedFailed, byPath["mustache"].Reason)
+
+	solver.(ReferenceScanner).UnescapeReferences(k)
+	assert.Equal(t, "{{ literal }}", k.String("escaped"))
+	assert.Equal(t, "<p>{{{ body }}}</p>", k.String("mustache"), "triple braces are not an escape")
+}
+
+func TestURIUnresolvedReferences_usesSolveErrors(t *testing.T) {
+	calls := 0
+	k := loadUnresolvedTestConfig(t, map[string]any{
+		"token": "@vault://db/pass",
+	})
+	solver := NewURISolverWithFSAndOptions("@", "://", fstest.MapFS{},
+		WithURIProtocolResolver("vault", func(uri string, _ *uriResolveState) (any, error) {
+			calls++
+			return nil, errors.New("vault sealed")
+		}),
+	)
+	solver.Solve(k)
+
+	refs := solver.(ReferenceScanner).UnresolvedReferences(k)
+	require.Len(t, refs, 1)
+	assert.Equal(t, UnresolvedFailed, refs[0].Reason)
+	assert.Equal(t, "vault sealed", refs[0].Detail)
+	assert.Equal(t, 1, calls, "diagnostics must not resolve again")
+}
@@ -4,12 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/knadh/koanf/v2"
@@ -19,6 +20,10 @@ import (
 
 type ProtocolResolver func(uri string, state *uriResolveState) (any, error)
 
+// ContextProtocolResolver resolves a URI with the context of the solve, so
+// network or process backed protocols stop when Load times out.
+type ContextProtocolResolver func(ctx context.Context, uri string) (any, error)
+
 type URIErrorStrategy int
 
 const (
@@ -34,6 +39,72 @@ type uris struct {
 	resolvers     map[string]ProtocolResolver
 	newStorager   func(conn string) (storageReader, error)
 	errorStrategy URIErrorStrategy
+	// builtin lists the protocols still served by registerDefaultResolvers,
+	// which are bound to their solver and registered again on clones.
+	builtin map[string]struct{}
+	// sensitiveProtocols resolve to values that must not leak, e.g. enc.
+	sensitiveProtocols map[string]struct{}
+	onSensitive        func(key string)
+	// ctx bounds resolvers that do I/O, such as storage and exec.
+	ctx  context.Context
+	http HTTPOptions
+	// cache keeps resolved values across solves when set.
+	cache *URICache
+	// run is shared by the copies made by value receivers.
+	run *uriRun
+}
+
+// uriRun records the resolver error of every key that failed during the last
+// solve, for UnresolvedReferences, and the resolve state shared by the keys
+// of a graph sweep.
+type uriRun struct {
+	failures map[string]error
+	sweep    *uriResolveState
+}
+
+func (r *uriRun) reset() {
+	if r != nil {
+		r.failures = map[string]error{}
+	}
+}
+
+func (r *uriRun) record(key string, err error) {
+	if r == nil {
+		return
+	}
+	if r.failures == nil {
+		r.failures = map[string]error{}
+	}
+	if err == nil {
+		delete(r.failures, key)
+		return
+	}
+	r.failures[key] = err
+}
+
+func (r *uriRun) failure(key string) error {
+	if r == nil {
+		return nil
+	}
+	return r.failures[key]
+}
+
+// decryptionErr returns the recorded failures wrapping ErrDecryptionFailed.
+func (r *uriRun) decryptionErr() error {
+	if r == nil {
+		return nil
+	}
+	var keys []string
+	for key, err := range r.failures {
+		if errors.Is(err, ErrDecryptionFailed) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	return &DecryptionError{Keys: keys, Err: r.failures[keys[0]]}
 }
 
 type storageReader interface {
@@ -41,10 +112,17 @@ type storageReader interface {
 }
 
 type uriResolveState struct {
+	ctx             context.Context
+	http            HTTPOptions
 	storagersByConn map[string]storageReader
 	valuesByURI     map[string]string
 	includeByURI    map[string]any
 	includePending  map[string]struct{}
+	// cache is carried in the state because resolver closures capture the
+	// solver they were registered on, not its clones.
+	cache *URICache
+	// files lists the file:// paths read so far, for URICache dependencies.
+	files []string
 }
 
 // NewURISolver will resolve variables
@@ -70,6 +148,7 @@ func NewURISolverWithFSAndOptions(s, e string, f fs.FS, opts ...URISolverOption)
 		resolvers:     map[string]ProtocolResolver{},
 		newStorager:   newStorageReader,
 		errorStrategy: URIErrorLeaveUnchanged,
+		run:           &uriRun{},
 	}
 	solver.registerDefaultResolvers()
 	for _, opt := range opts {
@@ -101,6 +180,96 @@ func WithURIProtocolResolver(protocol string, resolver ProtocolResolver) URISolv
 	}
 }
 
+// WithURIContextProtocolResolver registers resolver for protocol. The
+// resolver receives the SolveContext context, or the WithURIContext one for
+// Solve.
+func WithURIContextProtocolResolver(protocol string, resolver ContextProtocolResolver) URISolverOption {
+	return func(s *uris) {
+		if resolver == nil {
+			return
+		}
+		s.registerResolver(protocol, func(uri string, state *uriResolveState) (any, error) {
+			ctx := context.Background()
+			if state != nil && state.ctx != nil {
+				ctx = state.ctx
+			}
+			return resolver(ctx, uri)
+		})
+	}
+}
+
+// WithURIContext bounds resolvers that do I/O, such as storage and exec, by
+// ctx, typically the context passed to Load.
+func WithURIContext(ctx context.Context) URISolverOption {
+	return func(s *uris) {
+		s.ctx = ctx
+	}
+}
+
+// WithURISensitiveKeyHandler calls fn with the key of every value resolved
+// through a sensitive protocol, directly or through include.
+func WithURISensitiveKeyHandler(fn func(key string)) URISolverOption {
+	return func(s *uris) {
+		s.onSensitive = fn
+	}
+}
+
+// ReplaceURISolverOptions returns a copy of solver with opts applied when
+// solver is a URI solver. Other solvers are returned unchanged with ok=false.
+func ReplaceURISolverOptions(solver ConfigSolver, opts ...URISolverOption) (updated ConfigSolver, ok bool) {
+	uriSolver, ok := solver.(*uris)
+	if !ok || len(opts) == 0 {
+		return solver, false
+	}
+	clone := uriSolver.clone()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(clone)
+	}
+	return clone, true
+}
+
+// WithURIFS reads file:// targets, and the documents they include, from f.
+func WithURIFS(f fs.FS) URISolverOption {
+	return func(s *uris) {
+		if f != nil {
+			s.fs = f
+		}
+	}
+}
+
+// URISolverFS returns the file system solver reads file:// targets from when
+// solver is a URI solver.
+func URISolverFS(solver ConfigSolver) (fs.FS, bool) {
+	uriSolver, ok := solver.(*uris)
+	if !ok || uriSolver.fs == nil {
+		return nil, false
+	}
+	return uriSolver.fs, true
+}
+
+func (s *uris) clone() *uris {
+	clone := *s
+	clone.delimeters = &delimiters{Start: s.delimeters.Start, End: s.delimeters.End}
+	clone.resolvers = make(map[string]ProtocolResolver, len(s.resolvers))
+	clone.builtin = nil
+	clone.registerDefaultResolvers()
+	for protocol, resolver := range s.resolvers {
+		if _, ok := s.builtin[protocol]; !ok {
+			clone.registerResolver(protocol, resolver)
+		}
+	}
+	clone.http.Header = s.http.Header.Clone()
+	clone.sensitiveProtocols = make(map[string]struct{}, len(s.sensitiveProtocols))
+	for protocol := range s.sensitiveProtocols {
+		clone.sensitiveProtocols[protocol] = struct{}{}
+	}
+	clone.run = &uriRun{}
+	return &clone
+}
+
 func newStorageReader(conn string) (storageReader, error) {
 	return services.NewStoragerFromString(conn)
 }
@@ -118,17 +287,51 @@ func (s *uris) registerDefaultResolvers() {
 	s.registerResolver("include", func(uri string, state *uriResolveState) (any, error) {
 		return s.resolveIncludeProtocol(uri, state)
 	})
+	for _, format := range includeFormats {
+		s.registerResolver("include+"+format, func(uri string, state *uriResolveState) (any, error) {
+			return s.resolveInclude(uri, format, state)
+		})
+	}
+	s.builtin = make(map[string]struct{}, len(s.resolvers))
+	for protocol := range s.resolvers {
+		s.builtin[protocol] = struct{}{}
+	}
 }
 
 func (s *uris) registerResolver(protocol string, resolver ProtocolResolver) {
 	if protocol == "" || resolver == nil {
 		return
 	}
+	delete(s.builtin, protocol)
 	s.resolvers[protocol] = resolver
 }
 
+func (s *uris) markSensitiveProtocol(protocol string) {
+	if s.sensitiveProtocols == nil {
+		s.sensitiveProtocols = map[string]struct{}{}
+	}
+	s.sensitiveProtocols[protocol] = struct{}{}
+}
+
+// isSensitive reports whether protocol, or the protocol wrapped by an
+// include, resolves sensitive values.
+func (s uris) isSensitive(protocol, uri string) bool {
+	if _, ok := s.sensitiveProtocols[protocol]; ok {
+		return true
+	}
+	if _, include := includeProtocolFormat(protocol); !include {
+		return false
+	}
+	inner, innerURI, err := parseProtocolURI(uri, s.delimeters.End)
+	if err != nil {
+		return false
+	}
+	return s.isSensitive(inner, innerURI)
+}
+
 func newURIResolveState() *uriResolveState {
 	return &uriResolveState{
+		ctx:             context.Background(),
 		storagersByConn: map[string]storageReader{},
 		valuesByURI:     map[string]string{},
 		includeByURI:    map[string]any{},
@@ -136,35 +339,132 @@ func newURIResolveState() *uriResolveState {
 	}
 }
 
+func (s uris) newResolveState() *uriResolveState {
+	state := newURIResolveState()
+	if s.ctx != nil {
+		state.ctx = s.ctx
+	}
+	state.http = s.http
+	state.cache = s.cache
+	return state
+}
+
 // Solve will transform a configuration object
 func (s uris) Solve(config *koanf.Koanf) *koanf.Koanf {
-	c := config.All()
-	state := newURIResolveState()
+	s.solve(config, s.newResolveState())
+	return config
+}
+
+// SolveContext resolves URIs with ctx bounding resolvers that do I/O. It
+// returns ctx.Err() when the context ended during the solve, and a
+// *DecryptionError when an enc value failed to decrypt. Other resolver errors
+// follow the URIErrorStrategy.
+func (s uris) SolveContext(ctx context.Context, config *koanf.Koanf) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	state := s.newResolveState()
+	state.ctx = ctx
+	s.solve(config, state)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Err()
+}
 
-	for key, val := range c {
+// Err implements ErrorReporter. It reports the decryption failures of the
+// last solve or graph sweep, which fail Load whatever the URIErrorStrategy.
+func (s uris) Err() error {
+	return s.run.decryptionErr()
+}
+
+func (s uris) solve(config *koanf.Koanf, state *uriResolveState) {
+	s.run.reset()
+	for key, val := range config.All() {
 		v2, ok := val.(string)
 		if !ok {
 			continue
 		}
 		s.keypath(key, v2, config, state)
 	}
+}
 
-	return config
+// SolveKey resolves the URI in a single key. Keys of the same graph sweep
+// share included documents and include cycle detection.
+func (s uris) SolveKey(key string, config *koanf.Koanf) {
+	if val, ok := config.Get(key).(string); ok {
+		s.keypath(key, val, config, s.keyResolveState(nil))
+	}
+}
+
+// SolveKeyContext implements ContextKeySolver.
+func (s uris) SolveKeyContext(ctx context.Context, key string, config *koanf.Koanf) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if val, ok := config.Get(key).(string); ok {
+		s.keypath(key, val, config, s.keyResolveState(ctx))
+	}
+	return ctx.Err()
+}
+
+// beginSweep implements sweepSolver.
+func (s uris) beginSweep(ctx context.Context) {
+	if s.run == nil {
+		return
+	}
+	s.run.reset()
+	s.run.sweep = s.newResolveState()
+	if ctx != nil {
+		s.run.sweep.ctx = ctx
+	}
+}
+
+// endSweep implements sweepSolver.
+func (s uris) endSweep() {
+	if s.run != nil {
+		s.run.sweep = nil
+	}
+}
+
+// keyResolveState returns the state of the current graph sweep, or a new
+// state outside of one.
+func (s uris) keyResolveState(ctx context.Context) *uriResolveState {
+	if s.run != nil && s.run.sweep != nil {
+		return s.run.sweep
+	}
+	state := s.newResolveState()
+	if ctx != nil {
+		state.ctx = ctx
+	}
+	return state
+}
+
+// References returns nil: URI values do not reference other config keys.
+func (s uris) References(_ string, _ string, _ *koanf.Koanf) []string {
+	return nil
 }
 
 func (s uris) keypath(key, val string, config *koanf.Koanf, state *uriResolveState) {
+	if s.isEscaped(val) {
+		return
+	}
 	protocol, uri, ok := s.extractProtocolURI(val)
 	if !ok {
 		return
 	}
 	content, err := s.resolveByProtocol(protocol, uri, state)
+	s.run.record(key, err)
 	if err != nil {
 		if s.errorStrategy == URIErrorRemoveKey {
 			config.Delete(key)
 		}
 		return
 	}
-	config.Set(key, content)
+	setValue(config, key, content)
+	if s.onSensitive != nil && s.isSensitive(protocol, uri) {
+		s.onSensitive(key)
+	}
 }
 
 func SolveFileProtocol(f fs.FS, uri string) (string, error) {
@@ -222,7 +522,7 @@ func (s *uris) resolveStorageProtocol(uri string, state *uriResolveState) (strin
 	}
 
 	var out bytes.Buffer
-	_, err = store.ReadWithContext(context.Background(), objectPath, &out)
+	_, err = store.ReadWithContext(state.ctx, objectPath, &out)
 	if err != nil {
 		return "", err
 	}
@@ -234,35 +534,49 @@ func (s *uris) resolveStorageProtocol(uri string, state *uriResolveState) (strin
 }
 
 func (s *uris) resolveIncludeProtocol(uri string, state *uriResolveState) (any, error) {
+	return s.resolveInclude(uri, "", state)
+}
+
+// resolveInclude resolves the nested URI of an include, parses it as format,
+// or by the file extension when format is empty, and selects the #fragment.
+func (s *uris) resolveInclude(uri, format string, state *uriResolveState) (any, error) {
 	if state == nil {
 		state = newURIResolveState()
 	}
-	if cached, ok := state.includeByURI[uri]; ok {
-		return cached, nil
-	}
-	if _, pending := state.includePending[uri]; pending {
-		return nil, fmt.Errorf("cyclic include uri %q", uri)
-	}
 
 	protocol, innerURI, err := parseProtocolURI(uri, s.delimeters.End)
 	if err != nil {
 		return nil, err
 	}
+	documentURI, fragment := splitIncludeFragment(protocol, innerURI)
+	if format == "" {
+		format = inferIncludeFormat(documentURI)
+	}
+	cacheKey := format + "+" + protocol + s.delimeters.End + documentURI
 
-	state.includePending[uri] = struct{}{}
-	defer delete(state.includePending, uri)
+	parsed, cached := state.includeByURI[cacheKey]
+	if !cached {
+		if _, pending := state.includePending[cacheKey]; pending {
+			return nil, fmt.Errorf("%w: include uri %q", ErrCyclicReference, uri)
+		}
+		state.includePending[cacheKey] = struct{}{}
+		defer delete(state.includePending, cacheKey)
 
-	rawValue, err := s.resolveByProtocol(protocol, innerURI, state)
-	if err != nil {
-		return nil, err
+		rawValue, err := s.resolveByProtocol(protocol, documentURI, state)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err = decodeIncludedValue(rawValue, format)
+		if err != nil {
+			return nil, err
+		}
+		state.includeByURI[cacheKey] = parsed
 	}
 
-	parsed, err := decodeIncludedValue(rawValue)
-	if err != nil {
-		return nil, err
+	if fragment == "" {
+		return parsed, nil
 	}
-	state.includeByURI[uri] = parsed
-	return parsed, nil
+	return selectIncludeFragment(parsed, fragment)
 }
 
 func (s uris) resolveByProtocol(protocol, uri string, state *uriResolveState) (any, error) {
@@ -270,7 +584,81 @@ func (s uris) resolveByProtocol(protocol, uri string, state *uriResolveState) (a
 	if !ok {
 		return nil, fmt.Errorf("unknown uri protocol %q", protocol)
 	}
-	return resolver(uri, state)
+	if state == nil {
+		state = s.newResolveState()
+	}
+	if state.cache == nil {
+		return resolver(uri, state)
+	}
+	return state.cache.resolve(protocol, uri, state, func() (any, error) {
+		if protocol == "file" {
+			state.cache.trackFile(s.fs, uri, state)
+		}
+		return resolver(uri, state)
+	})
+}
+
+// UnresolvedReferences reports URI values that are still present in config
+// values, with the resolver error recorded by the last solve.
+func (s uris) UnresolvedReferences(config *koanf.Koanf) []*UnresolvedReferenceError {
+	keys, values := sortedStringLeaves(config)
+	var out []*UnresolvedReferenceError
+
+	for _, key := range keys {
+		value := values[key]
+		if s.isEscaped(value) {
+			continue
+		}
+		protocol, _, ok := s.extractProtocolURI(value)
+		if !ok {
+			continue
+		}
+
+		ref := &UnresolvedReferenceError{
+			Path:  key,
+			Token: value,
+		}
+		if _, known := s.resolvers[protocol]; !known {
+			ref.Reason = UnresolvedUnknownProtocol
+			ref.Detail = fmt.Sprintf("unknown uri protocol %q", protocol)
+			out = append(out, ref)
+			continue
+		}
+
+		ref.Reason = UnresolvedFailed
+		if err := s.run.failure(key); err != nil {
+			if errors.Is(err, ErrCyclicReference) {
+				ref.Reason = UnresolvedCycle
+			}
+			ref.Detail = err.Error()
+		}
+		out = append(out, ref)
+	}
+
+	return out
+}
+
+// UnescapeReferences turns escaped values (\@file://x) into literals (@file://x).
+func (s uris) UnescapeReferences(config *koanf.Koanf) {
+	escape := escapedDelimiter(s.delimeters.Start)
+	if escape == "" {
+		return
+	}
+	unescapeStrings(config, func(value string) string {
+		if !strings.HasPrefix(value, escape) {
+			return value
+		}
+		literal := value[len(escapeCharacter):]
+		if _, _, ok := s.extractProtocolURI(literal); !ok {
+			return value
+		}
+		return literal
+	})
+}
+
+func (s uris) isEscaped(value string) bool {
+	escape := escapedDelimiter(s.delimeters.Start)
+	return escape != "" && strings.HasPrefix(value, escape)
 }
 
 func (s uris) extractProtocolURI(value string) (protocol string, uri string, ok bool) {
@@ -313,28 +701,6 @@ func parseProtocolURI(raw string, delimiter string) (protocol string, uri string
 	return protocol, uri, nil
 }
 
-func decodeIncludedValue(value any) (any, error) {
-	switch v := value.(type) {
-	case nil:
-		return nil, fmt.Errorf("include payload is nil")
-	case string:
-		return parseJSON(v)
-	case []byte:
-		return parseJSON(string(v))
-	default:
-		// If a custom resolver already returned an object, accept it.
-		return v, nil
-	}
-}
-
-func parseJSON(input string) (any, error) {
-	var out any
-	if err := json.Unmarshal([]byte(strings.TrimSpace(input)), &out); err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
 func parseStorageURI(uri string) (conn string, objectPath string, err error) {
 	parts := strings.SplitN(strings.TrimSpace(uri), "#", 2)
 	if len(parts) != 2 {
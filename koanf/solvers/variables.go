@@ -1,23 +1,154 @@
 package solvers
 
 import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
 	"strings"
 
 	"github.com/knadh/koanf/v2"
 )
 
+const (
+	VariableNamespaceEnv  = "env"
+	VariableNamespaceFile = "file"
+
+	variableNamespaceSeparator = ":"
+)
+
+// NamespaceResolver resolves a namespaced variable reference such as
+// ${env:PGUSER}. It receives the reference without the namespace prefix.
+// Returning an error leaves the token unresolved.
+type NamespaceResolver func(ref string) (any, error)
+
+type VariablesSolverOption func(*variables)
+
 type variables struct {
 	delimeters *delimiters
+	fs         fs.FS
+	namespaces map[string]NamespaceResolver
+	// defaultFile is set while the file namespace reads from fs, so clones
+	// bind it to their own fs.
+	defaultFile bool
 }
 
 // NewVariablesSolver will resolve variables
 func NewVariablesSolver(s, e string) ConfigSolver {
-	return &variables{
+	return NewVariablesSolverWithOptions(s, e)
+}
+
+// NewVariablesSolverWithOptions will resolve variables with custom namespaces
+// and file system. The env and file namespaces are registered by default.
+func NewVariablesSolverWithOptions(s, e string, opts ...VariablesSolverOption) ConfigSolver {
+	solver := &variables{
 		delimeters: &delimiters{
 			Start: s,
 			End:   e,
 		},
+		fs:         os.DirFS("."),
+		namespaces: map[string]NamespaceResolver{},
+	}
+	solver.registerDefaultNamespaces()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(solver)
+	}
+	return solver
+}
+
+// WithVariablesFS sets the file system used by the file namespace.
+func WithVariablesFS(f fs.FS) VariablesSolverOption {
+	return func(s *variables) {
+		if f == nil {
+			return
+		}
+		s.fs = f
+	}
+}
+
+// WithVariableNamespace registers or replaces a namespace resolver, for
+// example WithVariableNamespace("vault", resolver) enables ${vault:db/pass}.
+// A nil resolver removes the namespace.
+func WithVariableNamespace(namespace string, resolver NamespaceResolver) VariablesSolverOption {
+	return func(s *variables) {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" {
+			return
+		}
+		if namespace == VariableNamespaceFile {
+			s.defaultFile = false
+		}
+		if resolver == nil {
+			delete(s.namespaces, namespace)
+			return
+		}
+		s.namespaces[namespace] = resolver
+	}
+}
+
+// ReplaceVariablesSolverOptions returns a copy of solver with opts applied
+// when solver is a variables solver. Other solvers are returned unchanged
+// with ok=false.
+func ReplaceVariablesSolverOptions(solver ConfigSolver, opts ...VariablesSolverOption) (updated ConfigSolver, ok bool) {
+	varSolver, ok := solver.(*variables)
+	if !ok || len(opts) == 0 {
+		return solver, false
+	}
+	clone := varSolver.clone()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(clone)
 	}
+	return clone, true
+}
+
+func (s *variables) clone() *variables {
+	clone := *s
+	clone.delimeters = &delimiters{Start: s.delimeters.Start, End: s.delimeters.End}
+	clone.namespaces = make(map[string]NamespaceResolver, len(s.namespaces))
+	for namespace, resolver := range s.namespaces {
+		clone.namespaces[namespace] = resolver
+	}
+	if clone.defaultFile {
+		clone.registerFileNamespace()
+	}
+	return &clone
+}
+
+func (s *variables) registerDefaultNamespaces() {
+	s.namespaces[VariableNamespaceEnv] = SolveEnvNamespace
+	s.registerFileNamespace()
+}
+
+func (s *variables) registerFileNamespace() {
+	s.defaultFile = true
+	s.namespaces[VariableNamespaceFile] = func(ref string) (any, error) {
+		return SolveFileProtocol(s.fs, ref)
+	}
+}
+
+// SolveEnvNamespace returns the value of the named environment variable.
+// Unset variables are reported as errors so the token stays unresolved.
+func SolveEnvNamespace(name string) (any, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("invalid env variable name %q", name)
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("env variable %q is not set", name)
+	}
+	return value, nil
+}
+
+// SolveContext implements ContextSolver.
+func (s variables) SolveContext(ctx context.Context, config *koanf.Koanf) error {
+	return solveInMemory(ctx, s, config)
 }
 
 // Solve will transform a configuration object
@@ -36,6 +167,34 @@ func (s variables) Solve(config *koanf.Koanf) *koanf.Koanf {
 	return config
 }
 
+// SolveKey resolves the variables in a single key.
+func (s variables) SolveKey(key string, config *koanf.Koanf) {
+	if val, ok := config.Get(key).(string); ok {
+		s.keypath(key, val, config)
+	}
+}
+
+// References returns the config paths referenced by value. Escaped and
+// namespaced tokens are not config references.
+func (s variables) References(_ string, value string, _ *koanf.Koanf) []string {
+	var out []string
+	offset := 0
+	for {
+		token, ok := s.nextToken(value, offset)
+		if !ok {
+			return out
+		}
+		offset = token.end
+		if token.escaped || token.path == "" {
+			continue
+		}
+		if _, _, namespaced := s.namespace(token.path); namespaced {
+			continue
+		}
+		out = append(out, token.path)
+	}
+}
+
 func (s variables) keypath(key, val string, config *koanf.Koanf) {
 	current := val
 	visited := map[string]struct{}{
@@ -50,7 +209,7 @@ func (s variables) keypath(key, val string, config *koanf.Koanf) {
 		}
 		changed = true
 		if setDirect {
-			config.Set(key, directValue)
+			setValue(config, key, directValue)
 			return
 		}
 		if next == current {
@@ -69,42 +228,34 @@ func (s variables) keypath(key, val string, config *koanf.Koanf) {
 }
 
 func (s variables) replaceTokens(key, input string, config *koanf.Koanf) (string, bool, any, bool) {
-	startDelimiter := s.delimeters.Start
-	endDelimiter := s.delimeters.End
 	offset := 0
 	var out strings.Builder
 	out.Grow(len(input))
 	changed := false
 
 	for {
-		startIndex := strings.Index(input[offset:], startDelimiter)
-		if startIndex == -1 {
-			out.WriteString(input[offset:])
-			break
-		}
-		startIndex += offset
-
-		contentStart := startIndex + len(startDelimiter)
-		endOffset := strings.Index(input[contentStart:], endDelimiter)
-		if endOffset == -1 {
+		token, ok := s.nextToken(input, offset)
+		if !ok {
 			out.WriteString(input[offset:])
 			break
 		}
 
-		contentEnd := contentStart + endOffset
-		tokenEnd := contentEnd + len(endDelimiter)
-		path := input[contentStart:contentEnd]
+		out.WriteString(input[offset:token.start])
 
-		out.WriteString(input[offset:startIndex])
+		if token.escaped {
+			out.WriteString(input[token.start:token.end])
+			offset = token.end
+			continue
+		}
 
-		if path == "" || path == key || !config.Exists(path) {
-			out.WriteString(input[startIndex:tokenEnd])
-			offset = tokenEnd
+		resolved, found := s.lookup(key, token.path, config)
+		if !found {
+			out.WriteString(input[token.start:token.end])
+			offset = token.end
 			continue
 		}
 
-		resolved := config.Get(path)
-		isFullMatch := startIndex == 0 && tokenEnd == len(input)
+		isFullMatch := token.start == 0 && token.end == len(input)
 		if isFullMatch {
 			if resolvedStr, ok := resolved.(string); ok {
 				if resolvedStr == input {
@@ -117,7 +268,7 @@ func (s variables) replaceTokens(key, input string, config *koanf.Koanf) (string
 
 		out.WriteString(ToString(resolved))
 		changed = true
-		offset = tokenEnd
+		offset = token.end
 	}
 
 	next := out.String()
@@ -127,3 +278,144 @@ func (s variables) replaceTokens(key, input string, config *koanf.Koanf) (string
 
 	return next, changed, nil, false
 }
+
+func (s variables) nextToken(input string, offset int) (delimitedToken, bool) {
+	return nextDelimitedToken(input, offset, s.delimeters)
+}
+
+// UnresolvedReferences reports variable tokens that are still present in
+// config values, with the reason they could not be resolved.
+func (s variables) UnresolvedReferences(config *koanf.Koanf) []*UnresolvedReferenceError {
+	keys, values := sortedStringLeaves(config)
+	var out []*UnresolvedReferenceError
+
+	for _, key := range keys {
+		input := values[key]
+		offset := 0
+		for {
+			token, ok := s.nextToken(input, offset)
+			if !ok {
+				break
+			}
+			offset = token.end
+			if token.escaped {
+				continue
+			}
+			out = append(out, s.diagnose(key, input[token.start:token.end], token.path, config))
+		}
+	}
+
+	return out
+}
+
+func (s variables) diagnose(key, token, path string, config *koanf.Koanf) *UnresolvedReferenceError {
+	ref := &UnresolvedReferenceError{
+		Path:  key,
+		Token: token,
+	}
+
+	if resolver, nsRef, ok := s.namespace(path); ok {
+		ref.Reason = UnresolvedFailed
+		if _, err := resolver(nsRef); err != nil {
+			ref.Detail = err.Error()
+		}
+		return ref
+	}
+
+	if path == "" || !config.Exists(path) {
+		ref.Reason = UnresolvedMissingPath
+		ref.Detail = fmt.Sprintf("key %q does not exist", path)
+		return ref
+	}
+
+	if cycle := s.referenceCycle(key, path, config); len(cycle) > 0 {
+		ref.Reason = UnresolvedCycle
+		ref.Detail = strings.Join(cycle, " -> ")
+		return ref
+	}
+
+	ref.Reason = UnresolvedFailed
+	ref.Detail = fmt.Sprintf("key %q did not resolve to a value", path)
+	return ref
+}
+
+// referenceCycle returns the chain of keys that loops back on itself when
+// following references from key through path, or nil when there is no cycle.
+func (s variables) referenceCycle(key, path string, config *koanf.Koanf) []string {
+	visited := map[string]struct{}{}
+
+	var walk func(current string, chain []string) []string
+	walk = func(current string, chain []string) []string {
+		for _, seen := range chain {
+			if seen == current {
+				return append(chain, current)
+			}
+		}
+		if _, done := visited[current]; done {
+			return nil
+		}
+		visited[current] = struct{}{}
+		chain = append(chain, current)
+
+		value, ok := config.Get(current).(string)
+		if !ok {
+			return nil
+		}
+		offset := 0
+		for {
+			token, ok := s.nextToken(value, offset)
+			if !ok {
+				return nil
+			}
+			offset = token.end
+			if token.escaped || !config.Exists(token.path) {
+				continue
+			}
+			if found := walk(token.path, chain); found != nil {
+				return found
+			}
+		}
+	}
+
+	return walk(path, []string{key})
+}
+
+// UnescapeReferences turns escaped tokens (\${path}) into literals (${path}).
+func (s variables) UnescapeReferences(config *koanf.Koanf) {
+	escape := escapedDelimiter(s.delimeters.Start)
+	if escape == "" {
+		return
+	}
+	unescapeStrings(config, func(value string) string {
+		return strings.ReplaceAll(value, escape, s.delimeters.Start)
+	})
+}
+
+// lookup resolves a token body either through a registered namespace
+// (env:NAME, file:path) or as a key path in config.
+func (s variables) lookup(key, path string, config *koanf.Koanf) (any, bool) {
+	if resolver, ref, ok := s.namespace(path); ok {
+		resolved, err := resolver(ref)
+		if err != nil {
+			return nil, false
+		}
+		return resolved, true
+	}
+
+	if path == "" || path == key || !config.Exists(path) {
+		return nil, false
+	}
+	return config.Get(path), true
+}
+
+func (s variables) namespace(path string) (NamespaceResolver, string, bool) {
+	idx := strings.Index(path, variableNamespaceSeparator)
+	if idx <= 0 {
+		return nil, "", false
+	}
+	resolver, ok := s.namespaces[strings.TrimSpace(path[:idx])]
+	if !ok || resolver == nil {
+		return nil, "", false
+	}
+	return resolver, path[idx+len(variableNamespaceSeparator):], true
+}
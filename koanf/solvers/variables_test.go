@@ -1,11 +1,14 @@
 package solvers
 
 import (
+	"errors"
 	"testing"
+	"testing/fstest"
 
 	"github.com/knadh/koanf/providers/confmap"
 	"github.com/knadh/koanf/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestKSolver_Variables(t *testing.T) {
@@ -216,3 +219,107 @@ func TestKSolver_Variables_missingTokenContinues(t *testing.T) {
 
 	assert.Equal(t, "${missing}-5", out.Get("value"))
 }
+
+func TestKSolver_Variables_envNamespace(t *testing.T) {
+	t.Setenv("GO_CONFIG_TEST_PGUSER", "admin")
+
+	defaultValues := map[string]any{
+		"db": map[string]any{
+			"host": "db.internal",
+			"dsn":  "postgres://${env:GO_CONFIG_TEST_PGUSER}@${db.host}",
+			"user": "${env:GO_CONFIG_TEST_PGUSER}",
+		},
+		"missing": "${env:GO_CONFIG_TEST_NOT_SET}",
+	}
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(defaultValues, "."), nil)
+
+	solver := NewVariablesSolver("${", "}")
+	out := solver.Solve(k)
+
+	assert.Equal(t, "postgres://admin@db.internal", out.Get("db.dsn"))
+	assert.Equal(t, "admin", out.Get("db.user"))
+	assert.Equal(t, "${env:GO_CONFIG_TEST_NOT_SET}", out.Get("missing"))
+}
+
+func TestKSolver_Variables_fileNamespace(t *testing.T) {
+	defaultValues := map[string]any{
+		"version": "${file:testdata/version.txt}",
+		"banner":  "v${file:testdata/version.txt}-dev",
+		"escape":  "${file:../secret.txt}",
+	}
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(defaultValues, "."), nil)
+
+	testFS := fstest.MapFS{
+		"testdata/version.txt": &fstest.MapFile{Data: []byte("1.2.3\n")},
+	}
+	solver := NewVariablesSolverWithOptions("${", "}", WithVariablesFS(testFS))
+	out := solver.Solve(k)
+
+	assert.Equal(t, "1.2.3", out.Get("version"))
+	assert.Equal(t, "v1.2.3-dev", out.Get("banner"))
+	assert.Equal(t, "${file:../secret.txt}", out.Get("escape"))
+}
+
+func TestKSolver_Variables_replaceOptionsRebindsFileNamespace(t *testing.T) {
+	k := koanf.New(".")
+	k.Load(confmap.Provider(map[string]any{"version": "${file:version.txt}"}, "."), nil)
+
+	original := NewVariablesSolverWithOptions("${", "}", WithVariablesFS(fstest.MapFS{}))
+	updated, ok := ReplaceVariablesSolverOptions(original, WithVariablesFS(fstest.MapFS{
+		"version.txt": &fstest.MapFile{Data: []byte("2.0.0")},
+	}))
+	require.True(t, ok)
+
+	assert.Equal(t, "2.0.0", updated.Solve(k).Get("version"))
+
+	k.Set("version", "${file:version.txt}")
+	assert.Equal(t, "${file:version.txt}", original.Solve(k).Get("version"))
+}
+
+func TestKSolver_Variables_customNamespace(t *testing.T) {
+	defaultValues := map[string]any{
+		"password": "${vault:db/password}",
+		"port":     "${consts:port}",
+		"plain":    "${unknown:value}",
+	}
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(defaultValues, "."), nil)
+
+	solver := NewVariablesSolverWithOptions("${", "}",
+		WithVariableNamespace("vault", func(ref string) (any, error) {
+			if ref != "db/password" {
+				return nil, errors.New("not found")
+			}
+			return "s3cr3t", nil
+		}),
+		WithVariableNamespace("consts", func(ref string) (any, error) {
+			return 5432, nil
+		}),
+	)
+	out := solver.Solve(k)
+
+	assert.Equal(t, "s3cr3t", out.Get("password"))
+	assert.Equal(t, 5432, out.Get("port"))
+	assert.Equal(t, "${unknown:value}", out.Get("plain"))
+}
+
+func TestKSolver_Variables_disableNamespace(t *testing.T) {
+	t.Setenv("GO_CONFIG_TEST_PGUSER", "admin")
+
+	defaultValues := map[string]any{
+		"user": "${env:GO_CONFIG_TEST_PGUSER}",
+	}
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(defaultValues, "."), nil)
+
+	solver := NewVariablesSolverWithOptions("${", "}", WithVariableNamespace(VariableNamespaceEnv, nil))
+	out := solver.Solve(k)
+
+	assert.Equal(t, "${env:GO_CONFIG_TEST_PGUSER}", out.Get("user"))
+}